@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"example/models"
+	"example/services"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var errBookingNotFound = errors.New("booking not found")
+
+// backend is implemented once on top of the REST API and once directly on
+// top of the services package, so every command works in both modes
+type backend interface {
+	ListRooms(ctx context.Context) ([]models.Room, error)
+	CreateRoom(ctx context.Context, room *models.Room) error
+	GetBookingByCode(ctx context.Context, code string) (*models.BookingDTO, error)
+	CancelBooking(ctx context.Context, bookingID int) error
+	OccupancyReport(ctx context.Context, params models.ReportParamsDTO) ([]models.OccupancyReportRow, error)
+	Close(ctx context.Context) error
+}
+
+type apiBackend struct {
+	baseURL string
+	client  *http.Client
+	actor   string // sent as X-Actor, the server logs it with the requests
+	apiKey  string // sent as X-API-Key, optional
+}
+
+func newAPIBackend(baseURL string, apiKey string) *apiBackend {
+	actor := "hotelctl"
+	if u, err := user.Current(); err == nil {
+		actor += "/" + u.Username
+	}
+	return &apiBackend{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: 30 * time.Second},
+		actor:   actor,
+		apiKey:  apiKey,
+	}
+}
+
+// apiError is returned when the server answers with a non 2xx status
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e apiError) Error() string {
+	return fmt.Sprintf("server returned %d: %s", e.Status, e.Message)
+}
+
+// do sends a request with an optional JSON body and decodes the JSON response into out, if not nil
+func (a *apiBackend) do(ctx context.Context, method, path string, body any, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("X-Actor", a.actor)
+	if a.apiKey != "" {
+		req.Header.Set("X-API-Key", a.apiKey)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(resp.Body)
+		return apiError{Status: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (a *apiBackend) ListRooms(ctx context.Context) ([]models.Room, error) {
+	var rooms []models.Room
+	err := a.do(ctx, http.MethodGet, "/rooms", nil, &rooms)
+	return rooms, err
+}
+
+func (a *apiBackend) CreateRoom(ctx context.Context, room *models.Room) error {
+	return a.do(ctx, http.MethodPost, "/rooms", room, room)
+}
+
+func (a *apiBackend) GetBookingByCode(ctx context.Context, code string) (*models.BookingDTO, error) {
+	var booking models.BookingDTO
+	err := a.do(ctx, http.MethodGet, "/booking-codes/"+url.PathEscape(code), nil, &booking)
+	var apiErr apiError
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+		return nil, errBookingNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+func (a *apiBackend) CancelBooking(ctx context.Context, bookingID int) error {
+	err := a.do(ctx, http.MethodPost, fmt.Sprintf("/bookings/%d/cancel", bookingID), nil, nil)
+	var apiErr apiError
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+		return errBookingNotFound
+	}
+	return err
+}
+
+func (a *apiBackend) OccupancyReport(ctx context.Context, params models.ReportParamsDTO) ([]models.OccupancyReportRow, error) {
+	query := url.Values{}
+	query.Set("start_date", params.StartDate)
+	query.Set("end_date", params.EndDate)
+	query.Set("group_by", params.GroupBy)
+	if params.By != "" {
+		query.Set("by", params.By)
+	}
+	var rows []models.OccupancyReportRow
+	err := a.do(ctx, http.MethodGet, "/reports/occupancy?"+query.Encode(), nil, &rows)
+	return rows, err
+}
+
+func (a *apiBackend) Close(ctx context.Context) error {
+	return nil
+}
+
+// dbBackend skips the server and uses the services package, so the same validation rules apply
+type dbBackend struct {
+	conn *pgx.Conn
+}
+
+func (d *dbBackend) ListRooms(ctx context.Context) ([]models.Room, error) {
+	return services.GetAllRooms(ctx, d.conn)
+}
+
+func (d *dbBackend) CreateRoom(ctx context.Context, room *models.Room) error {
+	return services.CreateRoom(ctx, d.conn, room)
+}
+
+func (d *dbBackend) GetBookingByCode(ctx context.Context, code string) (*models.BookingDTO, error) {
+	booking, err := services.GetBookingByCode(ctx, d.conn, code)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errBookingNotFound
+		}
+		return nil, err
+	}
+	bookingDTO := booking.ToDTO()
+	return &bookingDTO, nil
+}
+
+func (d *dbBackend) CancelBooking(ctx context.Context, bookingID int) error {
+	_, err := services.CancelBookingByID(ctx, d.conn, bookingID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errBookingNotFound
+	}
+	return err
+}
+
+func (d *dbBackend) OccupancyReport(ctx context.Context, paramsDTO models.ReportParamsDTO) ([]models.OccupancyReportRow, error) {
+	params, err := paramsDTO.ToModel()
+	if err != nil {
+		return nil, err
+	}
+	report, err := services.GetKPIReport(ctx, d.conn, params)
+	if err != nil {
+		return nil, err
+	}
+	var rows []models.OccupancyReportRow
+	for _, row := range report {
+		rows = append(rows, row.ToOccupancyRow())
+	}
+	return rows, nil
+}
+
+func (d *dbBackend) Close(ctx context.Context) error {
+	return d.conn.Close(ctx)
+}
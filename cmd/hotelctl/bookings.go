@@ -0,0 +1,88 @@
+package main
+
+import (
+	"example/models"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+func newBookingsCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bookings",
+		Short: "Look up and cancel bookings",
+	}
+	cmd.AddCommand(newBookingsGetCmd(opts), newBookingsCancelCmd(opts))
+	return cmd
+}
+
+func newBookingsGetCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get CODE",
+		Short: "Look up a booking by its confirmation code",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			b, err := opts.backend(ctx)
+			if err != nil {
+				return err
+			}
+			defer b.Close(ctx)
+
+			booking, err := b.GetBookingByCode(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), booking, func(tw *tabwriter.Writer) {
+				printBookings(tw, []models.BookingDTO{*booking})
+			})
+		},
+	}
+}
+
+func newBookingsCancelCmd(opts *options) *cobra.Command {
+	var byCode bool
+	cmd := &cobra.Command{
+		Use:   "cancel ID",
+		Short: "Cancel a booking",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			b, err := opts.backend(ctx)
+			if err != nil {
+				return err
+			}
+			defer b.Close(ctx)
+
+			var bookingID int
+			if byCode {
+				booking, err := b.GetBookingByCode(ctx, args[0])
+				if err != nil {
+					return err
+				}
+				bookingID = booking.ID
+			} else {
+				bookingID, err = strconv.Atoi(args[0])
+				if err != nil {
+					return fmt.Errorf("invalid booking ID %q", args[0])
+				}
+			}
+			if err := b.CancelBooking(ctx, bookingID); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Cancelled booking %d\n", bookingID)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&byCode, "code", false, "treat the argument as a booking code instead of an ID")
+	return cmd
+}
+
+func printBookings(tw *tabwriter.Writer, bookings []models.BookingDTO) {
+	fmt.Fprintln(tw, "ID\tCODE\tCUSTOMER\tROOM\tSTART\tEND\tSTATUS")
+	for _, b := range bookings {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%s\t%s\t%s\n", b.ID, b.Code, b.CustomerID, b.RoomID, b.StartDate, b.EndDate, b.Status)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+)
+
+// tables that can be exported, the name ends up in a COPY statement so it must not come from the user as is
+var exportableTables = []string{"customer", "room", "booking_group", "booking", "booking_occupant", "review", "hotel_service", "service_request", "staff", "staff_shift", "housekeeping_task", "room_block", "booking_segment", "folio_charge", "booking_hold", "waitlist_entry", "notification", "scheduled_job", "job_run", "night_audit"}
+
+func newExportCmd(opts *options) *cobra.Command {
+	var outPath string
+	cmd := &cobra.Command{
+		Use:       "export TABLE",
+		Short:     "Export a table as CSV (db only)",
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: exportableTables,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			table := args[0]
+			if !slices.Contains(exportableTables, table) {
+				return fmt.Errorf("unknown table %q", table)
+			}
+			var out io.Writer = cmd.OutOrStdout()
+			if outPath != "" {
+				f, err := os.Create(outPath)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				out = f
+			}
+
+			ctx := cmd.Context()
+			conn, err := opts.connect(ctx)
+			if err != nil {
+				return err
+			}
+			defer conn.Close(ctx)
+
+			_, err = conn.PgConn().CopyTo(ctx, out, fmt.Sprintf("COPY %s TO STDOUT WITH (FORMAT csv, HEADER)", table))
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&outPath, "file", "f", "", "write the CSV to this file instead of stdout")
+	return cmd
+}
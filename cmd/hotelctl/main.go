@@ -0,0 +1,13 @@
+// hotelctl is a command line tool for the hotel staff. It can talk to a
+// running hotel server through its REST API or directly to the database.
+package main
+
+import (
+	"os"
+)
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
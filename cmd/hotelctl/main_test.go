@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"example/config"
+	"example/handlers"
+	"example/models"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testDBName   = "hotelctltestdb"
+	schemaPath   = "../../schema.sql"
+	populatePath = "../../populate.sql"
+	conn         *pgx.Conn
+	dbURL        string
+	apiURL       string
+	// the headers of the last request to the server, to check what the api backend sends
+	lastHeaderMu sync.Mutex
+	lastHeader   http.Header
+)
+
+// newTestServer serves the routes used by hotelctl with the handlers of the server, on the test database
+func newTestServer() *httptest.Server {
+	validator := validator.New()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rooms", handlers.GetAllRooms(conn))
+	mux.HandleFunc("POST /rooms", handlers.CreateRoom(conn, validator))
+	mux.HandleFunc("GET /booking-codes/{code}", handlers.GetBookingByCode(conn))
+	mux.HandleFunc("POST /bookings/{id}/cancel", handlers.CancelBookingByID(conn))
+	mux.HandleFunc("GET /reports/occupancy", handlers.GetOccupancyReport(conn, validator))
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastHeaderMu.Lock()
+		lastHeader = r.Header.Clone()
+		lastHeaderMu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+}
+
+func TestMain(m *testing.M) {
+	// connect to the admin database and create a test database of its own, the server tests use theirs
+	ctx := context.Background()
+	cfg, err := config.Load(nil)
+	if err != nil {
+		fmt.Println("Invalid configuration:", err)
+		os.Exit(1)
+	}
+	cfg.Database.Host = "localhost"
+	adminConn, err := pgx.Connect(ctx, cfg.Database.URL())
+	if err != nil {
+		fmt.Println("Unable to connect to admin database:", err)
+		os.Exit(1)
+	}
+	defer adminConn.Close(ctx)
+
+	_, err = adminConn.Exec(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s", testDBName))
+	if err != nil {
+		fmt.Println("Unable to drop test database:", err)
+		os.Exit(1)
+	}
+	_, err = adminConn.Exec(ctx, fmt.Sprintf("CREATE DATABASE %s", testDBName))
+	if err != nil {
+		fmt.Println("Unable to create test database:", err)
+		os.Exit(1)
+	}
+	cfg.Database.Name = testDBName
+	dbURL = cfg.Database.URL()
+	conn, err = pgx.Connect(ctx, dbURL)
+	if err != nil {
+		fmt.Println("Unable to connect to test database:", err)
+		os.Exit(1)
+	}
+	defer conn.Close(ctx)
+
+	// populate the database schema, the data comes from the seed command
+	sql, err := os.ReadFile(schemaPath)
+	if err != nil {
+		fmt.Printf("Unable to read %s: %v\n", schemaPath, err)
+		os.Exit(1)
+	}
+	_, err = conn.Exec(ctx, string(sql))
+	if err != nil {
+		fmt.Printf("Unable to execute %s: %v\n", schemaPath, err)
+		os.Exit(1)
+	}
+
+	server := newTestServer()
+	defer server.Close()
+	apiURL = server.URL
+
+	code := m.Run()
+
+	os.Exit(code)
+}
+
+// run executes hotelctl with args against the test database and returns what it printed
+func run(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	return runIn(t, "db", args...)
+}
+
+// runIn executes hotelctl with args in a mode, api through the test server or db directly on the test database
+func runIn(t *testing.T, mode string, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	cmd := newRootCmd()
+	cmd.SetArgs(append([]string{"--mode", mode, "--db-url", dbURL, "--api-url", apiURL}, args...))
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	err := cmd.ExecuteContext(context.Background())
+	return out.String(), err
+}
+
+func countRows(t *testing.T, table string) int {
+	t.Helper()
+	var count int
+	err := conn.QueryRow(context.Background(), fmt.Sprintf("SELECT count(*) FROM %s", table)).Scan(&count)
+	require.NoError(t, err)
+	return count
+}
+
+func TestSeed(t *testing.T) {
+	t.Run("seed - populate.sql", func(t *testing.T) {
+		out, err := run(t, "seed", populatePath)
+		require.NoError(t, err)
+		require.Contains(t, out, "Loaded "+populatePath)
+
+		out, err = run(t, "-o", "json", "rooms", "list")
+		require.NoError(t, err)
+		var rooms []models.Room
+		err = json.Unmarshal([]byte(out), &rooms)
+		require.NoError(t, err)
+		require.Len(t, rooms, 5)
+
+		out, err = run(t, "-o", "json", "bookings", "get", "PR001")
+		require.NoError(t, err)
+		var booking models.BookingDTO
+		err = json.Unmarshal([]byte(out), &booking)
+		require.NoError(t, err)
+		require.Equal(t, "PR001", booking.Code)
+	})
+	t.Run("seed - failing statement rolls back the file", func(t *testing.T) {
+		customers := countRows(t, "customer")
+		path := filepath.Join(t.TempDir(), "broken.sql")
+		sql := "INSERT INTO customer(cf, customer_name, age, email) VALUES ('SEEDCF123456', 'Seed', 30, 'seed@example.com');\n" +
+			"INSERT INTO no_such_table VALUES (1);\n"
+		err := os.WriteFile(path, []byte(sql), 0o600)
+		require.NoError(t, err)
+
+		_, err = run(t, "seed", path)
+		require.ErrorContains(t, err, "unable to execute "+path)
+		require.Equal(t, customers, countRows(t, "customer"))
+	})
+	t.Run("seed - missing file", func(t *testing.T) {
+		_, err := run(t, "seed", filepath.Join(t.TempDir(), "missing.sql"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestExport(t *testing.T) {
+	_, err := conn.Exec(context.Background(), "INSERT INTO room(room_number, room_type, price, capacity) VALUES (901, 'basic', 50, 2)")
+	require.NoError(t, err)
+
+	t.Run("export - table to a file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "rooms.csv")
+		_, err := run(t, "export", "room", "-f", path)
+		require.NoError(t, err)
+
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+		records, err := csv.NewReader(f).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, countRows(t, "room")+1) // the header and a line per room
+		require.Contains(t, records[0], "room_number")
+	})
+	t.Run("export - table to stdout", func(t *testing.T) {
+		out, err := run(t, "export", "room")
+		require.NoError(t, err)
+		require.Contains(t, out, "901")
+	})
+	t.Run("export - tables outside the whitelist", func(t *testing.T) {
+		rooms := countRows(t, "room")
+		for _, table := range []string{"pg_authid", "ROOM", "room; DROP TABLE room", "room TO '/tmp/rooms.csv'", ""} {
+			out, err := run(t, "export", table)
+			require.ErrorContains(t, err, "invalid argument", table)
+			require.NotContains(t, out, "901", table)
+		}
+		require.Equal(t, rooms, countRows(t, "room"))
+	})
+	t.Run("export - no table", func(t *testing.T) {
+		_, err := run(t, "export")
+		require.Error(t, err)
+	})
+}
+
+func TestCommands(t *testing.T) {
+	night := func(days int) string {
+		return time.Now().AddDate(0, 0, days).Format("2006-01-02")
+	}
+	// each mode gets a room, a customer and a booking of its own
+	for i, mode := range []string{"api", "db"} {
+		t.Run(mode, func(t *testing.T) {
+			number := 300 + i
+			code := "CTL" + mode
+
+			t.Run("rooms create", func(t *testing.T) {
+				out, err := runIn(t, mode, "-o", "json", "rooms", "create", "--number", fmt.Sprint(number), "--type", "suite", "--price", "140", "--capacity", "4")
+				require.NoError(t, err)
+				var room models.Room
+				err = json.Unmarshal([]byte(out), &room)
+				require.NoError(t, err)
+				require.NotZero(t, room.ID)
+				require.Equal(t, number, room.Number)
+
+				_, err = runIn(t, mode, "rooms", "create", "--number", fmt.Sprint(number+10), "--price", "140", "--capacity", "0")
+				require.ErrorContains(t, err, "Invalid room data")
+			})
+			t.Run("rooms list", func(t *testing.T) {
+				out, err := runIn(t, mode, "-o", "json", "rooms", "list")
+				require.NoError(t, err)
+				var rooms []models.Room
+				err = json.Unmarshal([]byte(out), &rooms)
+				require.NoError(t, err)
+				require.Equal(t, countRows(t, "room"), len(rooms))
+				numbers := []int{}
+				for _, room := range rooms {
+					numbers = append(numbers, room.Number)
+				}
+				require.Contains(t, numbers, number)
+
+				out, err = runIn(t, mode, "rooms", "list")
+				require.NoError(t, err)
+				require.Contains(t, out, "NUMBER")
+				require.Contains(t, out, fmt.Sprint(number))
+			})
+			t.Run("bookings get and cancel", func(t *testing.T) {
+				var customerID, roomID, bookingID int
+				err := conn.QueryRow(context.Background(), "INSERT INTO customer(cf, customer_name, age, email) VALUES ($1, 'Ctl', 40, 'ctl@example.com') RETURNING id", "CTLCF"+mode).Scan(&customerID)
+				require.NoError(t, err)
+				err = conn.QueryRow(context.Background(), "SELECT id FROM room WHERE room_number = $1", number).Scan(&roomID)
+				require.NoError(t, err)
+				err = conn.QueryRow(context.Background(), "INSERT INTO booking(code, customer_id, room_id, start_date, end_date) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+					code, customerID, roomID, night(1), night(3)).Scan(&bookingID)
+				require.NoError(t, err)
+
+				out, err := runIn(t, mode, "-o", "json", "bookings", "get", code)
+				require.NoError(t, err)
+				var booking models.BookingDTO
+				err = json.Unmarshal([]byte(out), &booking)
+				require.NoError(t, err)
+				require.Equal(t, bookingID, booking.ID)
+				require.Equal(t, roomID, booking.RoomID)
+				require.Equal(t, models.BookingConfirmed, booking.Status)
+
+				_, err = runIn(t, mode, "bookings", "get", "UNKNOWN")
+				require.ErrorIs(t, err, errBookingNotFound)
+
+				out, err = runIn(t, mode, "bookings", "cancel", "--code", code)
+				require.NoError(t, err)
+				require.Equal(t, fmt.Sprintf("Cancelled booking %d\n", bookingID), out)
+				var status string
+				err = conn.QueryRow(context.Background(), "SELECT status FROM booking WHERE id = $1", bookingID).Scan(&status)
+				require.NoError(t, err)
+				require.Equal(t, models.BookingCancelled, status)
+
+				// a cancelled booking cannot be cancelled again, an unknown one is not found
+				_, err = runIn(t, mode, "bookings", "cancel", fmt.Sprint(bookingID))
+				require.ErrorContains(t, err, "invalid_booking_status")
+				_, err = runIn(t, mode, "bookings", "cancel", "999999")
+				require.ErrorIs(t, err, errBookingNotFound)
+				_, err = runIn(t, mode, "bookings", "cancel", "abc")
+				require.ErrorContains(t, err, "invalid booking ID")
+			})
+		})
+	}
+	t.Run("report occupancy", func(t *testing.T) {
+		// a booking for the next two nights, the report is the same in both modes
+		var customerID, roomID int
+		err := conn.QueryRow(context.Background(), "INSERT INTO customer(cf, customer_name, age, email) VALUES ('CTLREPORT', 'Ctl', 40, 'ctl@example.com') RETURNING id").Scan(&customerID)
+		require.NoError(t, err)
+		err = conn.QueryRow(context.Background(), "SELECT id FROM room WHERE room_number = 300").Scan(&roomID)
+		require.NoError(t, err)
+		_, err = conn.Exec(context.Background(), "INSERT INTO booking(code, customer_id, room_id, start_date, end_date) VALUES ('CTLREPORT', $1, $2, $3, $4)", customerID, roomID, night(1), night(3))
+		require.NoError(t, err)
+
+		args := []string{"-o", "json", "report", "occupancy", "--from", night(1), "--to", night(3), "--group-by", "day"}
+		var reports [][]models.OccupancyReportRow
+		for _, mode := range []string{"api", "db"} {
+			out, err := runIn(t, mode, args...)
+			require.NoError(t, err, mode)
+			var rows []models.OccupancyReportRow
+			err = json.Unmarshal([]byte(out), &rows)
+			require.NoError(t, err)
+			require.Len(t, rows, 3, mode)
+			require.Equal(t, countRows(t, "room"), rows[0].RoomsAvailable)
+			require.Positive(t, rows[0].RoomsSold)
+			reports = append(reports, rows)
+		}
+		require.Equal(t, reports[0], reports[1])
+
+		for _, mode := range []string{"api", "db"} {
+			out, err := runIn(t, mode, "report", "occupancy", "--from", night(1), "--to", night(3), "--by-room-type")
+			require.NoError(t, err, mode)
+			require.Contains(t, out, "OCCUPANCY")
+			require.Contains(t, out, "suite")
+
+			_, err = runIn(t, mode, "report", "occupancy", "--group-by", "year")
+			require.ErrorContains(t, err, "Invalid report parameters", mode)
+		}
+	})
+	t.Run("api backend - headers and errors", func(t *testing.T) {
+		t.Setenv("HOTELCTL_API_KEY", "test-key")
+		_, err := runIn(t, "api", "rooms", "list")
+		require.NoError(t, err)
+		lastHeaderMu.Lock()
+		header := lastHeader
+		lastHeaderMu.Unlock()
+		require.Equal(t, "test-key", header.Get("X-API-Key"))
+		require.Contains(t, header.Get("X-Actor"), "hotelctl")
+
+		// the answer of the server becomes the error, the booking of api mode is already cancelled
+		_, err = runIn(t, "api", "bookings", "cancel", "--code", "CTLapi")
+		var apiErr apiError
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.Status)
+
+		// a server that is not there
+		_, err = runIn(t, "api", "--api-url", "http://127.0.0.1:1", "rooms", "list")
+		require.Error(t, err)
+	})
+	t.Run("unknown mode and output", func(t *testing.T) {
+		_, err := runIn(t, "grpc", "rooms", "list")
+		require.ErrorContains(t, err, "unknown mode")
+		_, err = runIn(t, "db", "-o", "yaml", "rooms", "list")
+		require.ErrorContains(t, err, "unknown output format")
+	})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"example/models"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/spf13/cobra"
+)
+
+func newReportCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Run operational reports",
+	}
+	cmd.AddCommand(newOccupancyReportCmd(opts))
+	return cmd
+}
+
+func newOccupancyReportCmd(opts *options) *cobra.Command {
+	var params models.ReportParamsDTO
+	var byRoomType bool
+	cmd := &cobra.Command{
+		Use:   "occupancy",
+		Short: "Show the occupancy over a date range",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if byRoomType {
+				params.By = "room_type"
+			}
+			if err := validator.New().Struct(params); err != nil {
+				return fmt.Errorf("%s", models.ReportValidationError)
+			}
+			ctx := cmd.Context()
+			b, err := opts.backend(ctx)
+			if err != nil {
+				return err
+			}
+			defer b.Close(ctx)
+
+			rows, err := b.OccupancyReport(ctx, params)
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), rows, func(tw *tabwriter.Writer) {
+				fmt.Fprintln(tw, "PERIOD\tROOM TYPE\tAVAILABLE\tSOLD\tOCCUPANCY")
+				for _, r := range rows {
+					fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.2f%%\n", r.Period, r.RoomType, r.RoomsAvailable, r.RoomsSold, r.Occupancy)
+				}
+			})
+		},
+	}
+	today := time.Now()
+	cmd.Flags().StringVar(&params.StartDate, "from", today.Format("2006-01-02"), "first night of the report (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&params.EndDate, "to", today.AddDate(0, 0, 6).Format("2006-01-02"), "last night of the report (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&params.GroupBy, "group-by", "day", "group the nights by day, week or month")
+	cmd.Flags().BoolVar(&byRoomType, "by-room-type", false, "split the results by room type")
+	return cmd
+}
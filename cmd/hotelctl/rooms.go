@@ -0,0 +1,82 @@
+package main
+
+import (
+	"example/models"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/spf13/cobra"
+)
+
+func newRoomsCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rooms",
+		Short: "List and create rooms",
+	}
+	cmd.AddCommand(newRoomsListCmd(opts), newRoomsCreateCmd(opts))
+	return cmd
+}
+
+func newRoomsListCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List all rooms",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			b, err := opts.backend(ctx)
+			if err != nil {
+				return err
+			}
+			defer b.Close(ctx)
+
+			rooms, err := b.ListRooms(ctx)
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), rooms, func(tw *tabwriter.Writer) {
+				fmt.Fprintln(tw, "ID\tNUMBER\tTYPE\tPRICE\tCAPACITY")
+				for _, r := range rooms {
+					fmt.Fprintf(tw, "%d\t%d\t%s\t%d\t%d\n", r.ID, r.Number, r.Type, r.Price, r.Capacity)
+				}
+			})
+		},
+	}
+}
+
+func newRoomsCreateCmd(opts *options) *cobra.Command {
+	var room models.Room
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a new room",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// the server validates the payload, but db mode bypasses the handlers
+			if err := validator.New().Struct(room); err != nil {
+				return fmt.Errorf("%s", models.RoomValidationError)
+			}
+			ctx := cmd.Context()
+			b, err := opts.backend(ctx)
+			if err != nil {
+				return err
+			}
+			defer b.Close(ctx)
+
+			if err := b.CreateRoom(ctx, &room); err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), room, func(tw *tabwriter.Writer) {
+				fmt.Fprintf(tw, "Created room %d (ID %d)\n", room.Number, room.ID)
+			})
+		},
+	}
+	cmd.Flags().IntVar(&room.Number, "number", 0, "room number")
+	cmd.Flags().StringVar(&room.Type, "type", "basic", "room type: basic or suite")
+	cmd.Flags().IntVar(&room.Price, "price", 0, "price per night")
+	cmd.Flags().IntVar(&room.Capacity, "capacity", 0, "maximum number of guests")
+	cmd.MarkFlagRequired("number")
+	cmd.MarkFlagRequired("price")
+	cmd.MarkFlagRequired("capacity")
+	return cmd
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func newSeedCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "seed FILE",
+		Short: "Load seed data from a SQL file such as populate.sql (db only)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sql, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			conn, err := opts.connect(ctx)
+			if err != nil {
+				return err
+			}
+			defer conn.Close(ctx)
+
+			// without arguments pgx uses the simple protocol, which accepts multiple statements
+			tx, err := conn.Begin(ctx)
+			if err != nil {
+				return err
+			}
+			defer tx.Rollback(ctx)
+			if _, err := tx.Exec(ctx, string(sql)); err != nil {
+				return fmt.Errorf("unable to execute %s: %w", args[0], err)
+			}
+			if err := tx.Commit(ctx); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Loaded %s\n", args[0])
+			return nil
+		},
+	}
+}
@@ -0,0 +1,431 @@
+// Package config loads the settings of the hotel server from a YAML file, the environment and the command line
+package config
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"example/logging"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting of the server. The struct tags name the key in the YAML file, the environment
+// variable and the command line flag of a setting, secrets are redacted when the configuration is printed.
+type Config struct {
+	Server       ServerConfig      `yaml:"server"`
+	Database     DatabaseConfig    `yaml:"database"`
+	Hotel        HotelConfig       `yaml:"hotel"`
+	Mail         MailConfig        `yaml:"mail"`
+	Reviews      ReviewsConfig     `yaml:"reviews"`
+	BookingCodes BookingCodeConfig `yaml:"booking_codes"`
+	Log          LogConfig         `yaml:"log"`
+	Tracing      TracingConfig     `yaml:"tracing"`
+	RateLimit    RateLimitConfig   `yaml:"rate_limit"`
+}
+
+type ServerConfig struct {
+	Host            string        `yaml:"host" env:"SERVER_HOST" flag:"host" usage:"address to listen on"`
+	Port            int           `yaml:"port" env:"PORT" flag:"port" usage:"port to listen on"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" env:"READ_TIMEOUT" flag:"read-timeout" usage:"maximum duration to read a request"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" env:"WRITE_TIMEOUT" flag:"write-timeout" usage:"maximum duration to write a response"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" env:"IDLE_TIMEOUT" flag:"idle-timeout" usage:"how long an idle connection is kept open"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"how long the requests in flight are waited for at shutdown"`
+	DrainDelay      time.Duration `yaml:"drain_delay" env:"SHUTDOWN_DRAIN_DELAY" flag:"drain-delay" usage:"how long the server keeps serving while not ready before it shuts down"`
+	MaxBodyBytes    int           `yaml:"max_body_bytes" env:"MAX_BODY_BYTES" flag:"max-body-bytes" usage:"largest JSON body accepted"`
+	TLS             TLSConfig     `yaml:"tls"`
+}
+
+// TLSConfig enables HTTPS when a certificate is given
+type TLSConfig struct {
+	CertFile   string `yaml:"cert_file" env:"TLS_CERT_FILE" flag:"tls-cert" usage:"certificate file, enables HTTPS"`
+	KeyFile    string `yaml:"key_file" env:"TLS_KEY_FILE" flag:"tls-key" usage:"private key file of the certificate"`
+	MinVersion string `yaml:"min_version" env:"TLS_MIN_VERSION" flag:"tls-min-version" usage:"minimum TLS version, 1.2 or 1.3"`
+}
+
+type DatabaseConfig struct {
+	Host     string `yaml:"host" env:"DB_HOST" flag:"db-host" usage:"PostgreSQL host"`
+	Port     int    `yaml:"port" env:"DB_PORT" flag:"db-port" usage:"PostgreSQL port"`
+	User     string `yaml:"user" env:"DB_USER" flag:"db-user" usage:"PostgreSQL user"`
+	Password string `yaml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name     string `yaml:"name" env:"DB_NAME" flag:"db-name" usage:"PostgreSQL database"`
+	SSLMode  string `yaml:"sslmode" env:"DB_SSLMODE" flag:"db-sslmode" usage:"PostgreSQL sslmode, e.g. disable or verify-full"`
+}
+
+type HotelConfig struct {
+	Timezone string `yaml:"timezone" env:"HOTEL_TIMEZONE" flag:"timezone" usage:"IANA timezone of the hotel, the local time of the server when empty"`
+}
+
+// MailConfig chooses how the emails are sent: to an SMTP server, to .eml files in a directory, or not at all
+type MailConfig struct {
+	SMTPAddr     string        `yaml:"smtp_addr" env:"SMTP_ADDR" flag:"smtp-addr" usage:"host:port of the SMTP server"`
+	SMTPUser     string        `yaml:"smtp_user" env:"SMTP_USER" flag:"smtp-user" usage:"SMTP user, no authentication when empty"`
+	SMTPPassword string        `yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+	SMTPTimeout  time.Duration `yaml:"smtp_timeout" env:"SMTP_TIMEOUT" flag:"smtp-timeout" usage:"maximum duration to send an email"`
+	From         string        `yaml:"from" env:"MAIL_FROM" flag:"mail-from" usage:"sender of the emails"`
+	Dir          string        `yaml:"dir" env:"MAIL_DIR" flag:"mail-dir" usage:"directory where the emails are written without an SMTP server"`
+}
+
+type ReviewsConfig struct {
+	Moderation  string   `yaml:"moderation" env:"REVIEW_MODERATION" flag:"review-moderation" usage:"auto, or all to hold every review for a moderator"`
+	BannedWords []string `yaml:"banned_words" env:"REVIEW_BANNED_WORDS" flag:"review-banned-words" usage:"comma separated words added to the default banned words"`
+	WindowDays  int      `yaml:"window_days" env:"REVIEW_WINDOW_DAYS" flag:"review-window-days" usage:"days after check-out to write a review"`
+}
+
+type BookingCodeConfig struct {
+	Prefix string `yaml:"prefix" env:"BOOKING_CODE_PREFIX" flag:"booking-code-prefix" usage:"prefix of the generated booking codes"`
+	Length int    `yaml:"length" env:"BOOKING_CODE_LENGTH" flag:"booking-code-length" usage:"random characters of the generated booking codes"`
+}
+
+type LogConfig struct {
+	Format string `yaml:"format" env:"LOG_FORMAT" flag:"log-format" usage:"text or json"`
+	Level  string `yaml:"level" env:"LOG_LEVEL" flag:"log-level" usage:"debug, info, warn or error"`
+}
+
+// TracingConfig chooses where the OpenTelemetry spans are exported
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" flag:"tracing-exporter" usage:"none, stdout or otlp"`
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_OTLP_ENDPOINT" flag:"tracing-otlp-endpoint" usage:"URL of the OTLP/HTTP collector, e.g. http://localhost:4318/v1/traces"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" flag:"tracing-sample-ratio" usage:"share of the traces recorded, from 0 to 1"`
+	ServiceName string  `yaml:"service_name" env:"OTEL_SERVICE_NAME" flag:"tracing-service-name" usage:"service name of the spans"`
+}
+
+// RateLimitConfig limits the requests per second of every client, identified by its API key or its address, by
+// route class: public for the endpoints open to the guests (availability, new customers, bookings...), write and read
+// for the others. A rate of 0 disables the limit of the class.
+type RateLimitConfig struct {
+	PublicRate  float64  `yaml:"public_rate" env:"RATE_LIMIT_PUBLIC" flag:"rate-limit-public" usage:"requests per second to the public endpoints"`
+	PublicBurst int      `yaml:"public_burst" env:"RATE_LIMIT_PUBLIC_BURST" flag:"rate-limit-public-burst" usage:"burst of requests to the public endpoints"`
+	WriteRate   float64  `yaml:"write_rate" env:"RATE_LIMIT_WRITE" flag:"rate-limit-write" usage:"requests per second that change data"`
+	WriteBurst  int      `yaml:"write_burst" env:"RATE_LIMIT_WRITE_BURST" flag:"rate-limit-write-burst" usage:"burst of requests that change data"`
+	ReadRate    float64  `yaml:"read_rate" env:"RATE_LIMIT_READ" flag:"rate-limit-read" usage:"requests per second that read data"`
+	ReadBurst   int      `yaml:"read_burst" env:"RATE_LIMIT_READ_BURST" flag:"rate-limit-read-burst" usage:"burst of requests that read data"`
+	APIKeys     []string `yaml:"api_keys" env:"RATE_LIMIT_API_KEYS" secret:"true"`
+}
+
+const redacted = "********"
+
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Host:            "0.0.0.0",
+			Port:            8080,
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    2 * time.Minute, // the jobs run by hand answer when they are over
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: 30 * time.Second,
+			DrainDelay:      5 * time.Second,
+			MaxBodyBytes:    1 << 20,
+			TLS:             TLSConfig{MinVersion: "1.2"},
+		},
+		Database:     DatabaseConfig{Host: "localhost", Port: 5432},
+		Mail:         MailConfig{SMTPTimeout: 10 * time.Second},
+		Reviews:      ReviewsConfig{Moderation: "auto", WindowDays: 30},
+		BookingCodes: BookingCodeConfig{Length: 7},
+		Log:          LogConfig{Format: "text", Level: "info"},
+		Tracing:      TracingConfig{Exporter: "none", SampleRatio: 1, ServiceName: "hotel-server"},
+		RateLimit:    RateLimitConfig{PublicRate: 1, PublicBurst: 10, WriteRate: 5, WriteBurst: 20, ReadRate: 20, ReadBurst: 50},
+	}
+}
+
+// Load reads the configuration from its sources, each one overriding the previous: the defaults, the YAML file
+// given with -config or HOTEL_CONFIG, the environment variables and the command line flags. A secret can be read
+// from the file named by its variable with the _FILE suffix, e.g. DB_PASSWORD_FILE. The configuration is returned
+// with the validation errors, so that it can still be printed.
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+	flags := flag.NewFlagSet("hotel", flag.ContinueOnError)
+	path := flags.String("config", os.Getenv("HOTEL_CONFIG"), "YAML configuration file")
+	for _, s := range cfg.settings() {
+		if s.flag != "" {
+			flags.Var(&flagValue{setting: s}, s.flag, s.usage)
+		}
+	}
+	// the flags are applied last, they only record their value while parsing
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	if *path != "" {
+		err = cfg.loadFile(*path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = cfg.loadEnv()
+	if err != nil {
+		return nil, err
+	}
+	flags.Visit(func(f *flag.Flag) {
+		if v, ok := f.Value.(*flagValue); ok && err == nil {
+			err = v.apply()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cfg, cfg.Validate()
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err = decoder.Decode(c)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	for _, s := range c.settings() {
+		if s.env == "" {
+			continue
+		}
+		value := os.Getenv(s.env)
+		if file := os.Getenv(s.env + "_FILE"); s.secret && file != "" {
+			if value != "" {
+				return fmt.Errorf("both %s and %s_FILE are set", s.env, s.env)
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("unable to read %s_FILE: %w", s.env, err)
+			}
+			value = strings.TrimRight(string(data), "\r\n")
+		}
+		if value == "" {
+			continue
+		}
+		err := s.set(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", s.env, err)
+		}
+	}
+	return nil
+}
+
+// Validate returns all the invalid settings at once
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		invalid("server.port must be between 1 and 65535")
+	}
+	if c.Server.ReadTimeout <= 0 || c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 || c.Server.ShutdownTimeout <= 0 {
+		invalid("server timeouts must be positive")
+	}
+	if c.Server.DrainDelay < 0 {
+		invalid("server.drain_delay must not be negative")
+	}
+	if c.Server.MaxBodyBytes <= 0 {
+		invalid("server.max_body_bytes must be positive")
+	}
+	if (c.Server.TLS.CertFile == "") != (c.Server.TLS.KeyFile == "") {
+		invalid("server.tls needs both cert_file and key_file")
+	}
+	if _, err := c.Server.TLS.Version(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Database.Host == "" || c.Database.Name == "" {
+		invalid("database.host and database.name are required")
+	}
+	if c.Database.Port < 1 || c.Database.Port > 65535 {
+		invalid("database.port must be between 1 and 65535")
+	}
+	if _, err := c.Hotel.Location(); err != nil {
+		invalid("invalid hotel.timezone: %w", err)
+	}
+	if c.Mail.SMTPTimeout <= 0 {
+		invalid("mail.smtp_timeout must be positive")
+	}
+	if c.Reviews.Moderation != "auto" && c.Reviews.Moderation != "all" {
+		invalid("reviews.moderation must be auto or all")
+	}
+	if c.Reviews.WindowDays <= 0 {
+		invalid("reviews.window_days must be positive")
+	}
+	if _, err := logging.New(io.Discard, c.Log.Format, c.Log.Level); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Tracing.Exporter != "none" && c.Tracing.Exporter != "stdout" && c.Tracing.Exporter != "otlp" {
+		invalid("tracing.exporter must be none, stdout or otlp")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		invalid("tracing.sample_ratio must be between 0 and 1")
+	}
+	if c.RateLimit.PublicRate < 0 || c.RateLimit.WriteRate < 0 || c.RateLimit.ReadRate < 0 {
+		invalid("rate_limit rates must not be negative")
+	}
+	if c.RateLimit.PublicBurst < 0 || c.RateLimit.WriteBurst < 0 || c.RateLimit.ReadBurst < 0 {
+		invalid("rate_limit bursts must not be negative")
+	}
+	return errors.Join(errs...)
+}
+
+// Print writes the effective configuration as YAML, with the secrets redacted
+func (c *Config) Print(w io.Writer) error {
+	printed := *c
+	for _, s := range printed.settings() {
+		if !s.secret || s.value.IsZero() {
+			continue
+		}
+		if s.value.Kind() == reflect.Slice {
+			s.value.Set(reflect.ValueOf([]string{redacted}))
+		} else {
+			s.value.SetString(redacted)
+		}
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	err := encoder.Encode(&printed)
+	if err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// URL returns the connection string of the database
+func (c DatabaseConfig) URL() string {
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(c.User, c.Password),
+		Host:   net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Path:   "/" + c.Name,
+	}
+	if c.SSLMode != "" {
+		u.RawQuery = url.Values{"sslmode": {c.SSLMode}}.Encode()
+	}
+	return u.String()
+}
+
+// Location returns the timezone of the hotel
+func (c HotelConfig) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(c.Timezone)
+}
+
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+func (c TLSConfig) Version() (uint16, error) {
+	switch c.MinVersion {
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("server.tls.min_version must be 1.2 or 1.3")
+	}
+}
+
+// setting is a field of the configuration with its sources
+type setting struct {
+	value  reflect.Value
+	env    string
+	flag   string
+	usage  string
+	secret bool
+}
+
+// settings lists the fields of the configuration, the values can be set through them
+func (c *Config) settings() []setting {
+	var settings []setting
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.Type.Kind() == reflect.Struct {
+				walk(v.Field(i))
+				continue
+			}
+			settings = append(settings, setting{
+				value:  v.Field(i),
+				env:    field.Tag.Get("env"),
+				flag:   field.Tag.Get("flag"),
+				usage:  field.Tag.Get("usage"),
+				secret: field.Tag.Get("secret") == "true",
+			})
+		}
+	}
+	walk(reflect.ValueOf(c).Elem())
+	return settings
+}
+
+// set parses a value given as text, lists are comma separated
+func (s setting) set(text string) error {
+	switch s.value.Interface().(type) {
+	case time.Duration:
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return err
+		}
+		s.value.SetInt(int64(d))
+	case int:
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return err
+		}
+		s.value.SetInt(int64(n))
+	case float64:
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return err
+		}
+		s.value.SetFloat(f)
+	case string:
+		s.value.SetString(text)
+	case []string:
+		var list []string
+		for _, item := range strings.Split(text, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		s.value.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.value.Type())
+	}
+	return nil
+}
+
+// flagValue keeps the value of a flag until the other sources are loaded
+type flagValue struct {
+	setting
+	text string
+}
+
+func (f *flagValue) String() string {
+	return f.text
+}
+
+func (f *flagValue) Set(text string) error {
+	f.text = text
+	return nil
+}
+
+func (f *flagValue) apply() error {
+	err := f.set(f.text)
+	if err != nil {
+		return fmt.Errorf("invalid -%s: %w", f.flag, err)
+	}
+	return nil
+}
@@ -6,17 +6,39 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 )
 
+const bookingQuery = "SELECT id, code, customer_id, room_id, start_date, end_date, status, checked_in_at, checked_out_at, adults, children FROM booking"
+
 func GetAllBookings(ctx context.Context, conn *pgx.Conn) ([]models.Booking, error) {
-	rows, _ := conn.Query(ctx, "SELECT id, code, customer_id, room_id, start_date, end_date FROM booking")
+	rows, _ := conn.Query(ctx, bookingQuery)
+	defer rows.Close()
+	var bookings []models.Booking
+	for rows.Next() {
+		var booking models.Booking
+		err := rows.Scan(&booking.ID, &booking.Code, &booking.CustomerID, &booking.RoomID, &booking.StartDate, &booking.EndDate, &booking.Status, &booking.CheckedInAt, &booking.CheckedOutAt, &booking.Adults, &booking.Children)
+		if err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, booking)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return bookings, nil
+}
+
+// GetArrivals returns the confirmed bookings starting on the date
+func GetArrivals(ctx context.Context, conn *pgx.Conn, date time.Time) ([]models.Booking, error) {
+	rows, _ := conn.Query(ctx, bookingQuery+" WHERE status = 'confirmed' AND start_date = $1 ORDER BY id", date)
 	defer rows.Close()
 	var bookings []models.Booking
 	for rows.Next() {
 		var booking models.Booking
-		err := rows.Scan(&booking.ID, &booking.Code, &booking.CustomerID, &booking.RoomID, &booking.StartDate, &booking.EndDate)
+		err := rows.Scan(&booking.ID, &booking.Code, &booking.CustomerID, &booking.RoomID, &booking.StartDate, &booking.EndDate, &booking.Status, &booking.CheckedInAt, &booking.CheckedOutAt, &booking.Adults, &booking.Children)
 		if err != nil {
 			return nil, err
 		}
@@ -29,25 +51,114 @@ func GetAllBookings(ctx context.Context, conn *pgx.Conn) ([]models.Booking, erro
 }
 
 func GetBookingByID(ctx context.Context, conn *pgx.Conn, bookingID int) (*models.Booking, error) {
-	row := conn.QueryRow(ctx, "SELECT id, code, customer_id, room_id, start_date, end_date FROM booking WHERE id = $1", bookingID)
+	row := conn.QueryRow(ctx, bookingQuery+" WHERE id = $1", bookingID)
 	var booking models.Booking
-	err := row.Scan(&booking.ID, &booking.Code, &booking.CustomerID, &booking.RoomID, &booking.StartDate, &booking.EndDate)
+	err := row.Scan(&booking.ID, &booking.Code, &booking.CustomerID, &booking.RoomID, &booking.StartDate, &booking.EndDate, &booking.Status, &booking.CheckedInAt, &booking.CheckedOutAt, &booking.Adults, &booking.Children)
+	if err != nil {
+		return nil, err
+	}
+	booking.Occupants, err = GetBookingOccupants(ctx, conn, booking.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+func GetBookingByCode(ctx context.Context, conn *pgx.Conn, code string) (*models.Booking, error) {
+	row := conn.QueryRow(ctx, bookingQuery+" WHERE code = $1", code)
+	var booking models.Booking
+	err := row.Scan(&booking.ID, &booking.Code, &booking.CustomerID, &booking.RoomID, &booking.StartDate, &booking.EndDate, &booking.Status, &booking.CheckedInAt, &booking.CheckedOutAt, &booking.Adults, &booking.Children)
+	if err != nil {
+		return nil, err
+	}
+	booking.Occupants, err = GetBookingOccupants(ctx, conn, booking.ID)
 	if err != nil {
 		return nil, err
 	}
 	return &booking, nil
 }
 
+// GetOverlappingBookings returns the bookings of a room that still hold it between the given dates, end date excluded
+func GetOverlappingBookings(ctx context.Context, conn *pgx.Conn, roomID int, startDate, endDate time.Time) ([]models.Booking, error) {
+	rows, _ := conn.Query(ctx, bookingQuery+` b
+WHERE status NOT IN ('cancelled', 'no_show', 'checked_out')
+AND EXISTS (SELECT 1 FROM room_stay s WHERE s.booking_id = b.id AND s.room_id = $1 AND s.start_date < $3 AND s.end_date > $2)
+ORDER BY start_date`, roomID, startDate, endDate)
+	defer rows.Close()
+	var bookings []models.Booking
+	for rows.Next() {
+		var booking models.Booking
+		err := rows.Scan(&booking.ID, &booking.Code, &booking.CustomerID, &booking.RoomID, &booking.StartDate, &booking.EndDate, &booking.Status, &booking.CheckedInAt, &booking.CheckedOutAt, &booking.Adults, &booking.Children)
+		if err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, booking)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return bookings, nil
+}
+
+// CreateBooking saves a booking with its occupants and the charges of its nights. A booking made with a hold removes
+// it and marks its waitlist offer, if any, as booked. It returns pgx.ErrNoRows and saves nothing when the hold is gone.
 func CreateBooking(ctx context.Context, conn *pgx.Conn, booking *models.Booking) error {
-	row := conn.QueryRow(ctx, "INSERT INTO booking (code, customer_id, room_id, start_date, end_date) VALUES ($1, $2, $3, $4, $5) RETURNING id", booking.Code, booking.CustomerID, booking.RoomID, booking.StartDate, booking.EndDate)
-	err := row.Scan(&booking.ID)
-	return err
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		row := tx.QueryRow(ctx, "INSERT INTO booking (code, customer_id, room_id, start_date, end_date, adults, children) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, status",
+			booking.Code, booking.CustomerID, booking.RoomID, booking.StartDate, booking.EndDate, booking.Adults, booking.Children)
+		err := row.Scan(&booking.ID, &booking.Status)
+		if err != nil {
+			return err
+		}
+		err = insertOccupants(ctx, tx, booking.ID, booking.Occupants)
+		if err != nil {
+			return err
+		}
+		if booking.HoldID != 0 {
+			// before the hold is deleted, its deletion clears the hold_id of the offer
+			_, err = tx.Exec(ctx, "UPDATE waitlist_entry SET status = 'booked' WHERE hold_id = $1 AND status = 'offered'", booking.HoldID)
+			if err != nil {
+				return err
+			}
+			tag, err := tx.Exec(ctx, "DELETE FROM booking_hold WHERE id = $1", booking.HoldID)
+			if err != nil {
+				return err
+			}
+			if tag.RowsAffected() == 0 {
+				return pgx.ErrNoRows
+			}
+		}
+		_, err = tx.Exec(ctx, repriceFolioQuery, booking.ID)
+		return err
+	})
 }
 
+// UpdateBookingByID replaces a booking and its occupants
 func UpdateBookingByID(ctx context.Context, conn *pgx.Conn, booking *models.Booking) error {
-	row := conn.QueryRow(ctx, "UPDATE booking SET code = $1, customer_id = $2, room_id = $3, start_date = $4, end_date = $5 WHERE id = $6 RETURNING code, customer_id, room_id, start_date, end_date", booking.Code, booking.CustomerID, booking.RoomID, booking.StartDate, booking.EndDate, booking.ID)
-	err := row.Scan(&booking.Code, &booking.CustomerID, &booking.RoomID, &booking.StartDate, &booking.EndDate)
-	return err
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		row := tx.QueryRow(ctx, "UPDATE booking SET code = $1, customer_id = $2, room_id = $3, start_date = $4, end_date = $5, adults = $6, children = $7 WHERE id = $8 RETURNING code, customer_id, room_id, start_date, end_date, status, checked_in_at, checked_out_at, adults, children",
+			booking.Code, booking.CustomerID, booking.RoomID, booking.StartDate, booking.EndDate, booking.Adults, booking.Children, booking.ID)
+		err := row.Scan(&booking.Code, &booking.CustomerID, &booking.RoomID, &booking.StartDate, &booking.EndDate, &booking.Status, &booking.CheckedInAt, &booking.CheckedOutAt, &booking.Adults, &booking.Children)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "DELETE FROM booking_occupant WHERE booking_id = $1", booking.ID)
+		if err != nil {
+			return err
+		}
+		return insertOccupants(ctx, tx, booking.ID, booking.Occupants)
+	})
+}
+
+func UpdateBookingStatus(ctx context.Context, conn *pgx.Conn, booking *models.Booking) error {
+	tag, err := conn.Exec(ctx, "UPDATE booking SET status = $1, checked_in_at = $2, checked_out_at = $3 WHERE id = $4", booking.Status, booking.CheckedInAt, booking.CheckedOutAt, booking.ID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
 }
 
 func PatchBookingByID(ctx context.Context, conn *pgx.Conn, bookingID int, patch models.BookingPatch) error {
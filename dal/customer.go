@@ -8,12 +8,12 @@ import (
 )
 
 func GetAllCustomers(ctx context.Context, conn *pgx.Conn) ([]models.Customer, error) {
-	rows, _ := conn.Query(ctx, "SELECT id, cf, customer_name, age, email FROM customer")
+	rows, _ := conn.Query(ctx, "SELECT id, cf, customer_name, age, email, coalesce(lang, '') FROM customer")
 	defer rows.Close()
 	var customers []models.Customer
 	for rows.Next() {
 		var customer models.Customer
-		err := rows.Scan(&customer.ID, &customer.CF, &customer.Name, &customer.Age, &customer.Email)
+		err := rows.Scan(&customer.ID, &customer.CF, &customer.Name, &customer.Age, &customer.Email, &customer.Language)
 		if err != nil {
 			return nil, err
 		}
@@ -26,9 +26,9 @@ func GetAllCustomers(ctx context.Context, conn *pgx.Conn) ([]models.Customer, er
 }
 
 func GetCustomerByID(ctx context.Context, conn *pgx.Conn, customerID int) (*models.Customer, error) {
-	row := conn.QueryRow(ctx, "SELECT id, cf, customer_name, age, email FROM customer WHERE id = $1", customerID)
+	row := conn.QueryRow(ctx, "SELECT id, cf, customer_name, age, email, coalesce(lang, '') FROM customer WHERE id = $1", customerID)
 	var customer models.Customer
-	err := row.Scan(&customer.ID, &customer.CF, &customer.Name, &customer.Age, &customer.Email)
+	err := row.Scan(&customer.ID, &customer.CF, &customer.Name, &customer.Age, &customer.Email, &customer.Language)
 	if err != nil {
 		return nil, err
 	}
@@ -36,14 +36,14 @@ func GetCustomerByID(ctx context.Context, conn *pgx.Conn, customerID int) (*mode
 }
 
 func CreateCustomer(ctx context.Context, conn *pgx.Conn, customer *models.Customer) error {
-	row := conn.QueryRow(ctx, "INSERT INTO customer (cf, customer_name, age, email) VALUES ($1, $2, $3, $4) RETURNING id", customer.CF, customer.Name, customer.Age, customer.Email)
+	row := conn.QueryRow(ctx, "INSERT INTO customer (cf, customer_name, age, email, lang) VALUES ($1, $2, $3, $4, NULLIF($5, '')) RETURNING id", customer.CF, customer.Name, customer.Age, customer.Email, customer.Language)
 	err := row.Scan(&customer.ID)
 	return err
 }
 
 func UpdateCustomerByID(ctx context.Context, conn *pgx.Conn, customer *models.Customer) error {
-	row := conn.QueryRow(ctx, "UPDATE customer SET cf = $1, customer_name = $2, age = $3, email = $4 WHERE id = $5 RETURNING cf, customer_name, age, email", customer.CF, customer.Name, customer.Age, customer.Email, customer.ID)
-	err := row.Scan(&customer.CF, &customer.Name, &customer.Age, &customer.Email)
+	row := conn.QueryRow(ctx, "UPDATE customer SET cf = $1, customer_name = $2, age = $3, email = $4, lang = NULLIF($5, '') WHERE id = $6 RETURNING cf, customer_name, age, email, coalesce(lang, '')", customer.CF, customer.Name, customer.Age, customer.Email, customer.Language, customer.ID)
+	err := row.Scan(&customer.CF, &customer.Name, &customer.Age, &customer.Email, &customer.Language)
 	return err
 }
 
@@ -0,0 +1,46 @@
+package dal
+
+import (
+	"context"
+	"example/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func GetFolioCharges(ctx context.Context, conn *pgx.Conn, bookingID int) ([]models.FolioCharge, error) {
+	rows, _ := conn.Query(ctx, "SELECT id, booking_id, charge_date, charge_type, description, amount, posted_at FROM folio_charge WHERE booking_id = $1 ORDER BY charge_date, id", bookingID)
+	defer rows.Close()
+	var charges []models.FolioCharge
+	for rows.Next() {
+		var charge models.FolioCharge
+		err := rows.Scan(&charge.ID, &charge.BookingID, &charge.Date, &charge.Type, &charge.Description, &charge.Amount, &charge.PostedAt)
+		if err != nil {
+			return nil, err
+		}
+		charges = append(charges, charge)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return charges, nil
+}
+
+// RepriceFolio replaces the room charges that are not posted yet with one charge per night of the stay,
+// at the current price of the room the guest sleeps in. Cancelled and no show bookings have no room charges.
+func RepriceFolio(ctx context.Context, conn *pgx.Conn, bookingID int) error {
+	_, err := conn.Exec(ctx, repriceFolioQuery, bookingID)
+	return err
+}
+
+const repriceFolioQuery = `WITH unposted AS (
+	DELETE FROM folio_charge WHERE booking_id = $1 AND charge_type = 'room' AND posted_at IS NULL
+)
+INSERT INTO folio_charge (booking_id, charge_date, charge_type, description, amount)
+SELECT s.booking_id, night::date, 'room', 'Room ' || r.room_number, r.price
+FROM room_stay s JOIN room r ON r.id = s.room_id
+CROSS JOIN generate_series(s.start_date, s.end_date - 1, interval '1 day') AS night
+WHERE s.booking_id = $1 AND s.status NOT IN ('cancelled', 'no_show')
+AND NOT EXISTS (
+	SELECT 1 FROM folio_charge f
+	WHERE f.booking_id = $1 AND f.charge_type = 'room' AND f.charge_date = night::date AND f.posted_at IS NOT NULL
+)`
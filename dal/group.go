@@ -0,0 +1,145 @@
+package dal
+
+import (
+	"context"
+	"example/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const groupQuery = "SELECT id, code, group_name, lead_customer_id, start_date, end_date, cutoff_date, status FROM booking_group"
+
+func GetAllGroups(ctx context.Context, conn *pgx.Conn) ([]models.Group, error) {
+	rows, _ := conn.Query(ctx, groupQuery+" ORDER BY start_date, id")
+	defer rows.Close()
+	var groups []models.Group
+	for rows.Next() {
+		var group models.Group
+		err := rows.Scan(&group.ID, &group.Code, &group.Name, &group.LeadCustomerID, &group.StartDate, &group.EndDate, &group.CutoffDate, &group.Status)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return groups, nil
+}
+
+// GetGroupByID returns a group with its rooms
+func GetGroupByID(ctx context.Context, conn *pgx.Conn, groupID int) (*models.Group, error) {
+	row := conn.QueryRow(ctx, groupQuery+" WHERE id = $1", groupID)
+	var group models.Group
+	err := row.Scan(&group.ID, &group.Code, &group.Name, &group.LeadCustomerID, &group.StartDate, &group.EndDate, &group.CutoffDate, &group.Status)
+	if err != nil {
+		return nil, err
+	}
+	group.Rooms, err = GetGroupRooms(ctx, conn, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func GetGroupByCode(ctx context.Context, conn *pgx.Conn, code string) (*models.Group, error) {
+	row := conn.QueryRow(ctx, groupQuery+" WHERE code = $1", code)
+	var group models.Group
+	err := row.Scan(&group.ID, &group.Code, &group.Name, &group.LeadCustomerID, &group.StartDate, &group.EndDate, &group.CutoffDate, &group.Status)
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func GetGroupRooms(ctx context.Context, conn *pgx.Conn, groupID int) ([]models.GroupRoom, error) {
+	rows, _ := conn.Query(ctx, "SELECT id, code, customer_id, room_id, start_date, end_date, status, checked_in_at, checked_out_at, adults, children, allotment FROM booking WHERE group_id = $1 ORDER BY id", groupID)
+	defer rows.Close()
+	var rooms []models.GroupRoom
+	for rows.Next() {
+		var room models.GroupRoom
+		b := &room.Booking
+		err := rows.Scan(&b.ID, &b.Code, &b.CustomerID, &b.RoomID, &b.StartDate, &b.EndDate, &b.Status, &b.CheckedInAt, &b.CheckedOutAt, &b.Adults, &b.Children, &room.Allotment)
+		if err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, room)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return rooms, nil
+}
+
+// CreateGroup saves a group and the bookings of its rooms, either all of them or none
+func CreateGroup(ctx context.Context, conn *pgx.Conn, group *models.Group) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		row := tx.QueryRow(ctx, "INSERT INTO booking_group (code, group_name, lead_customer_id, start_date, end_date, cutoff_date) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, status",
+			group.Code, group.Name, group.LeadCustomerID, group.StartDate, group.EndDate, group.CutoffDate)
+		err := row.Scan(&group.ID, &group.Status)
+		if err != nil {
+			return err
+		}
+		for i := range group.Rooms {
+			b := &group.Rooms[i].Booking
+			row = tx.QueryRow(ctx, "INSERT INTO booking (code, customer_id, room_id, start_date, end_date, adults, children, group_id, allotment) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, status",
+				b.Code, b.CustomerID, b.RoomID, b.StartDate, b.EndDate, b.Adults, b.Children, group.ID, group.Rooms[i].Allotment)
+			err = row.Scan(&b.ID, &b.Status)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CancelGroup cancels a group and the bookings of its rooms that are still confirmed
+func CancelGroup(ctx context.Context, conn *pgx.Conn, groupID int) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "UPDATE booking_group SET status = 'cancelled' WHERE id = $1", groupID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return pgx.ErrNoRows
+		}
+		_, err = tx.Exec(ctx, "UPDATE booking SET status = 'cancelled' WHERE group_id = $1 AND status = 'confirmed'", groupID)
+		return err
+	})
+}
+
+// SetGroupRoomGuest gives a guest to a room of a group, the room is no longer an allotment
+func SetGroupRoomGuest(ctx context.Context, conn *pgx.Conn, groupID int, bookingID int, customerID int) error {
+	tag, err := conn.Exec(ctx, "UPDATE booking SET customer_id = $1, allotment = false WHERE id = $2 AND group_id = $3", customerID, bookingID, groupID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// ReleaseAllotments cancels the confirmed rooms without a guest of the groups whose cut-off date is not after
+// the given date, and returns them
+func ReleaseAllotments(ctx context.Context, conn *pgx.Conn, date time.Time) ([]models.Booking, error) {
+	rows, _ := conn.Query(ctx, `UPDATE booking b SET status = 'cancelled'
+FROM booking_group g
+WHERE g.id = b.group_id AND b.allotment AND b.status = 'confirmed' AND g.cutoff_date <= $1
+RETURNING b.id, b.code, b.customer_id, b.room_id, b.start_date, b.end_date, b.status, b.checked_in_at, b.checked_out_at, b.adults, b.children`, date)
+	defer rows.Close()
+	var bookings []models.Booking
+	for rows.Next() {
+		var booking models.Booking
+		err := rows.Scan(&booking.ID, &booking.Code, &booking.CustomerID, &booking.RoomID, &booking.StartDate, &booking.EndDate, &booking.Status, &booking.CheckedInAt, &booking.CheckedOutAt, &booking.Adults, &booking.Children)
+		if err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, booking)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return bookings, nil
+}
@@ -0,0 +1,80 @@
+package dal
+
+import (
+	"context"
+	"example/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// holdQuery selects the holds that did not expire at $1, the time of the clock of the services and not the one of
+// the database
+const holdQuery = "SELECT id, room_id, customer_id, start_date, end_date, expires_at FROM booking_hold WHERE expires_at > $1"
+
+func GetActiveHolds(ctx context.Context, conn *pgx.Conn, now time.Time) ([]models.Hold, error) {
+	rows, _ := conn.Query(ctx, holdQuery+" ORDER BY expires_at, id", now)
+	return collectHolds(rows)
+}
+
+// GetHoldByID returns a hold that did not expire at the given time
+func GetHoldByID(ctx context.Context, conn *pgx.Conn, holdID int, now time.Time) (*models.Hold, error) {
+	row := conn.QueryRow(ctx, holdQuery+" AND id = $2", now, holdID)
+	var hold models.Hold
+	err := row.Scan(&hold.ID, &hold.RoomID, &hold.CustomerID, &hold.StartDate, &hold.EndDate, &hold.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// GetOverlappingHolds returns the holds of a room active at the given time that overlap the given dates, end date
+// excluded, except excludeHoldID
+func GetOverlappingHolds(ctx context.Context, conn *pgx.Conn, roomID int, startDate, endDate time.Time, excludeHoldID int, now time.Time) ([]models.Hold, error) {
+	rows, _ := conn.Query(ctx, holdQuery+" AND room_id = $2 AND start_date < $4 AND end_date > $3 AND id <> $5 ORDER BY start_date", now, roomID, startDate, endDate, excludeHoldID)
+	return collectHolds(rows)
+}
+
+func CreateHold(ctx context.Context, conn *pgx.Conn, hold *models.Hold) error {
+	row := conn.QueryRow(ctx, "INSERT INTO booking_hold (room_id, customer_id, start_date, end_date, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		hold.RoomID, hold.CustomerID, hold.StartDate, hold.EndDate, hold.ExpiresAt)
+	err := row.Scan(&hold.ID)
+	return err
+}
+
+func DeleteHold(ctx context.Context, conn *pgx.Conn, holdID int) error {
+	tag, err := conn.Exec(ctx, "DELETE FROM booking_hold WHERE id = $1", holdID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteExpiredHolds removes the holds expired before the given time and returns how many
+func DeleteExpiredHolds(ctx context.Context, conn *pgx.Conn, before time.Time) (int64, error) {
+	tag, err := conn.Exec(ctx, "DELETE FROM booking_hold WHERE expires_at <= $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func collectHolds(rows pgx.Rows) ([]models.Hold, error) {
+	defer rows.Close()
+	var holds []models.Hold
+	for rows.Next() {
+		var hold models.Hold
+		err := rows.Scan(&hold.ID, &hold.RoomID, &hold.CustomerID, &hold.StartDate, &hold.EndDate, &hold.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, hold)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return holds, nil
+}
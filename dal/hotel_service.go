@@ -8,12 +8,12 @@ import (
 )
 
 func GetAllHotelServices(ctx context.Context, conn *pgx.Conn) ([]models.HotelService, error) {
-	rows, _ := conn.Query(ctx, "SELECT id, service_type, description, duration FROM hotel_service")
+	rows, _ := conn.Query(ctx, "SELECT id, service_type, description, duration, to_char(opening_time, 'HH24:MI'), to_char(closing_time, 'HH24:MI'), capacity FROM hotel_service")
 	defer rows.Close()
 	var services []models.HotelService
 	for rows.Next() {
 		var service models.HotelService
-		err := rows.Scan(&service.ID, &service.Type, &service.Description, &service.Duration)
+		err := rows.Scan(&service.ID, &service.Type, &service.Description, &service.Duration, &service.OpeningTime, &service.ClosingTime, &service.Capacity)
 		if err != nil {
 			return nil, err
 		}
@@ -26,9 +26,9 @@ func GetAllHotelServices(ctx context.Context, conn *pgx.Conn) ([]models.HotelSer
 }
 
 func GetHotelServiceByID(ctx context.Context, conn *pgx.Conn, serviceID int) (*models.HotelService, error) {
-	row := conn.QueryRow(ctx, "SELECT id, service_type, description, duration FROM hotel_service WHERE id = $1", serviceID)
+	row := conn.QueryRow(ctx, "SELECT id, service_type, description, duration, to_char(opening_time, 'HH24:MI'), to_char(closing_time, 'HH24:MI'), capacity FROM hotel_service WHERE id = $1", serviceID)
 	var service models.HotelService
-	err := row.Scan(&service.ID, &service.Type, &service.Description, &service.Duration)
+	err := row.Scan(&service.ID, &service.Type, &service.Description, &service.Duration, &service.OpeningTime, &service.ClosingTime, &service.Capacity)
 	if err != nil {
 		return nil, err
 	}
@@ -36,14 +36,14 @@ func GetHotelServiceByID(ctx context.Context, conn *pgx.Conn, serviceID int) (*m
 }
 
 func CreateHotelService(ctx context.Context, conn *pgx.Conn, service *models.HotelService) error {
-	row := conn.QueryRow(ctx, "INSERT INTO hotel_service (service_type, description, duration) VALUES ($1, $2, $3) RETURNING id", service.Type, service.Description, service.Duration)
+	row := conn.QueryRow(ctx, "INSERT INTO hotel_service (service_type, description, duration, opening_time, closing_time, capacity) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id", service.Type, service.Description, service.Duration, service.OpeningTime, service.ClosingTime, service.Capacity)
 	err := row.Scan(&service.ID)
 	return err
 }
 
 func UpdateHotelServiceByID(ctx context.Context, conn *pgx.Conn, service *models.HotelService) error {
-	row := conn.QueryRow(ctx, "UPDATE hotel_service SET service_type = $1, description = $2, duration = $3 WHERE id = $4 RETURNING service_type, description, duration", service.Type, service.Description, service.Duration, service.ID)
-	err := row.Scan(&service.Type, &service.Description, &service.Duration)
+	row := conn.QueryRow(ctx, "UPDATE hotel_service SET service_type = $1, description = $2, duration = $3, opening_time = $4, closing_time = $5, capacity = $6 WHERE id = $7 RETURNING service_type, description, duration, to_char(opening_time, 'HH24:MI'), to_char(closing_time, 'HH24:MI'), capacity", service.Type, service.Description, service.Duration, service.OpeningTime, service.ClosingTime, service.Capacity, service.ID)
+	err := row.Scan(&service.Type, &service.Description, &service.Duration, &service.OpeningTime, &service.ClosingTime, &service.Capacity)
 	return err
 }
 
@@ -0,0 +1,122 @@
+package dal
+
+import (
+	"context"
+	"example/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func GetHousekeepingBoard(ctx context.Context, conn *pgx.Conn, date time.Time) ([]models.HousekeepingBoardRow, error) {
+	rows, _ := conn.Query(ctx, `SELECT r.id, r.room_number, r.room_type, r.housekeeping_status,
+	EXISTS (SELECT 1 FROM room_stay s WHERE s.room_id = r.id AND s.status NOT IN ('cancelled', 'no_show') AND s.start_date <= $1 AND s.end_date > $1),
+	EXISTS (SELECT 1 FROM room_stay s WHERE s.room_id = r.id AND s.status NOT IN ('cancelled', 'no_show') AND s.start_date = $1),
+	EXISTS (SELECT 1 FROM room_stay s WHERE s.room_id = r.id AND s.status NOT IN ('cancelled', 'no_show') AND s.end_date = $1),
+	t.id, t.staff_id
+FROM room r LEFT JOIN housekeeping_task t ON t.room_id = r.id AND t.task_date = $1
+ORDER BY r.room_number, r.id`, date)
+	defer rows.Close()
+	var board []models.HousekeepingBoardRow
+	for rows.Next() {
+		var row models.HousekeepingBoardRow
+		err := rows.Scan(&row.RoomID, &row.RoomNumber, &row.RoomType, &row.Status, &row.Occupied, &row.Arrival, &row.Departure, &row.TaskID, &row.StaffID)
+		if err != nil {
+			return nil, err
+		}
+		board = append(board, row)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return board, nil
+}
+
+func GetRoomHousekeepingStatus(ctx context.Context, conn *pgx.Conn, roomID int) (string, error) {
+	row := conn.QueryRow(ctx, "SELECT housekeeping_status FROM room WHERE id = $1", roomID)
+	var status string
+	err := row.Scan(&status)
+	return status, err
+}
+
+func UpdateRoomHousekeepingStatus(ctx context.Context, conn *pgx.Conn, roomID int, status string) error {
+	tag, err := conn.Exec(ctx, "UPDATE room SET housekeeping_status = $1 WHERE id = $2", status, roomID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// GetHousekeepingWork returns the rooms to clean on a date, one task for each departure (also when the guest
+// moves to another room) or stay-over.
+// The tasks are not saved and have no staff member.
+func GetHousekeepingWork(ctx context.Context, conn *pgx.Conn, date time.Time) ([]models.HousekeepingTask, error) {
+	rows, _ := conn.Query(ctx, `SELECT room_id, booking_id, CASE WHEN end_date = $1 THEN 'departure' ELSE 'stay_over' END
+FROM room_stay WHERE status NOT IN ('cancelled', 'no_show') AND start_date < $1 AND end_date >= $1
+ORDER BY room_id`, date)
+	defer rows.Close()
+	var tasks []models.HousekeepingTask
+	for rows.Next() {
+		task := models.HousekeepingTask{Date: date}
+		err := rows.Scan(&task.RoomID, &task.BookingID, &task.Type)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return tasks, nil
+}
+
+// GetHousekeepingTasks returns the tasks of a date, only the ones of a staff member when staffID is not nil
+func GetHousekeepingTasks(ctx context.Context, conn *pgx.Conn, date time.Time, staffID *int) ([]models.HousekeepingTask, error) {
+	rows, _ := conn.Query(ctx, `SELECT id, room_id, booking_id, task_date, task_type, staff_id, completed_at FROM housekeeping_task
+WHERE task_date = $1 AND ($2::int IS NULL OR staff_id = $2) ORDER BY id`, date, staffID)
+	defer rows.Close()
+	var tasks []models.HousekeepingTask
+	for rows.Next() {
+		var task models.HousekeepingTask
+		err := rows.Scan(&task.ID, &task.RoomID, &task.BookingID, &task.Date, &task.Type, &task.StaffID, &task.CompletedAt)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return tasks, nil
+}
+
+func GetHousekeepingTaskByID(ctx context.Context, conn *pgx.Conn, taskID int) (*models.HousekeepingTask, error) {
+	row := conn.QueryRow(ctx, "SELECT id, room_id, booking_id, task_date, task_type, staff_id, completed_at FROM housekeeping_task WHERE id = $1", taskID)
+	var task models.HousekeepingTask
+	err := row.Scan(&task.ID, &task.RoomID, &task.BookingID, &task.Date, &task.Type, &task.StaffID, &task.CompletedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// CreateHousekeepingTask saves a task, rooms that already have a task on that date are skipped
+func CreateHousekeepingTask(ctx context.Context, conn *pgx.Conn, task *models.HousekeepingTask) error {
+	_, err := conn.Exec(ctx, "INSERT INTO housekeeping_task (room_id, booking_id, task_date, task_type, staff_id) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (room_id, task_date) DO NOTHING",
+		task.RoomID, task.BookingID, task.Date, task.Type, task.StaffID)
+	return err
+}
+
+func CompleteHousekeepingTask(ctx context.Context, conn *pgx.Conn, taskID int, completedAt time.Time) error {
+	tag, err := conn.Exec(ctx, "UPDATE housekeeping_task SET completed_at = $1 WHERE id = $2", completedAt, taskID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package dal
+
+import (
+	"context"
+	"example/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const jobRunColumns = "id, job_name, run_trigger, status, coalesce(result, ''), coalesce(error, ''), started_at, finished_at"
+
+// GetJobStates returns the saved state of the jobs by name, jobs that never ran or were never paused have none
+func GetJobStates(ctx context.Context, conn *pgx.Conn) (map[string]models.Job, error) {
+	rows, _ := conn.Query(ctx, "SELECT job_name, paused, next_run_at FROM scheduled_job")
+	defer rows.Close()
+	jobs := make(map[string]models.Job)
+	for rows.Next() {
+		var job models.Job
+		err := rows.Scan(&job.Name, &job.Paused, &job.NextRunAt)
+		if err != nil {
+			return nil, err
+		}
+		jobs[job.Name] = job
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return jobs, nil
+}
+
+func SetJobPaused(ctx context.Context, conn *pgx.Conn, jobName string, paused bool) error {
+	_, err := conn.Exec(ctx, "INSERT INTO scheduled_job (job_name, paused) VALUES ($1, $2) ON CONFLICT (job_name) DO UPDATE SET paused = EXCLUDED.paused", jobName, paused)
+	return err
+}
+
+func SetJobNextRun(ctx context.Context, conn *pgx.Conn, jobName string, nextRunAt time.Time) error {
+	_, err := conn.Exec(ctx, "INSERT INTO scheduled_job (job_name, next_run_at) VALUES ($1, $2) ON CONFLICT (job_name) DO UPDATE SET next_run_at = EXCLUDED.next_run_at", jobName, nextRunAt)
+	return err
+}
+
+// TryLockJob takes the advisory lock of a job for the session of the connection, it returns false when another
+// session (e.g. another replica) holds it
+func TryLockJob(ctx context.Context, conn *pgx.Conn, jobName string) (bool, error) {
+	var locked bool
+	err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock(hashtext('job:' || $1))", jobName).Scan(&locked)
+	return locked, err
+}
+
+func UnlockJob(ctx context.Context, conn *pgx.Conn, jobName string) error {
+	_, err := conn.Exec(ctx, "SELECT pg_advisory_unlock(hashtext('job:' || $1))", jobName)
+	return err
+}
+
+// GetJobRuns returns the last runs of a job, newest first
+func GetJobRuns(ctx context.Context, conn *pgx.Conn, jobName string, limit int) ([]models.JobRun, error) {
+	rows, _ := conn.Query(ctx, "SELECT "+jobRunColumns+" FROM job_run WHERE job_name = $1 ORDER BY started_at DESC, id DESC LIMIT $2", jobName, limit)
+	return collectJobRuns(rows)
+}
+
+// GetLastJobRuns returns the last run of every job that ran at least once
+func GetLastJobRuns(ctx context.Context, conn *pgx.Conn) ([]models.JobRun, error) {
+	rows, _ := conn.Query(ctx, "SELECT DISTINCT ON (job_name) "+jobRunColumns+" FROM job_run ORDER BY job_name, started_at DESC, id DESC")
+	return collectJobRuns(rows)
+}
+
+func CreateJobRun(ctx context.Context, conn *pgx.Conn, run *models.JobRun) error {
+	row := conn.QueryRow(ctx, "INSERT INTO job_run (job_name, run_trigger) VALUES ($1, $2) RETURNING id, status, started_at", run.JobName, run.Trigger)
+	return row.Scan(&run.ID, &run.Status, &run.StartedAt)
+}
+
+func FinishJobRun(ctx context.Context, conn *pgx.Conn, run *models.JobRun) error {
+	row := conn.QueryRow(ctx, "UPDATE job_run SET status = $1, result = NULLIF($2, ''), error = NULLIF($3, ''), finished_at = now() WHERE id = $4 RETURNING finished_at",
+		run.Status, run.Result, run.Error, run.ID)
+	return row.Scan(&run.FinishedAt)
+}
+
+func collectJobRuns(rows pgx.Rows) ([]models.JobRun, error) {
+	defer rows.Close()
+	var runs []models.JobRun
+	for rows.Next() {
+		var run models.JobRun
+		err := rows.Scan(&run.ID, &run.JobName, &run.Trigger, &run.Status, &run.Result, &run.Error, &run.StartedAt, &run.FinishedAt)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return runs, nil
+}
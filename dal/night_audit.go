@@ -0,0 +1,102 @@
+package dal
+
+import (
+	"context"
+	"example/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const nightAuditQuery = "SELECT business_date, closed_at, arrivals, departures, in_house, no_shows, charges_posted, rooms_occupied, rooms_total, room_revenue FROM night_audit"
+
+// GetBusinessDate returns pgx.ErrNoRows when the business date was never set
+func GetBusinessDate(ctx context.Context, conn *pgx.Conn) (time.Time, error) {
+	var date time.Time
+	err := conn.QueryRow(ctx, "SELECT business_date FROM business_day").Scan(&date)
+	return date, err
+}
+
+// CloseBusinessDay runs the night audit of a date in a transaction: the bookings that did not arrive become no
+// shows, the room charges of the guests in house are posted, the summary of the day is saved and the business
+// date moves to the next day. The check-ins and check-outs of the day are counted from midnight to midnight in the
+// timezone of the hotel. It returns the summary and the no show bookings.
+func CloseBusinessDay(ctx context.Context, conn *pgx.Conn, date time.Time, location *time.Location) (*models.NightAudit, []models.Booking, error) {
+	audit := models.NightAudit{BusinessDate: date}
+	dayStart := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, location)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	var noShows []models.Booking
+	err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		rows, _ := tx.Query(ctx, `UPDATE booking SET status = 'no_show' WHERE status = 'confirmed' AND start_date <= $1
+RETURNING id, code, customer_id, room_id, start_date, end_date, status, checked_in_at, checked_out_at, adults, children`, date)
+		for rows.Next() {
+			var booking models.Booking
+			err := rows.Scan(&booking.ID, &booking.Code, &booking.CustomerID, &booking.RoomID, &booking.StartDate, &booking.EndDate, &booking.Status, &booking.CheckedInAt, &booking.CheckedOutAt, &booking.Adults, &booking.Children)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			noShows = append(noShows, booking)
+		}
+		rows.Close()
+		if rows.Err() != nil {
+			return rows.Err()
+		}
+		tag, err := tx.Exec(ctx, `UPDATE folio_charge f SET posted_at = now()
+FROM booking b
+WHERE b.id = f.booking_id AND b.status = 'checked_in' AND f.charge_type = 'room' AND f.posted_at IS NULL AND f.charge_date <= $1`, date)
+		if err != nil {
+			return err
+		}
+		row := tx.QueryRow(ctx, `INSERT INTO night_audit (business_date, arrivals, departures, in_house, no_shows, charges_posted, rooms_occupied, rooms_total, room_revenue)
+SELECT $1,
+    (SELECT count(*) FROM booking WHERE status IN ('checked_in', 'checked_out') AND checked_in_at >= $4 AND checked_in_at < $5),
+    (SELECT count(*) FROM booking WHERE status = 'checked_out' AND checked_out_at >= $4 AND checked_out_at < $5),
+    (SELECT count(*) FROM booking WHERE status = 'checked_in' AND start_date <= $1 AND end_date > $1),
+    $2, $3,
+    (SELECT count(DISTINCT room_id) FROM room_stay WHERE status = 'checked_in' AND start_date <= $1 AND end_date > $1),
+    (SELECT count(*) FROM room),
+    (SELECT coalesce(sum(amount), 0) FROM folio_charge WHERE charge_type = 'room' AND charge_date = $1 AND posted_at IS NOT NULL)
+RETURNING closed_at, arrivals, departures, in_house, no_shows, charges_posted, rooms_occupied, rooms_total, room_revenue`, date, len(noShows), tag.RowsAffected(), dayStart, dayEnd)
+		err = row.Scan(&audit.ClosedAt, &audit.Arrivals, &audit.Departures, &audit.InHouse, &audit.NoShows, &audit.ChargesPosted, &audit.RoomsOccupied, &audit.RoomsTotal, &audit.RoomRevenue)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `INSERT INTO business_day (business_date) VALUES ($1)
+ON CONFLICT (singleton) DO UPDATE SET business_date = EXCLUDED.business_date`, date.AddDate(0, 0, 1))
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &audit, noShows, nil
+}
+
+// GetNightAudits returns the summaries of the closed days, the last one first
+func GetNightAudits(ctx context.Context, conn *pgx.Conn) ([]models.NightAudit, error) {
+	rows, _ := conn.Query(ctx, nightAuditQuery+" ORDER BY business_date DESC")
+	defer rows.Close()
+	var audits []models.NightAudit
+	for rows.Next() {
+		var a models.NightAudit
+		err := rows.Scan(&a.BusinessDate, &a.ClosedAt, &a.Arrivals, &a.Departures, &a.InHouse, &a.NoShows, &a.ChargesPosted, &a.RoomsOccupied, &a.RoomsTotal, &a.RoomRevenue)
+		if err != nil {
+			return nil, err
+		}
+		audits = append(audits, a)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return audits, nil
+}
+
+func GetNightAudit(ctx context.Context, conn *pgx.Conn, date time.Time) (*models.NightAudit, error) {
+	row := conn.QueryRow(ctx, nightAuditQuery+" WHERE business_date = $1", date)
+	var a models.NightAudit
+	err := row.Scan(&a.BusinessDate, &a.ClosedAt, &a.Arrivals, &a.Departures, &a.InHouse, &a.NoShows, &a.ChargesPosted, &a.RoomsOccupied, &a.RoomsTotal, &a.RoomRevenue)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
@@ -0,0 +1,62 @@
+package dal
+
+import (
+	"context"
+	"example/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const notificationQuery = "SELECT id, customer_id, coalesce(booking_id, 0), kind, recipient, lang, subject, body, status, coalesce(error, ''), created_at, sent_at FROM notification"
+
+// GetNotifications returns the send log, newest first, only for a booking or a customer when the ID is not 0
+func GetNotifications(ctx context.Context, conn *pgx.Conn, bookingID int, customerID int) ([]models.Notification, error) {
+	rows, _ := conn.Query(ctx, notificationQuery+`
+WHERE ($1 = 0 OR booking_id = $1) AND ($2 = 0 OR customer_id = $2)
+ORDER BY created_at DESC, id DESC`, bookingID, customerID)
+	return collectNotifications(rows)
+}
+
+// GetPendingNotifications returns at most limit notifications still to send, oldest first
+func GetPendingNotifications(ctx context.Context, conn *pgx.Conn, limit int) ([]models.Notification, error) {
+	rows, _ := conn.Query(ctx, notificationQuery+" WHERE status = 'pending' ORDER BY created_at, id LIMIT $1", limit)
+	return collectNotifications(rows)
+}
+
+// HasSentNotification tells if an email of the kind was already sent, or is about to be, for the booking
+func HasSentNotification(ctx context.Context, conn *pgx.Conn, bookingID int, kind string) (bool, error) {
+	var sent bool
+	err := conn.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM notification WHERE booking_id = $1 AND kind = $2 AND status IN ('pending', 'sent'))", bookingID, kind).Scan(&sent)
+	return sent, err
+}
+
+// CreateNotification saves a notification to send
+func CreateNotification(ctx context.Context, conn *pgx.Conn, notification *models.Notification) error {
+	row := conn.QueryRow(ctx, "INSERT INTO notification (customer_id, booking_id, kind, recipient, lang, subject, body) VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6, $7) RETURNING id, status, created_at",
+		notification.CustomerID, notification.BookingID, notification.Kind, notification.Recipient, notification.Language, notification.Subject, notification.Body)
+	return row.Scan(&notification.ID, &notification.Status, &notification.CreatedAt)
+}
+
+// FinishNotification records that a notification was sent or failed
+func FinishNotification(ctx context.Context, conn *pgx.Conn, notification *models.Notification) error {
+	row := conn.QueryRow(ctx, "UPDATE notification SET status = $1, error = NULLIF($2, ''), sent_at = now() WHERE id = $3 RETURNING sent_at",
+		notification.Status, notification.Error, notification.ID)
+	return row.Scan(&notification.SentAt)
+}
+
+func collectNotifications(rows pgx.Rows) ([]models.Notification, error) {
+	defer rows.Close()
+	var notifications []models.Notification
+	for rows.Next() {
+		var n models.Notification
+		err := rows.Scan(&n.ID, &n.CustomerID, &n.BookingID, &n.Kind, &n.Recipient, &n.Language, &n.Subject, &n.Body, &n.Status, &n.Error, &n.CreatedAt, &n.SentAt)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return notifications, nil
+}
@@ -0,0 +1,48 @@
+package dal
+
+import (
+	"context"
+	"example/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func GetBookingOccupants(ctx context.Context, conn *pgx.Conn, bookingID int) ([]models.Occupant, error) {
+	rows, _ := conn.Query(ctx, "SELECT first_name, last_name, birth_date, nationality, document_type, document_number FROM booking_occupant WHERE booking_id = $1 ORDER BY id", bookingID)
+	defer rows.Close()
+	var occupants []models.Occupant
+	for rows.Next() {
+		var occupant models.Occupant
+		err := rows.Scan(&occupant.FirstName, &occupant.LastName, &occupant.BirthDate, &occupant.Nationality, &occupant.DocumentType, &occupant.DocumentNumber)
+		if err != nil {
+			return nil, err
+		}
+		occupants = append(occupants, occupant)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return occupants, nil
+}
+
+// UpdateBookingOccupants replaces the occupants of a booking
+func UpdateBookingOccupants(ctx context.Context, conn *pgx.Conn, bookingID int, occupants []models.Occupant) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "DELETE FROM booking_occupant WHERE booking_id = $1", bookingID)
+		if err != nil {
+			return err
+		}
+		return insertOccupants(ctx, tx, bookingID, occupants)
+	})
+}
+
+func insertOccupants(ctx context.Context, tx pgx.Tx, bookingID int, occupants []models.Occupant) error {
+	for _, o := range occupants {
+		_, err := tx.Exec(ctx, "INSERT INTO booking_occupant (booking_id, first_name, last_name, birth_date, nationality, document_type, document_number) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+			bookingID, o.FirstName, o.LastName, o.BirthDate, o.Nationality, o.DocumentType, o.DocumentNumber)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"example/logging"
+	"example/metrics"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("example/dal")
+
+// QueryTracer measures the queries by dal function, traces them in a span and logs them at debug level with the
+// logger of their context, so that they carry the ID of the request or the job that runs them. Set it as the Tracer
+// of the connection config.
+type QueryTracer struct{}
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	connection string
+	function   string
+	sql        string
+	start      time.Time
+	span       trace.Span
+}
+
+// TraceQueryStart starts the span of the query, the statement is recorded without its arguments
+func (QueryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	connection := metrics.ConnectionName(conn)
+	metrics.QueryStarted(connection)
+	function := caller()
+	ctx, span := tracer.Start(ctx, "dal."+function,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(operation(data.SQL)),
+			semconv.DBQueryText(data.SQL),
+		),
+	)
+	return context.WithValue(ctx, queryStartKey{}, queryStart{connection: connection, function: function, sql: data.SQL, start: time.Now(), span: span})
+}
+
+// TraceQueryEnd logs the query without its arguments, they may hold personal data
+func (QueryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	q, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	duration := time.Since(q.start)
+	metrics.ObserveQuery(q.connection, q.function, duration, data.Err)
+	if data.Err != nil && !errors.Is(data.Err, pgx.ErrNoRows) {
+		q.span.RecordError(data.Err)
+		q.span.SetStatus(codes.Error, data.Err.Error())
+	}
+	q.span.End()
+	logger := logging.FromContext(ctx)
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("function", q.function),
+		slog.String("sql", q.sql),
+		slog.Duration("duration", duration),
+		slog.Int64("rows", data.CommandTag.RowsAffected()),
+	}
+	if data.Err != nil {
+		attrs = append(attrs, slog.String("error", data.Err.Error()))
+	}
+	logger.LogAttrs(ctx, slog.LevelDebug, "Query", attrs...)
+}
+
+// caller returns the name of the dal function running the query, "other" for the queries made outside dal
+func caller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if name, ok := strings.CutPrefix(frame.Function, "example/dal."); ok && !strings.HasPrefix(name, "QueryTracer.") {
+			// a function literal, e.g. the transaction of CloseBusinessDay.func1, counts for its function
+			name, _, _ = strings.Cut(name, ".")
+			return name
+		}
+		if !more {
+			return "other"
+		}
+	}
+}
+
+// operation returns the first keyword of a statement, e.g. SELECT
+func operation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToUpper(fields[0])
+}
@@ -0,0 +1,65 @@
+package dal
+
+import (
+	"context"
+	"example/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// every room contributes one available room night per night of the range, a room night is sold
+// when a stay covers it; the window partitions aggregate the room nights of each period
+const kpiQuery = `
+WITH nights AS (
+    SELECT night::date AS night FROM generate_series($1::date, $2::date, interval '1 day') AS night
+), room_nights AS (
+    SELECT date_trunc($3::text, n.night)::date AS period,
+           CASE WHEN $4::boolean THEN r.room_type::text ELSE 'all' END AS room_type,
+           s.booking_id IS NOT NULL AS sold,
+           CASE WHEN s.booking_id IS NULL THEN 0 ELSE r.price END AS revenue
+    FROM nights n
+    CROSS JOIN room r
+    LEFT JOIN room_stay s ON s.room_id = r.id AND n.night >= s.start_date AND n.night < s.end_date
+        AND s.status NOT IN ('cancelled', 'no_show')
+)
+SELECT DISTINCT period, room_type,
+       count(*) OVER w AS rooms_available,
+       count(*) FILTER (WHERE sold) OVER w AS rooms_sold,
+       sum(revenue) OVER w AS room_revenue
+FROM room_nights
+WINDOW w AS (PARTITION BY period, room_type)
+ORDER BY period, room_type`
+
+func GetKPIReport(ctx context.Context, conn *pgx.Conn, params models.ReportParams) ([]models.KPIRow, error) {
+	rows, _ := conn.Query(ctx, kpiQuery, params.StartDate, params.EndDate, params.GroupBy, params.ByRoomType)
+	defer rows.Close()
+	var report []models.KPIRow
+	for rows.Next() {
+		var row models.KPIRow
+		err := rows.Scan(&row.Period, &row.RoomType, &row.RoomsAvailable, &row.RoomsSold, &row.RoomRevenue)
+		if err != nil {
+			return nil, err
+		}
+		report = append(report, row)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return report, nil
+}
+
+// GetHotelStats counts the guests in house and the movements of a date
+func GetHotelStats(ctx context.Context, conn *pgx.Conn, date time.Time) (*models.HotelStats, error) {
+	var stats models.HotelStats
+	err := conn.QueryRow(ctx, `SELECT
+    (SELECT coalesce(sum(adults + children), 0) FROM booking WHERE status = 'checked_in'),
+    (SELECT count(*) FROM booking WHERE NOT allotment AND status IN ('confirmed', 'checked_in', 'checked_out') AND start_date = $1),
+    (SELECT count(*) FROM booking WHERE NOT allotment AND status IN ('confirmed', 'checked_in', 'checked_out') AND end_date = $1),
+    (SELECT count(*) FROM service_request WHERE service_date >= $1)`, date).
+		Scan(&stats.InHouseGuests, &stats.Arrivals, &stats.Departures, &stats.OpenServiceRequests)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
@@ -3,17 +3,39 @@ package dal
 import (
 	"context"
 	"example/models"
+	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 )
 
 func GetAllReviews(ctx context.Context, conn *pgx.Conn) ([]models.Review, error) {
-	rows, _ := conn.Query(ctx, "SELECT booking_id, review_comment, rating, review_date FROM review")
+	rows, _ := conn.Query(ctx, `SELECT rv.booking_id, b.customer_id, rv.review_comment, rv.rating, rv.review_date, rv.status, COALESCE(rv.moderation_reason, ''), COALESCE(rv.reply, ''), rv.reply_date
+		FROM review rv JOIN booking b ON b.id = rv.booking_id`)
 	defer rows.Close()
 	var reviews []models.Review
 	for rows.Next() {
 		var review models.Review
-		err := rows.Scan(&review.BookingID, &review.Comment, &review.Rating, &review.Date)
+		err := rows.Scan(&review.BookingID, &review.CustomerID, &review.Comment, &review.Rating, &review.Date, &review.Status, &review.ModerationReason, &review.Reply, &review.ReplyDate)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return reviews, nil
+}
+
+func GetReviewsByStatus(ctx context.Context, conn *pgx.Conn, status string) ([]models.Review, error) {
+	rows, _ := conn.Query(ctx, `SELECT rv.booking_id, b.customer_id, rv.review_comment, rv.rating, rv.review_date, rv.status, COALESCE(rv.moderation_reason, ''), COALESCE(rv.reply, ''), rv.reply_date
+		FROM review rv JOIN booking b ON b.id = rv.booking_id WHERE rv.status = $1`, status)
+	defer rows.Close()
+	var reviews []models.Review
+	for rows.Next() {
+		var review models.Review
+		err := rows.Scan(&review.BookingID, &review.CustomerID, &review.Comment, &review.Rating, &review.Date, &review.Status, &review.ModerationReason, &review.Reply, &review.ReplyDate)
 		if err != nil {
 			return nil, err
 		}
@@ -26,24 +48,87 @@ func GetAllReviews(ctx context.Context, conn *pgx.Conn) ([]models.Review, error)
 }
 
 func GetReviewByID(ctx context.Context, conn *pgx.Conn, reviewID int) (*models.Review, error) {
-	row := conn.QueryRow(ctx, "SELECT booking_id, review_comment, rating, review_date FROM review WHERE booking_id = $1", reviewID)
+	row := conn.QueryRow(ctx, `SELECT rv.booking_id, b.customer_id, rv.review_comment, rv.rating, rv.review_date, rv.status, COALESCE(rv.moderation_reason, ''), COALESCE(rv.reply, ''), rv.reply_date
+		FROM review rv JOIN booking b ON b.id = rv.booking_id WHERE rv.booking_id = $1`, reviewID)
 	var review models.Review
-	err := row.Scan(&review.BookingID, &review.Comment, &review.Rating, &review.Date)
+	err := row.Scan(&review.BookingID, &review.CustomerID, &review.Comment, &review.Rating, &review.Date, &review.Status, &review.ModerationReason, &review.Reply, &review.ReplyDate)
 	if err != nil {
 		return nil, err
 	}
 	return &review, nil
 }
 
+// GetReviewsByRoomID returns the published reviews of the bookings of a room
+func GetReviewsByRoomID(ctx context.Context, conn *pgx.Conn, roomID int) ([]models.Review, error) {
+	rows, _ := conn.Query(ctx, `SELECT rv.booking_id, b.customer_id, rv.review_comment, rv.rating, rv.review_date, rv.status, COALESCE(rv.moderation_reason, ''), COALESCE(rv.reply, ''), rv.reply_date
+		FROM review rv
+		JOIN booking b ON b.id = rv.booking_id
+		WHERE b.room_id = $1 AND rv.status = 'published' ORDER BY rv.review_date`, roomID)
+	defer rows.Close()
+	var reviews []models.Review
+	for rows.Next() {
+		var review models.Review
+		err := rows.Scan(&review.BookingID, &review.CustomerID, &review.Comment, &review.Rating, &review.Date, &review.Status, &review.ModerationReason, &review.Reply, &review.ReplyDate)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return reviews, nil
+}
+
+// review stats group keys, the key is never taken from the request as is
+var reviewStatsGroups = map[string]string{
+	"room":      "r.room_number::text",
+	"room_type": "r.room_type::text",
+	"month":     "to_char(rv.review_date, 'YYYY-MM')",
+}
+
+// GetReviewStats aggregates the published reviews
+func GetReviewStats(ctx context.Context, conn *pgx.Conn, groupBy string) ([]models.ReviewStats, error) {
+	group, ok := reviewStatsGroups[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown review stats group %q", groupBy)
+	}
+	query := fmt.Sprintf(`SELECT %s AS grp, count(*), round(avg(rv.rating), 2)::float8,
+		count(*) FILTER (WHERE rv.rating = 1), count(*) FILTER (WHERE rv.rating = 2), count(*) FILTER (WHERE rv.rating = 3),
+		count(*) FILTER (WHERE rv.rating = 4), count(*) FILTER (WHERE rv.rating = 5)
+		FROM review rv
+		JOIN booking b ON b.id = rv.booking_id
+		JOIN room r ON r.id = b.room_id
+		WHERE rv.status = 'published'
+		GROUP BY grp ORDER BY grp`, group)
+	rows, _ := conn.Query(ctx, query)
+	defer rows.Close()
+	var stats []models.ReviewStats
+	for rows.Next() {
+		var s models.ReviewStats
+		var d [5]int
+		err := rows.Scan(&s.Group, &s.Reviews, &s.AverageRating, &d[0], &d[1], &d[2], &d[3], &d[4])
+		if err != nil {
+			return nil, err
+		}
+		s.Distribution = map[int]int{1: d[0], 2: d[1], 3: d[2], 4: d[3], 5: d[4]}
+		stats = append(stats, s)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return stats, nil
+}
+
 func CreateReview(ctx context.Context, conn *pgx.Conn, review *models.Review) error {
-	row := conn.QueryRow(ctx, "INSERT INTO review (booking_id, review_comment, rating, review_date) VALUES ($1, $2, $3, $4) RETURNING booking_id", review.BookingID, review.Comment, review.Rating, review.Date)
+	row := conn.QueryRow(ctx, "INSERT INTO review (booking_id, review_comment, rating, review_date, status, moderation_reason) VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')) RETURNING booking_id", review.BookingID, review.Comment, review.Rating, review.Date, review.Status, review.ModerationReason)
 	err := row.Scan(&review.BookingID)
 	return err
 }
 
 func UpdateReviewByID(ctx context.Context, conn *pgx.Conn, review *models.Review) error {
-	row := conn.QueryRow(ctx, "UPDATE review SET review_comment = $1, rating = $2, review_date = $3 WHERE booking_id = $4 RETURNING review_comment, rating, review_date", review.Comment, review.Rating, review.Date, review.BookingID)
-	err := row.Scan(&review.Comment, &review.Rating, &review.Date)
+	row := conn.QueryRow(ctx, "UPDATE review SET review_comment = $1, rating = $2, review_date = $3, status = $4, moderation_reason = NULLIF($5, '') WHERE booking_id = $6 RETURNING review_comment, rating, review_date, status, COALESCE(moderation_reason, ''), COALESCE(reply, ''), reply_date", review.Comment, review.Rating, review.Date, review.Status, review.ModerationReason, review.BookingID)
+	err := row.Scan(&review.Comment, &review.Rating, &review.Date, &review.Status, &review.ModerationReason, &review.Reply, &review.ReplyDate)
 	return err
 }
 
@@ -59,6 +144,33 @@ func PatchReviewByID(ctx context.Context, conn *pgx.Conn, reviewID int, patch mo
 	return nil
 }
 
+func UpdateReviewModeration(ctx context.Context, conn *pgx.Conn, reviewID int, status string, reason string) error {
+	tag, err := conn.Exec(ctx, "UPDATE review SET status = $1, moderation_reason = NULLIF($2, '') WHERE booking_id = $3", status, reason, reviewID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateReviewReply sets the reply of the hotel, an empty reply removes it
+func UpdateReviewReply(ctx context.Context, conn *pgx.Conn, reviewID int, reply string, date time.Time) error {
+	var replyDate *time.Time
+	if reply != "" {
+		replyDate = &date
+	}
+	tag, err := conn.Exec(ctx, "UPDATE review SET reply = NULLIF($1, ''), reply_date = $2 WHERE booking_id = $3", reply, replyDate, reviewID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
 func DeleteReviewByID(ctx context.Context, conn *pgx.Conn, reviewID int) error {
 	tag, err := conn.Exec(ctx, "DELETE FROM review WHERE booking_id = $1", reviewID)
 	if err != nil {
@@ -3,6 +3,7 @@ package dal
 import (
 	"context"
 	"example/models"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 )
@@ -35,6 +36,36 @@ func GetRoomByID(ctx context.Context, conn *pgx.Conn, roomID int) (*models.Room,
 	return &room, nil
 }
 
+// GetAvailableRooms returns the rooms with no active booking and no block between the given dates, end date excluded.
+// An empty roomType matches every type.
+func GetAvailableRooms(ctx context.Context, conn *pgx.Conn, startDate, endDate time.Time, roomType string, guests int, now time.Time) ([]models.Room, error) {
+	rows, _ := conn.Query(ctx, `SELECT r.id, r.room_number, r.room_type, r.price, r.capacity FROM room r
+WHERE ($3 = '' OR r.room_type::text = $3) AND r.capacity >= $4
+AND NOT EXISTS (
+	SELECT 1 FROM room_stay s
+	WHERE s.room_id = r.id AND s.status NOT IN ('cancelled', 'no_show') AND s.start_date < $2 AND s.end_date > $1
+) AND NOT EXISTS (
+	SELECT 1 FROM room_block k WHERE k.room_id = r.id AND k.start_date < $2 AND k.end_date > $1
+) AND NOT EXISTS (
+	SELECT 1 FROM booking_hold h WHERE h.room_id = r.id AND h.expires_at > $5 AND h.start_date < $2 AND h.end_date > $1
+)
+ORDER BY r.price, r.room_number`, startDate, endDate, roomType, guests, now)
+	defer rows.Close()
+	var rooms []models.Room
+	for rows.Next() {
+		var room models.Room
+		err := rows.Scan(&room.ID, &room.Number, &room.Type, &room.Price, &room.Capacity)
+		if err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, room)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return rooms, nil
+}
+
 func CreateRoom(ctx context.Context, conn *pgx.Conn, room *models.Room) error {
 	row := conn.QueryRow(ctx, "INSERT INTO room (room_number, room_type, price, capacity) VALUES ($1, $2, $3, $4) RETURNING id", room.Number, room.Type, room.Price, room.Capacity)
 	err := row.Scan(&room.ID)
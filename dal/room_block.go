@@ -0,0 +1,80 @@
+package dal
+
+import (
+	"context"
+	"example/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func GetRoomBlocksByRoomID(ctx context.Context, conn *pgx.Conn, roomID int) ([]models.RoomBlock, error) {
+	rows, _ := conn.Query(ctx, "SELECT id, room_id, start_date, end_date, reason FROM room_block WHERE room_id = $1 ORDER BY start_date", roomID)
+	return collectRoomBlocks(rows)
+}
+
+// GetOverlappingRoomBlocks returns the blocks of a room that overlap the given dates, end date excluded
+func GetOverlappingRoomBlocks(ctx context.Context, conn *pgx.Conn, roomID int, startDate, endDate time.Time) ([]models.RoomBlock, error) {
+	rows, _ := conn.Query(ctx, "SELECT id, room_id, start_date, end_date, reason FROM room_block WHERE room_id = $1 AND start_date < $3 AND end_date > $2 ORDER BY start_date", roomID, startDate, endDate)
+	return collectRoomBlocks(rows)
+}
+
+func CreateRoomBlock(ctx context.Context, conn *pgx.Conn, block *models.RoomBlock) error {
+	row := conn.QueryRow(ctx, "INSERT INTO room_block (room_id, start_date, end_date, reason) VALUES ($1, $2, $3, $4) RETURNING id", block.RoomID, block.StartDate, block.EndDate, block.Reason)
+	err := row.Scan(&block.ID)
+	return err
+}
+
+// DeleteRoomBlock lifts a block and returns it
+func DeleteRoomBlock(ctx context.Context, conn *pgx.Conn, roomID int, blockID int) (*models.RoomBlock, error) {
+	row := conn.QueryRow(ctx, "DELETE FROM room_block WHERE id = $1 AND room_id = $2 RETURNING id, room_id, start_date, end_date, reason", blockID, roomID)
+	var block models.RoomBlock
+	err := row.Scan(&block.ID, &block.RoomID, &block.StartDate, &block.EndDate, &block.Reason)
+	if err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// GetRoomBlockConflicts returns the active bookings overlapping a block that ends after the given date
+func GetRoomBlockConflicts(ctx context.Context, conn *pgx.Conn, from time.Time) ([]models.RoomBlockConflict, error) {
+	rows, _ := conn.Query(ctx, `SELECT k.id, k.room_id, k.start_date, k.end_date, k.reason,
+	b.id, b.code, b.customer_id, b.room_id, b.start_date, b.end_date, b.status, b.checked_in_at, b.checked_out_at, b.adults, b.children
+FROM room_block k
+JOIN room_stay s ON s.room_id = k.room_id AND s.start_date < k.end_date AND s.end_date > k.start_date
+JOIN booking b ON b.id = s.booking_id
+WHERE k.end_date > $1 AND b.status NOT IN ('cancelled', 'no_show', 'checked_out')
+ORDER BY k.start_date, b.start_date`, from)
+	defer rows.Close()
+	var conflicts []models.RoomBlockConflict
+	for rows.Next() {
+		var c models.RoomBlockConflict
+		err := rows.Scan(&c.Block.ID, &c.Block.RoomID, &c.Block.StartDate, &c.Block.EndDate, &c.Block.Reason,
+			&c.Booking.ID, &c.Booking.Code, &c.Booking.CustomerID, &c.Booking.RoomID, &c.Booking.StartDate, &c.Booking.EndDate, &c.Booking.Status, &c.Booking.CheckedInAt, &c.Booking.CheckedOutAt, &c.Booking.Adults, &c.Booking.Children)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, c)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return conflicts, nil
+}
+
+func collectRoomBlocks(rows pgx.Rows) ([]models.RoomBlock, error) {
+	defer rows.Close()
+	var blocks []models.RoomBlock
+	for rows.Next() {
+		var block models.RoomBlock
+		err := rows.Scan(&block.ID, &block.RoomID, &block.StartDate, &block.EndDate, &block.Reason)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return blocks, nil
+}
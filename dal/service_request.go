@@ -3,46 +3,49 @@ package dal
 import (
 	"context"
 	"example/models"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func GetAllServiceRequests(ctx context.Context, conn *pgx.Conn) ([]models.ServiceRequest, error) {
-	rows, _ := conn.Query(ctx, "SELECT id, customer_id, service_id, service_date FROM service_request")
-	defer rows.Close()
-	var requests []models.ServiceRequest
-	for rows.Next() {
-		var request models.ServiceRequest
-		err := rows.Scan(&request.ID, &request.CustomerID, &request.ServiceID, &request.Date)
-		if err != nil {
-			return nil, err
-		}
-		requests = append(requests, request)
-	}
-	if rows.Err() != nil {
-		return nil, rows.Err()
-	}
-	return requests, nil
+	rows, _ := conn.Query(ctx, "SELECT id, customer_id, service_id, service_date, start_time, staff_id FROM service_request")
+	return collectServiceRequests(rows)
 }
 
 func GetServiceRequestByID(ctx context.Context, conn *pgx.Conn, requestID int) (*models.ServiceRequest, error) {
-	row := conn.QueryRow(ctx, "SELECT id, customer_id, service_id, service_date FROM service_request WHERE id = $1", requestID)
+	row := conn.QueryRow(ctx, "SELECT id, customer_id, service_id, service_date, start_time, staff_id FROM service_request WHERE id = $1", requestID)
 	var request models.ServiceRequest
-	err := row.Scan(&request.ID, &request.CustomerID, &request.ServiceID, &request.Date)
+	var startTime pgtype.Time
+	err := row.Scan(&request.ID, &request.CustomerID, &request.ServiceID, &request.Date, &startTime, &request.StaffID)
 	if err != nil {
 		return nil, err
 	}
+	request.StartTime = time.Duration(startTime.Microseconds) * time.Microsecond
 	return &request, nil
 }
 
+// GetServiceRequestsByServiceAndDate returns the requests of a service on a date, used to check its capacity
+func GetServiceRequestsByServiceAndDate(ctx context.Context, conn *pgx.Conn, serviceID int, date time.Time) ([]models.ServiceRequest, error) {
+	rows, _ := conn.Query(ctx, "SELECT id, customer_id, service_id, service_date, start_time, staff_id FROM service_request WHERE service_id = $1 AND service_date = $2 ORDER BY start_time", serviceID, date)
+	return collectServiceRequests(rows)
+}
+
+// GetServiceRequestsByStaffID returns the requests assigned to a staff member between two dates, both included
+func GetServiceRequestsByStaffID(ctx context.Context, conn *pgx.Conn, staffID int, from, to time.Time) ([]models.ServiceRequest, error) {
+	rows, _ := conn.Query(ctx, "SELECT id, customer_id, service_id, service_date, start_time, staff_id FROM service_request WHERE staff_id = $1 AND service_date BETWEEN $2 AND $3 ORDER BY service_date, start_time", staffID, from, to)
+	return collectServiceRequests(rows)
+}
+
 func CreateServiceRequest(ctx context.Context, conn *pgx.Conn, request *models.ServiceRequest) error {
-	row := conn.QueryRow(ctx, "INSERT INTO service_request (customer_id, service_id, service_date) VALUES ($1, $2, $3) RETURNING id", request.CustomerID, request.ServiceID, request.Date)
+	row := conn.QueryRow(ctx, "INSERT INTO service_request (customer_id, service_id, service_date, start_time, staff_id) VALUES ($1, $2, $3, $4, $5) RETURNING id", request.CustomerID, request.ServiceID, request.Date, toPgTime(request.StartTime), request.StaffID)
 	err := row.Scan(&request.ID)
 	return err
 }
 
 func UpdateServiceRequestByID(ctx context.Context, conn *pgx.Conn, request *models.ServiceRequest) error {
-	row := conn.QueryRow(ctx, "UPDATE service_request SET customer_id = $1, service_id = $2, service_date = $3 WHERE id = $4 RETURNING customer_id, service_id, service_date", request.CustomerID, request.ServiceID, request.Date, request.ID)
+	row := conn.QueryRow(ctx, "UPDATE service_request SET customer_id = $1, service_id = $2, service_date = $3, start_time = $4, staff_id = $5 WHERE id = $6 RETURNING customer_id, service_id, service_date", request.CustomerID, request.ServiceID, request.Date, toPgTime(request.StartTime), request.StaffID, request.ID)
 	err := row.Scan(&request.CustomerID, &request.ServiceID, &request.Date)
 	return err
 }
@@ -59,6 +62,18 @@ func PatchServiceRequestByID(ctx context.Context, conn *pgx.Conn, requestID int,
 	return nil
 }
 
+// UpdateServiceRequestStaff assigns a request to a staff member, a nil staffID leaves it unassigned
+func UpdateServiceRequestStaff(ctx context.Context, conn *pgx.Conn, requestID int, staffID *int) error {
+	tag, err := conn.Exec(ctx, "UPDATE service_request SET staff_id = $1 WHERE id = $2", staffID, requestID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
 func DeleteServiceRequestByID(ctx context.Context, conn *pgx.Conn, requestID int) error {
 	tag, err := conn.Exec(ctx, "DELETE FROM service_request WHERE id = $1", requestID)
 	if err != nil {
@@ -69,3 +84,26 @@ func DeleteServiceRequestByID(ctx context.Context, conn *pgx.Conn, requestID int
 	}
 	return nil
 }
+
+func toPgTime(d time.Duration) pgtype.Time {
+	return pgtype.Time{Microseconds: d.Microseconds(), Valid: true}
+}
+
+func collectServiceRequests(rows pgx.Rows) ([]models.ServiceRequest, error) {
+	defer rows.Close()
+	var requests []models.ServiceRequest
+	for rows.Next() {
+		var request models.ServiceRequest
+		var startTime pgtype.Time
+		err := rows.Scan(&request.ID, &request.CustomerID, &request.ServiceID, &request.Date, &startTime, &request.StaffID)
+		if err != nil {
+			return nil, err
+		}
+		request.StartTime = time.Duration(startTime.Microseconds) * time.Microsecond
+		requests = append(requests, request)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return requests, nil
+}
@@ -0,0 +1,175 @@
+package dal
+
+import (
+	"context"
+	"example/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const staffQuery = `SELECT s.id, s.staff_name, s.email,
+	coalesce(array_agg(k.service_type::text ORDER BY k.service_type) FILTER (WHERE k.service_type IS NOT NULL), '{}')
+FROM staff s LEFT JOIN staff_skill k ON k.staff_id = s.id`
+
+func GetAllStaff(ctx context.Context, conn *pgx.Conn) ([]models.Staff, error) {
+	rows, _ := conn.Query(ctx, staffQuery+" GROUP BY s.id ORDER BY s.id")
+	defer rows.Close()
+	var staff []models.Staff
+	for rows.Next() {
+		var member models.Staff
+		err := rows.Scan(&member.ID, &member.Name, &member.Email, &member.Skills)
+		if err != nil {
+			return nil, err
+		}
+		staff = append(staff, member)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return staff, nil
+}
+
+func GetStaffByID(ctx context.Context, conn *pgx.Conn, staffID int) (*models.Staff, error) {
+	row := conn.QueryRow(ctx, staffQuery+" WHERE s.id = $1 GROUP BY s.id", staffID)
+	var member models.Staff
+	err := row.Scan(&member.ID, &member.Name, &member.Email, &member.Skills)
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func CreateStaff(ctx context.Context, conn *pgx.Conn, member *models.Staff) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		row := tx.QueryRow(ctx, "INSERT INTO staff (staff_name, email) VALUES ($1, $2) RETURNING id", member.Name, member.Email)
+		err := row.Scan(&member.ID)
+		if err != nil {
+			return err
+		}
+		return insertStaffSkills(ctx, tx, member)
+	})
+}
+
+func UpdateStaffByID(ctx context.Context, conn *pgx.Conn, member *models.Staff) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "UPDATE staff SET staff_name = $1, email = $2 WHERE id = $3", member.Name, member.Email, member.ID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return pgx.ErrNoRows
+		}
+		_, err = tx.Exec(ctx, "DELETE FROM staff_skill WHERE staff_id = $1", member.ID)
+		if err != nil {
+			return err
+		}
+		return insertStaffSkills(ctx, tx, member)
+	})
+}
+
+func DeleteStaffByID(ctx context.Context, conn *pgx.Conn, staffID int) error {
+	tag, err := conn.Exec(ctx, "DELETE FROM staff WHERE id = $1", staffID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func insertStaffSkills(ctx context.Context, tx pgx.Tx, member *models.Staff) error {
+	_, err := tx.Exec(ctx, "INSERT INTO staff_skill (staff_id, service_type) SELECT $1, unnest($2::text[]::hotel_services[])", member.ID, member.Skills)
+	return err
+}
+
+// GetStaffShifts returns the shifts of a staff member between two dates, both included
+func GetStaffShifts(ctx context.Context, conn *pgx.Conn, staffID int, from, to time.Time) ([]models.StaffShift, error) {
+	rows, _ := conn.Query(ctx, "SELECT id, staff_id, shift_date, start_time, end_time FROM staff_shift WHERE staff_id = $1 AND shift_date BETWEEN $2 AND $3 ORDER BY shift_date, start_time", staffID, from, to)
+	defer rows.Close()
+	var shifts []models.StaffShift
+	for rows.Next() {
+		var shift models.StaffShift
+		var startTime, endTime pgtype.Time
+		err := rows.Scan(&shift.ID, &shift.StaffID, &shift.Date, &startTime, &endTime)
+		if err != nil {
+			return nil, err
+		}
+		shift.StartTime = time.Duration(startTime.Microseconds) * time.Microsecond
+		shift.EndTime = time.Duration(endTime.Microseconds) * time.Microsecond
+		shifts = append(shifts, shift)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return shifts, nil
+}
+
+func CreateStaffShift(ctx context.Context, conn *pgx.Conn, shift *models.StaffShift) error {
+	row := conn.QueryRow(ctx, "INSERT INTO staff_shift (staff_id, shift_date, start_time, end_time) VALUES ($1, $2, $3, $4) RETURNING id", shift.StaffID, shift.Date, toPgTime(shift.StartTime), toPgTime(shift.EndTime))
+	err := row.Scan(&shift.ID)
+	return err
+}
+
+// DeleteStaffShift deletes a shift and returns its date
+func DeleteStaffShift(ctx context.Context, conn *pgx.Conn, staffID int, shiftID int) (time.Time, error) {
+	row := conn.QueryRow(ctx, "DELETE FROM staff_shift WHERE id = $1 AND staff_id = $2 RETURNING shift_date", shiftID, staffID)
+	var date time.Time
+	err := row.Scan(&date)
+	return date, err
+}
+
+// GetAvailableStaff returns the staff members that can perform a service between start and end of the given date:
+// they have the skill, a shift covering the whole time and no other overlapping request.
+// The least loaded staff members of the day come first.
+func GetAvailableStaff(ctx context.Context, conn *pgx.Conn, serviceType string, date time.Time, start, end time.Duration, excludeRequestID int) ([]int, error) {
+	rows, _ := conn.Query(ctx, `SELECT s.id
+FROM staff s JOIN staff_skill k ON k.staff_id = s.id AND k.service_type = $1::text::hotel_services
+WHERE EXISTS (
+	SELECT 1 FROM staff_shift sh
+	WHERE sh.staff_id = s.id AND sh.shift_date = $2 AND sh.start_time <= $3 AND sh.end_time >= $4
+) AND NOT EXISTS (
+	SELECT 1 FROM service_request r JOIN hotel_service hs ON hs.id = r.service_id
+	WHERE r.staff_id = s.id AND r.service_date = $2 AND r.id <> $5
+	AND r.start_time < $4 AND r.start_time + make_interval(mins => hs.duration) > $3
+)
+ORDER BY (SELECT count(*) FROM service_request r WHERE r.staff_id = s.id AND r.service_date = $2 AND r.id <> $5), s.id`,
+		serviceType, date, toPgTime(start), toPgTime(end), excludeRequestID)
+	defer rows.Close()
+	var staffIDs []int
+	for rows.Next() {
+		var id int
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		staffIDs = append(staffIDs, id)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return staffIDs, nil
+}
+
+// GetStaffOnShift returns the staff members with the given skill that have a shift on the date
+func GetStaffOnShift(ctx context.Context, conn *pgx.Conn, serviceType string, date time.Time) ([]int, error) {
+	rows, _ := conn.Query(ctx, `SELECT DISTINCT sh.staff_id FROM staff_shift sh
+JOIN staff_skill k ON k.staff_id = sh.staff_id AND k.service_type = $1::text::hotel_services
+WHERE sh.shift_date = $2 ORDER BY sh.staff_id`, serviceType, date)
+	defer rows.Close()
+	var staffIDs []int
+	for rows.Next() {
+		var id int
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		staffIDs = append(staffIDs, id)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return staffIDs, nil
+}
@@ -0,0 +1,72 @@
+package dal
+
+import (
+	"context"
+	"example/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetBookingSegments returns the rooms of a booking in order, a single segment when the guest never moved
+func GetBookingSegments(ctx context.Context, conn *pgx.Conn, bookingID int) ([]models.BookingSegment, error) {
+	rows, _ := conn.Query(ctx, "SELECT booking_id, room_id, start_date, end_date FROM room_stay WHERE booking_id = $1 ORDER BY start_date", bookingID)
+	return collectBookingSegments(rows)
+}
+
+// GetOverlappingStays returns the stays of other active bookings in a room between the given dates, end date excluded
+func GetOverlappingStays(ctx context.Context, conn *pgx.Conn, roomID int, startDate, endDate time.Time, excludeBookingID int) ([]models.BookingSegment, error) {
+	rows, _ := conn.Query(ctx, `SELECT booking_id, room_id, start_date, end_date FROM room_stay
+WHERE room_id = $1 AND start_date < $3 AND end_date > $2 AND booking_id <> $4 AND status NOT IN ('cancelled', 'no_show')
+ORDER BY start_date`, roomID, startDate, endDate, excludeBookingID)
+	return collectBookingSegments(rows)
+}
+
+// UpdateBookingSegments saves the new rooms of a booking. The booking takes the dates of the whole stay
+// and the room of the last segment, the one the guest leaves from.
+func UpdateBookingSegments(ctx context.Context, conn *pgx.Conn, booking *models.Booking, segments []models.BookingSegment) error {
+	first, last := segments[0], segments[len(segments)-1]
+	booking.RoomID = last.RoomID
+	booking.StartDate = first.StartDate
+	booking.EndDate = last.EndDate
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "DELETE FROM booking_segment WHERE booking_id = $1", booking.ID)
+		if err != nil {
+			return err
+		}
+		// a booking in a single room needs no segments
+		if len(segments) > 1 {
+			for _, segment := range segments {
+				_, err = tx.Exec(ctx, "INSERT INTO booking_segment (booking_id, room_id, start_date, end_date) VALUES ($1, $2, $3, $4)", booking.ID, segment.RoomID, segment.StartDate, segment.EndDate)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		_, err = tx.Exec(ctx, "UPDATE booking SET room_id = $1, start_date = $2, end_date = $3 WHERE id = $4", booking.RoomID, booking.StartDate, booking.EndDate, booking.ID)
+		return err
+	})
+}
+
+// DeleteBookingSegments brings a booking back to the single room in booking.room_id
+func DeleteBookingSegments(ctx context.Context, conn *pgx.Conn, bookingID int) error {
+	_, err := conn.Exec(ctx, "DELETE FROM booking_segment WHERE booking_id = $1", bookingID)
+	return err
+}
+
+func collectBookingSegments(rows pgx.Rows) ([]models.BookingSegment, error) {
+	defer rows.Close()
+	var segments []models.BookingSegment
+	for rows.Next() {
+		var segment models.BookingSegment
+		err := rows.Scan(&segment.BookingID, &segment.RoomID, &segment.StartDate, &segment.EndDate)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return segments, nil
+}
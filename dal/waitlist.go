@@ -0,0 +1,115 @@
+package dal
+
+import (
+	"context"
+	"example/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const waitlistQuery = "SELECT id, customer_id, coalesce(room_type::text, ''), start_date, end_date, guests, status, room_id, hold_id, created_at, offered_at FROM waitlist_entry"
+
+// GetWaitlist returns the entries in order of arrival, only the ones with a status when it is not empty
+func GetWaitlist(ctx context.Context, conn *pgx.Conn, status string) ([]models.WaitlistEntry, error) {
+	rows, _ := conn.Query(ctx, waitlistQuery+" WHERE ($1 = '' OR status::text = $1) ORDER BY created_at, id", status)
+	return collectWaitlistEntries(rows)
+}
+
+// GetWaitingEntries returns the entries still waiting for a stay starting from the given date, first come first served
+func GetWaitingEntries(ctx context.Context, conn *pgx.Conn, from time.Time) ([]models.WaitlistEntry, error) {
+	rows, _ := conn.Query(ctx, waitlistQuery+" WHERE status = 'waiting' AND start_date >= $1 ORDER BY created_at, id", from)
+	return collectWaitlistEntries(rows)
+}
+
+func GetWaitlistEntryByID(ctx context.Context, conn *pgx.Conn, entryID int) (*models.WaitlistEntry, error) {
+	row := conn.QueryRow(ctx, waitlistQuery+" WHERE id = $1", entryID)
+	var e models.WaitlistEntry
+	err := row.Scan(&e.ID, &e.CustomerID, &e.RoomType, &e.StartDate, &e.EndDate, &e.Guests, &e.Status, &e.RoomID, &e.HoldID, &e.CreatedAt, &e.OfferedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func CreateWaitlistEntry(ctx context.Context, conn *pgx.Conn, entry *models.WaitlistEntry) error {
+	row := conn.QueryRow(ctx, "INSERT INTO waitlist_entry (customer_id, room_type, start_date, end_date, guests) VALUES ($1, NULLIF($2, '')::room_types, $3, $4, $5) RETURNING id, status, created_at",
+		entry.CustomerID, entry.RoomType, entry.StartDate, entry.EndDate, entry.Guests)
+	err := row.Scan(&entry.ID, &entry.Status, &entry.CreatedAt)
+	return err
+}
+
+// OfferWaitlistRoom holds a room for a waiting entry and records the offer, either both or none. The offers are made
+// one at a time, and it returns pgx.ErrNoRows when the entry is no longer waiting or the room was held meanwhile, e.g.
+// by the matching of the other connection.
+func OfferWaitlistRoom(ctx context.Context, conn *pgx.Conn, entry *models.WaitlistEntry, hold *models.Hold) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext('waitlist'))")
+		if err != nil {
+			return err
+		}
+		var held bool
+		err = tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM booking_hold WHERE room_id = $1 AND expires_at > $2 AND start_date < $4 AND end_date > $3)",
+			hold.RoomID, *entry.OfferedAt, hold.StartDate, hold.EndDate).Scan(&held)
+		if err != nil {
+			return err
+		}
+		if held {
+			return pgx.ErrNoRows
+		}
+		err = tx.QueryRow(ctx, "INSERT INTO booking_hold (room_id, customer_id, start_date, end_date, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+			hold.RoomID, hold.CustomerID, hold.StartDate, hold.EndDate, hold.ExpiresAt).Scan(&hold.ID)
+		if err != nil {
+			return err
+		}
+		entry.HoldID = &hold.ID
+		tag, err := tx.Exec(ctx, "UPDATE waitlist_entry SET status = $1, room_id = $2, hold_id = $3, offered_at = $4 WHERE id = $5 AND status = 'waiting'",
+			entry.Status, entry.RoomID, entry.HoldID, entry.OfferedAt, entry.ID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return pgx.ErrNoRows
+		}
+		return nil
+	})
+}
+
+// ExpireWaitlistOffers marks as expired the offers whose hold expired before the given time, or whose hold is holdID
+// when it is not 0, and returns how many
+func ExpireWaitlistOffers(ctx context.Context, conn *pgx.Conn, before time.Time, holdID int) (int64, error) {
+	tag, err := conn.Exec(ctx, `UPDATE waitlist_entry SET status = 'expired' WHERE status = 'offered'
+AND hold_id IN (SELECT id FROM booking_hold WHERE expires_at <= $1 OR id = $2)`, before, holdID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func DeleteWaitlistEntry(ctx context.Context, conn *pgx.Conn, entryID int) error {
+	tag, err := conn.Exec(ctx, "DELETE FROM waitlist_entry WHERE id = $1", entryID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func collectWaitlistEntries(rows pgx.Rows) ([]models.WaitlistEntry, error) {
+	defer rows.Close()
+	var entries []models.WaitlistEntry
+	for rows.Next() {
+		var e models.WaitlistEntry
+		err := rows.Scan(&e.ID, &e.CustomerID, &e.RoomType, &e.StartDate, &e.EndDate, &e.Guests, &e.Status, &e.RoomID, &e.HoldID, &e.CreatedAt, &e.OfferedAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return entries, nil
+}
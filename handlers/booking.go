@@ -1,11 +1,11 @@
 package handlers
 
 import (
-	"encoding/json"
 	"errors"
+	"example/logging"
 	"example/models"
 	"example/services"
-	"log"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -18,7 +18,7 @@ func GetAllBookings(dbConnection *pgx.Conn) http.HandlerFunc {
 		bookings, err := services.GetAllBookings(r.Context(), dbConnection)
 		if err != nil {
 			http.Error(w, "Unable to get all bookings", http.StatusServiceUnavailable)
-			log.Println("Error getting bookings:", err.Error())
+			logging.FromContext(r.Context()).Error("Error getting bookings", "error", err)
 			return
 		}
 
@@ -45,7 +45,29 @@ func GetBookingByID(dbConnection *pgx.Conn) http.HandlerFunc {
 				return
 			}
 			http.Error(w, "Unable to get booking", http.StatusServiceUnavailable)
-			log.Println("Error getting booking:", err.Error())
+			logging.FromContext(r.Context()).Error("Error getting booking", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, booking.ToDTO())
+	}
+}
+
+// GetBookingByCode finds a booking by the confirmation code given to the guest
+func GetBookingByCode(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		booking, err := services.GetBookingByCode(r.Context(), dbConnection, r.PathValue("code"))
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Booking not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to get booking", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting booking by code", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusOK)
@@ -56,9 +78,9 @@ func GetBookingByID(dbConnection *pgx.Conn) http.HandlerFunc {
 func CreateBooking(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var bookingDTO models.BookingDTO
-		err := json.NewDecoder(r.Body).Decode(&bookingDTO)
+		err := decodeJSON(w, r, &bookingDTO)
 		if err != nil {
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			invalidJSON(w, err)
 			return
 		}
 		err = validator.Struct(bookingDTO)
@@ -79,7 +101,7 @@ func CreateBooking(dbConnection *pgx.Conn, validator *validator.Validate) http.H
 				return
 			}
 			http.Error(w, "Unable to create booking", http.StatusServiceUnavailable)
-			log.Println("Error creating booking:", err.Error())
+			logging.FromContext(r.Context()).Error("Error creating booking", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusCreated)
@@ -95,9 +117,9 @@ func UpdateBookingByID(dbConnection *pgx.Conn, validator *validator.Validate) ht
 			return
 		}
 		var bookingDTO models.BookingDTO
-		err = json.NewDecoder(r.Body).Decode(&bookingDTO)
+		err = decodeJSON(w, r, &bookingDTO)
 		if err != nil {
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			invalidJSON(w, err)
 			return
 		}
 		bookingDTO.ID = bookingID
@@ -122,7 +144,7 @@ func UpdateBookingByID(dbConnection *pgx.Conn, validator *validator.Validate) ht
 				return
 			}
 			http.Error(w, "Unable to update booking", http.StatusServiceUnavailable)
-			log.Println("Error updating booking:", err.Error())
+			logging.FromContext(r.Context()).Error("Error updating booking", "error", err)
 			return
 		}
 		w.WriteHeader(status)
@@ -138,9 +160,9 @@ func PatchBookingByID(dbConnection *pgx.Conn, validator *validator.Validate) htt
 			return
 		}
 		var patch models.BookingPatch
-		err = json.NewDecoder(r.Body).Decode(&patch)
+		err = decodeJSON(w, r, &patch)
 		if err != nil {
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			invalidJSON(w, err)
 			return
 		}
 		err = validator.Struct(patch)
@@ -159,7 +181,7 @@ func PatchBookingByID(dbConnection *pgx.Conn, validator *validator.Validate) htt
 				return
 			}
 			http.Error(w, "Unable to patch booking", http.StatusServiceUnavailable)
-			log.Println("Error patching booking:", err.Error())
+			logging.FromContext(r.Context()).Error("Error patching booking", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
@@ -180,9 +202,147 @@ func DeleteBookingByID(dbConnection *pgx.Conn) http.HandlerFunc {
 				return
 			}
 			http.Error(w, "Unable to delete booking", http.StatusServiceUnavailable)
-			log.Println("Error deleting booking:", err.Error())
+			logging.FromContext(r.Context()).Error("Error deleting booking", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+// CheckInBooking checks in a booking, the body can register the occupants at the desk
+func CheckInBooking(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookingID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid booking ID", http.StatusBadRequest)
+			return
+		}
+		var occupantsDTO models.OccupantsDTO
+		err = decodeJSON(w, r, &occupantsDTO)
+		if err != nil && !errors.Is(err, io.EOF) {
+			invalidJSON(w, err)
+			return
+		}
+		err = validator.Struct(occupantsDTO)
+		if err != nil {
+			http.Error(w, models.OccupantsValidationError, http.StatusBadRequest)
+			return
+		}
+		occupants, err := models.OccupantsToModel(occupantsDTO.Occupants)
+		if err != nil {
+			http.Error(w, "Invalid date format, use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		booking, err := services.CheckInBooking(r.Context(), dbConnection, bookingID, occupants)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Booking not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to check in booking", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error checking in booking", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, booking.ToDTO())
+	}
+}
+
+// UpdateBookingOccupants registers the guests of a booking before the arrival
+func UpdateBookingOccupants(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookingID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid booking ID", http.StatusBadRequest)
+			return
+		}
+		var occupantsDTO models.OccupantsDTO
+		err = decodeJSON(w, r, &occupantsDTO)
+		if err != nil {
+			invalidJSON(w, err)
+			return
+		}
+		err = validator.Struct(occupantsDTO)
+		if err != nil {
+			http.Error(w, models.OccupantsValidationError, http.StatusBadRequest)
+			return
+		}
+		occupants, err := models.OccupantsToModel(occupantsDTO.Occupants)
+		if err != nil {
+			http.Error(w, "Invalid date format, use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		booking, err := services.UpdateBookingOccupants(r.Context(), dbConnection, bookingID, occupants)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Booking not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to update occupants", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error updating occupants", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, booking.ToDTO())
+	}
+}
+
+func CheckOutBooking(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookingID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid booking ID", http.StatusBadRequest)
+			return
+		}
+		booking, err := services.CheckOutBooking(r.Context(), dbConnection, bookingID)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Booking not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to check out booking", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error checking out booking", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, booking.ToDTO())
+	}
+}
+
+func CancelBookingByID(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookingID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid booking ID", http.StatusBadRequest)
+			return
+		}
+		booking, err := services.CancelBookingByID(r.Context(), dbConnection, bookingID)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Booking not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to cancel booking", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error cancelling booking", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, booking.ToDTO())
+	}
+}
@@ -3,9 +3,10 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"example/logging"
 	"example/models"
 	"example/services"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 
@@ -18,7 +19,7 @@ func GetAllCustomers(dbConnection *pgx.Conn) http.HandlerFunc {
 		customers, err := services.GetAllCustomers(r.Context(), dbConnection)
 		if err != nil {
 			http.Error(w, "Unable to get all customers", http.StatusServiceUnavailable)
-			log.Println("Error getting customers:", err.Error())
+			logging.FromContext(r.Context()).Error("Error getting customers", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusOK)
@@ -40,7 +41,7 @@ func GetCustomerByID(dbConnection *pgx.Conn) http.HandlerFunc {
 				return
 			}
 			http.Error(w, "Unable to get customer", http.StatusServiceUnavailable)
-			log.Println("Error getting customer:", err.Error())
+			logging.FromContext(r.Context()).Error("Error getting customer", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusOK)
@@ -51,9 +52,9 @@ func GetCustomerByID(dbConnection *pgx.Conn) http.HandlerFunc {
 func CreateCustomer(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var newCustomer models.Customer
-		err := json.NewDecoder(r.Body).Decode(&newCustomer)
+		err := decodeJSON(w, r, &newCustomer)
 		if err != nil {
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			invalidJSON(w, err)
 			return
 		}
 		err = validator.Struct(newCustomer)
@@ -64,7 +65,7 @@ func CreateCustomer(dbConnection *pgx.Conn, validator *validator.Validate) http.
 		err = services.CreateCustomer(r.Context(), dbConnection, &newCustomer)
 		if err != nil {
 			http.Error(w, "Unable to create customer", http.StatusServiceUnavailable)
-			log.Println("Error creating customer:", err.Error())
+			logging.FromContext(r.Context()).Error("Error creating customer", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusCreated)
@@ -80,9 +81,9 @@ func UpdateCustomerByID(dbConnection *pgx.Conn, validator *validator.Validate) h
 			return
 		}
 		var updatedCustomer models.Customer
-		err = json.NewDecoder(r.Body).Decode(&updatedCustomer)
+		err = decodeJSON(w, r, &updatedCustomer)
 		if err != nil {
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			invalidJSON(w, err)
 			return
 		}
 		updatedCustomer.ID = customerID
@@ -98,7 +99,7 @@ func UpdateCustomerByID(dbConnection *pgx.Conn, validator *validator.Validate) h
 				return
 			}
 			http.Error(w, "Unable to update customer", http.StatusServiceUnavailable)
-			log.Println("Error updating customer:", err.Error())
+			logging.FromContext(r.Context()).Error("Error updating customer", "error", err)
 			return
 		}
 		w.WriteHeader(status)
@@ -114,9 +115,9 @@ func PatchCustomerByID(dbConnection *pgx.Conn, validator *validator.Validate) ht
 			return
 		}
 		var patch models.CustomerPatch
-		err = json.NewDecoder(r.Body).Decode(&patch)
+		err = decodeJSON(w, r, &patch)
 		if err != nil {
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			invalidJSON(w, err)
 			return
 		}
 		err = validator.Struct(patch)
@@ -131,7 +132,7 @@ func PatchCustomerByID(dbConnection *pgx.Conn, validator *validator.Validate) ht
 				return
 			}
 			http.Error(w, "Unable to patch customer", http.StatusServiceUnavailable)
-			log.Println("Error patching customer:", err.Error())
+			logging.FromContext(r.Context()).Error("Error patching customer", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
@@ -152,23 +153,49 @@ func DeleteCustomerByID(dbConnection *pgx.Conn) http.HandlerFunc {
 				return
 			}
 			http.Error(w, "Unable to delete customer", http.StatusServiceUnavailable)
-			log.Println("Error deleting customer:", err.Error())
+			logging.FromContext(r.Context()).Error("Error deleting customer", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
 
+// maxBodyBytes is the largest JSON body accepted, bigger ones are answered with 413
+var maxBodyBytes int64 = 1 << 20
+
+func SetMaxBodyBytes(n int64) {
+	maxBodyBytes = n
+}
+
+// decodeJSON decodes the body of the request, reading at most maxBodyBytes of it
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	return json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes)).Decode(v)
+}
+
+// invalidJSON answers a request whose body could not be decoded
+func invalidJSON(w http.ResponseWriter, err error) {
+	if bodyTooLarge(err) {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+}
+
+func bodyTooLarge(err error) bool {
+	var tooLarge *http.MaxBytesError
+	return errors.As(err, &tooLarge)
+}
+
 func returnJSON(w http.ResponseWriter, payload any) {
 	w.Header().Set("Content-Type", "application/json")
 	bytes, err := json.Marshal(payload)
 	if err != nil {
 		http.Error(w, "Unable to marshal payload", http.StatusInternalServerError)
-		log.Println("Error marshaling payload:", err.Error())
+		slog.Error("Error marshaling payload", "error", err)
 		return
 	}
 	_, err = w.Write(bytes)
 	if err != nil {
-		log.Println("Error writing response:", err.Error())
+		slog.Error("Error writing response", "error", err)
 	}
 }
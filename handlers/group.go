@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"errors"
+	"example/logging"
+	"example/models"
+	"example/services"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetAllGroups lists the groups without their rooms
+func GetAllGroups(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groups, err := services.GetAllGroups(r.Context(), dbConnection)
+		if err != nil {
+			http.Error(w, "Unable to get all groups", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting groups", "error", err)
+			return
+		}
+		groupDTOs := []models.GroupDTO{}
+		for _, group := range groups {
+			groupDTOs = append(groupDTOs, group.ToDTO())
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, groupDTOs)
+	}
+}
+
+func GetGroupByID(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid group ID", http.StatusBadRequest)
+			return
+		}
+		group, err := services.GetGroupByID(r.Context(), dbConnection, groupID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Group not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to get group", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting group", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, group.ToDTO())
+	}
+}
+
+func CreateGroup(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var groupDTO models.GroupDTO
+		err := decodeJSON(w, r, &groupDTO)
+		if err != nil {
+			invalidJSON(w, err)
+			return
+		}
+		err = validator.Struct(groupDTO)
+		if err != nil {
+			http.Error(w, models.GroupValidationError, http.StatusBadRequest)
+			return
+		}
+		group, err := groupDTO.ToModel()
+		if err != nil {
+			http.Error(w, "Invalid date format, use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		err = services.CreateGroup(r.Context(), dbConnection, &group)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "Unable to create group", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error creating group", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		returnJSON(w, group.ToDTO())
+	}
+}
+
+func CancelGroup(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid group ID", http.StatusBadRequest)
+			return
+		}
+		group, err := services.CancelGroup(r.Context(), dbConnection, groupID)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Group not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to cancel group", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error cancelling group", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, group.ToDTO())
+	}
+}
+
+// SetGroupRoomGuest names the guest of a room of the group, turning an allotment into a booking
+func SetGroupRoomGuest(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid group ID", http.StatusBadRequest)
+			return
+		}
+		bookingID, err := strconv.Atoi(r.PathValue("bookingID"))
+		if err != nil {
+			http.Error(w, "Invalid booking ID", http.StatusBadRequest)
+			return
+		}
+		var guestDTO models.GroupGuestDTO
+		err = decodeJSON(w, r, &guestDTO)
+		if err != nil {
+			invalidJSON(w, err)
+			return
+		}
+		err = validator.Struct(guestDTO)
+		if err != nil {
+			http.Error(w, models.GroupGuestValidationError, http.StatusBadRequest)
+			return
+		}
+		group, err := services.SetGroupRoomGuest(r.Context(), dbConnection, groupID, bookingID, guestDTO.CustomerID)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Group room not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to set group room guest", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error setting group room guest", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, group.ToDTO())
+	}
+}
+
+// ReleaseAllotments frees the rooms without a guest of the groups whose cut-off date is today (or ?date=)
+// or earlier, and returns the released bookings
+func ReleaseAllotments(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		date, err := queryDate(r, "date", services.Today())
+		if err != nil {
+			http.Error(w, "Invalid date format, use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		released, err := services.ReleaseAllotments(r.Context(), dbConnection, date)
+		if err != nil {
+			http.Error(w, "Unable to release allotments", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error releasing allotments", "error", err)
+			return
+		}
+		bookingDTOs := []models.BookingDTO{}
+		for _, booking := range released {
+			bookingDTOs = append(bookingDTOs, booking.ToDTO())
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, bookingDTOs)
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"example/logging"
+	"example/models"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Healthz tells that the process is alive, it does not depend on the database so that a database outage does not
+// restart the server
+func Healthz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, models.HealthDTO{Status: models.HealthOK})
+	}
+}
+
+// Monitor is the database connection of the probes and of the metrics, apart from the one of the requests so that a
+// probe or a scrape never collides with a request. A pgx connection cannot be shared between goroutines, the probes
+// and the scrapes take turns on it.
+type Monitor struct {
+	conn *pgx.Conn
+	turn chan struct{}
+}
+
+func NewMonitor(conn *pgx.Conn) *Monitor {
+	return &Monitor{conn: conn, turn: make(chan struct{}, 1)}
+}
+
+// use calls f with the connection once the probes and scrapes before are over, or fails when ctx is done first
+func (m *Monitor) use(ctx context.Context, f func(conn *pgx.Conn) error) error {
+	select {
+	case m.turn <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-m.turn }()
+	return f(m.conn)
+}
+
+// Readyz tells whether the server can take requests: the database answers and the server is not shutting down
+func Readyz(monitor *Monitor, shuttingDown *atomic.Bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if shuttingDown.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			returnJSON(w, models.HealthDTO{Status: models.HealthShuttingDown})
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+		err := monitor.use(ctx, func(conn *pgx.Conn) error {
+			return conn.Ping(ctx)
+		})
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Error pinging database", "error", err)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			returnJSON(w, models.HealthDTO{Status: models.HealthUnavailable, Database: models.HealthUnavailable})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, models.HealthDTO{Status: models.HealthOK, Database: models.HealthOK})
+	}
+}
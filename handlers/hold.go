@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"example/logging"
+	"example/models"
+	"example/services"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetActiveHolds lists the holds that did not expire yet
+func GetActiveHolds(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		holds, err := services.GetActiveHolds(r.Context(), dbConnection)
+		if err != nil {
+			http.Error(w, "Unable to get holds", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting holds", "error", err)
+			return
+		}
+		holdDTOs := []models.HoldDTO{}
+		for _, hold := range holds {
+			holdDTOs = append(holdDTOs, hold.ToDTO())
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, holdDTOs)
+	}
+}
+
+func GetHoldByID(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		holdID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid hold ID", http.StatusBadRequest)
+			return
+		}
+		hold, err := services.GetHoldByID(r.Context(), dbConnection, holdID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Hold not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to get hold", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting hold", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, hold.ToDTO())
+	}
+}
+
+// CreateHold reserves a room for a few minutes, the hold ID can then be used to create the booking
+func CreateHold(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var holdDTO models.HoldDTO
+		err := decodeJSON(w, r, &holdDTO)
+		if err != nil {
+			invalidJSON(w, err)
+			return
+		}
+		err = validator.Struct(holdDTO)
+		if err != nil {
+			http.Error(w, models.HoldValidationError, http.StatusBadRequest)
+			return
+		}
+		hold, ttl, err := holdDTO.ToModel()
+		if err != nil {
+			http.Error(w, "Invalid date format, use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		err = services.CreateHold(r.Context(), dbConnection, &hold, ttl)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "Unable to create hold", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error creating hold", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		returnJSON(w, hold.ToDTO())
+	}
+}
+
+func DeleteHold(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		holdID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid hold ID", http.StatusBadRequest)
+			return
+		}
+		err = services.DeleteHold(r.Context(), dbConnection, holdID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Hold not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to delete hold", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error deleting hold", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -1,11 +1,10 @@
 package handlers
 
 import (
-	"encoding/json"
 	"errors"
+	"example/logging"
 	"example/models"
 	"example/services"
-	"log"
 	"net/http"
 	"strconv"
 
@@ -18,7 +17,7 @@ func GetAllHotelServices(dbConnection *pgx.Conn) http.HandlerFunc {
 		hotelServices, err := services.GetAllHotelServices(r.Context(), dbConnection)
 		if err != nil {
 			http.Error(w, "Unable to get all hotel services", http.StatusServiceUnavailable)
-			log.Println("Error getting hotel services:", err.Error())
+			logging.FromContext(r.Context()).Error("Error getting hotel services", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusOK)
@@ -40,7 +39,7 @@ func GetHotelServiceByID(dbConnection *pgx.Conn) http.HandlerFunc {
 				return
 			}
 			http.Error(w, "Unable to get hotel service", http.StatusServiceUnavailable)
-			log.Println("Error getting hotel service:", err.Error())
+			logging.FromContext(r.Context()).Error("Error getting hotel service", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusOK)
@@ -51,9 +50,9 @@ func GetHotelServiceByID(dbConnection *pgx.Conn) http.HandlerFunc {
 func CreateHotelService(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var service models.HotelService
-		err := json.NewDecoder(r.Body).Decode(&service)
+		err := decodeJSON(w, r, &service)
 		if err != nil {
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			invalidJSON(w, err)
 			return
 		}
 		err = validator.Struct(service)
@@ -69,7 +68,7 @@ func CreateHotelService(dbConnection *pgx.Conn, validator *validator.Validate) h
 				return
 			}
 			http.Error(w, "Unable to create hotel service", http.StatusServiceUnavailable)
-			log.Println("Error creating hotel service:", err.Error())
+			logging.FromContext(r.Context()).Error("Error creating hotel service", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusCreated)
@@ -85,9 +84,9 @@ func UpdateHotelServiceByID(dbConnection *pgx.Conn, validator *validator.Validat
 			return
 		}
 		var service models.HotelService
-		err = json.NewDecoder(r.Body).Decode(&service)
+		err = decodeJSON(w, r, &service)
 		if err != nil {
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			invalidJSON(w, err)
 			return
 		}
 		service.ID = serviceID
@@ -107,7 +106,7 @@ func UpdateHotelServiceByID(dbConnection *pgx.Conn, validator *validator.Validat
 				return
 			}
 			http.Error(w, "Unable to update hotel service", http.StatusServiceUnavailable)
-			log.Println("Error updating hotel service:", err.Error())
+			logging.FromContext(r.Context()).Error("Error updating hotel service", "error", err)
 			return
 		}
 		w.WriteHeader(status)
@@ -123,9 +122,9 @@ func PatchHotelServiceByID(dbConnection *pgx.Conn, validator *validator.Validate
 			return
 		}
 		var patch models.HotelServicePatch
-		err = json.NewDecoder(r.Body).Decode(&patch)
+		err = decodeJSON(w, r, &patch)
 		if err != nil {
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			invalidJSON(w, err)
 			return
 		}
 		err = validator.Struct(patch)
@@ -144,7 +143,7 @@ func PatchHotelServiceByID(dbConnection *pgx.Conn, validator *validator.Validate
 				return
 			}
 			http.Error(w, "Unable to patch hotel service", http.StatusServiceUnavailable)
-			log.Println("Error patching hotel service:", err.Error())
+			logging.FromContext(r.Context()).Error("Error patching hotel service", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
@@ -165,9 +164,42 @@ func DeleteHotelServiceByID(dbConnection *pgx.Conn) http.HandlerFunc {
 				return
 			}
 			http.Error(w, "Unable to delete hotel service", http.StatusServiceUnavailable)
-			log.Println("Error deleting hotel service:", err.Error())
+			logging.FromContext(r.Context()).Error("Error deleting hotel service", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+func GetServiceSlots(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serviceID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid hotel service ID", http.StatusBadRequest)
+			return
+		}
+		date := r.URL.Query().Get("date")
+		err = validator.Var(date, "required,datetime=2006-01-02")
+		if err != nil {
+			http.Error(w, "Query parameter 'date' is required and must be in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+		day, err := models.ParseDate(date)
+		if err != nil {
+			http.Error(w, "Invalid date format, use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		slots, err := services.GetServiceSlots(r.Context(), dbConnection, serviceID, day)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Hotel service not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to get service slots", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting service slots", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, slots)
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"example/logging"
+	"example/models"
+	"example/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetHousekeepingBoard returns the status of every room on a date, today by default
+func GetHousekeepingBoard(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		date, err := queryDate(r, "date", services.Today())
+		if err != nil {
+			http.Error(w, "Query parameter 'date' must be in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+		board, err := services.GetHousekeepingBoard(r.Context(), dbConnection, date)
+		if err != nil {
+			http.Error(w, "Unable to get housekeeping board", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting housekeeping board", "error", err)
+			return
+		}
+		if board == nil {
+			board = []models.HousekeepingBoardRow{}
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, board)
+	}
+}
+
+func UpdateRoomHousekeepingStatus(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid room ID", http.StatusBadRequest)
+			return
+		}
+		var statusDTO models.HousekeepingStatusDTO
+		err = decodeJSON(w, r, &statusDTO)
+		if err != nil {
+			invalidJSON(w, err)
+			return
+		}
+		err = validator.Struct(statusDTO)
+		if err != nil {
+			http.Error(w, models.HousekeepingStatusValidationError, http.StatusBadRequest)
+			return
+		}
+		err = services.UpdateRoomHousekeepingStatus(r.Context(), dbConnection, roomID, statusDTO.Status)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Room not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to update housekeeping status", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error updating housekeeping status", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, statusDTO)
+	}
+}
+
+// GetHousekeepingTasks returns the tasks of a date (today by default), optionally only the ones of ?staff_id=
+func GetHousekeepingTasks(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		date, err := queryDate(r, "date", services.Today())
+		if err != nil {
+			http.Error(w, "Query parameter 'date' must be in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+		var staffID *int
+		if r.URL.Query().Has("staff_id") {
+			id, err := strconv.Atoi(r.URL.Query().Get("staff_id"))
+			if err != nil {
+				http.Error(w, "Invalid staff ID", http.StatusBadRequest)
+				return
+			}
+			staffID = &id
+		}
+		tasks, err := services.GetHousekeepingTasks(r.Context(), dbConnection, date, staffID)
+		if err != nil {
+			http.Error(w, "Unable to get housekeeping tasks", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting housekeeping tasks", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, housekeepingTaskDTOs(tasks))
+	}
+}
+
+// GenerateHousekeepingTasks creates the task list of a date, today by default
+func GenerateHousekeepingTasks(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		date, err := queryDate(r, "date", services.Today())
+		if err != nil {
+			http.Error(w, "Query parameter 'date' must be in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+		tasks, err := services.GenerateHousekeepingTasks(r.Context(), dbConnection, date)
+		if err != nil {
+			http.Error(w, "Unable to generate housekeeping tasks", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error generating housekeeping tasks", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, housekeepingTaskDTOs(tasks))
+	}
+}
+
+func CompleteHousekeepingTask(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		taskID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid task ID", http.StatusBadRequest)
+			return
+		}
+		task, err := services.CompleteHousekeepingTask(r.Context(), dbConnection, taskID)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Task not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to complete task", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error completing task", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, task.ToDTO())
+	}
+}
+
+func housekeepingTaskDTOs(tasks []models.HousekeepingTask) []models.HousekeepingTaskDTO {
+	taskDTOs := []models.HousekeepingTaskDTO{}
+	for _, task := range tasks {
+		taskDTOs = append(taskDTOs, task.ToDTO())
+	}
+	return taskDTOs
+}
+
+// queryDate parses a YYYY-MM-DD query parameter, returning def when it is missing
+func queryDate(r *http.Request, name string, def time.Time) (time.Time, error) {
+	if !r.URL.Query().Has(name) {
+		return def, nil
+	}
+	return models.ParseDate(r.URL.Query().Get(name))
+}
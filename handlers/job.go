@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"example/logging"
+	"example/models"
+	"example/services"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func GetJobs(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jobs, err := services.GetJobs(r.Context(), dbConnection)
+		if err != nil {
+			http.Error(w, "Unable to get jobs", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting jobs", "error", err)
+			return
+		}
+		jobDTOs := []models.JobDTO{}
+		for _, job := range jobs {
+			jobDTOs = append(jobDTOs, job.ToDTO())
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, jobDTOs)
+	}
+}
+
+// GetJobRuns returns the history of a job, the last runs first
+func GetJobRuns(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		runs, err := services.GetJobRuns(r.Context(), dbConnection, r.PathValue("name"))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Job not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to get job runs", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting job runs", "error", err)
+			return
+		}
+		runDTOs := []models.JobRunDTO{}
+		for _, run := range runs {
+			runDTOs = append(runDTOs, run.ToDTO())
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, runDTOs)
+	}
+}
+
+// TriggerJob runs a job now on the connection of the scheduler, the response is sent when the run is over
+func TriggerJob() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		run, err := services.TriggerJob(r.Context(), r.PathValue("name"))
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Job not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to run job", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error running job", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, run.ToDTO())
+	}
+}
+
+// PauseJob stops the scheduled runs of a job when paused is true, and restarts them otherwise
+func PauseJob(dbConnection *pgx.Conn, paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, err := services.PauseJob(r.Context(), dbConnection, r.PathValue("name"), paused)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Job not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to update job", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error updating job", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, job.ToDTO())
+	}
+}
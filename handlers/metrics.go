@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"context"
+	"example/metrics"
+	"example/models"
+	"example/services"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetMetrics serves the Prometheus metrics, the figures of the hotel are read from the database at every scrape
+func GetMetrics(monitor *Monitor) http.HandlerFunc {
+	handler := metrics.Handler(func(ctx context.Context) (stats *models.HotelStats, err error) {
+		err = monitor.use(ctx, func(conn *pgx.Conn) error {
+			stats, err = services.GetHotelStats(ctx, conn)
+			return err
+		})
+		return stats, err
+	})
+	return handler.ServeHTTP
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"example/logging"
+	"example/metrics"
+	"example/ratelimit"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	// actorHeader names who makes the request, e.g. the staff member using hotelctl
+	actorHeader = "X-Actor"
+	// apiKeyHeader identifies the clients with a key of their own, e.g. an integration behind a shared address
+	apiKeyHeader = "X-API-Key"
+)
+
+// the classes of routes of the rate limits
+const (
+	RoutePublic = "public"
+	RouteWrite  = "write"
+	RouteRead   = "read"
+)
+
+// publicRoutes are open to the guests, e.g. from the website of the hotel, they get the strictest limit
+var publicRoutes = map[string]bool{
+	"GET /rooms/available":      true,
+	"GET /services/{id}/slots":  true,
+	"GET /booking-codes/{code}": true,
+	"POST /customers":           true,
+	"POST /bookings":            true,
+	"POST /holds":               true,
+	"POST /waitlist":            true,
+	"POST /reviews":             true,
+}
+
+// the probes and the scrapes are never limited
+var unlimitedRoutes = map[string]bool{
+	"GET /healthz": true,
+	"GET /readyz":  true,
+	"GET /metrics": true,
+}
+
+// statusRecorder remembers the status and the size of a response for the request log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Logging gives every request an ID, taken from the X-Request-ID header of the client when valid, puts a logger
+// with the ID in the context of the request and logs the request once it is served
+func Logging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		logger := slog.Default().With("request_id", id)
+		if span := trace.SpanContextFromContext(r.Context()); span.IsValid() {
+			logger = logger.With("trace_id", span.TraceID().String())
+		}
+		recorder := &statusRecorder{ResponseWriter: w}
+		serveWithContext(next, recorder, r, logging.WithLogger(r.Context(), logger))
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		if recorder.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		// the mux sets the pattern of the route on the request
+		logger.LogAttrs(r.Context(), level, "Request",
+			slog.String("method", r.Method),
+			slog.String("route", r.Pattern),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", recorder.status),
+			slog.Int("bytes", recorder.bytes),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("actor", actor(r)),
+		)
+	})
+}
+
+// Metrics counts the requests and measures their latency by route pattern
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		metrics.ObserveRequest(r.Method, r.Pattern, recorder.status, time.Since(start))
+	})
+}
+
+// Tracing starts the span of every request, as a child of the trace of the client when it sends a traceparent
+// header, and names it after the route pattern once the request is served
+func Tracing(next http.Handler) http.Handler {
+	tracer := otel.Tracer("example/handlers")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+		recorder := &statusRecorder{ResponseWriter: w}
+		serveWithContext(next, recorder, r, ctx)
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		if r.Pattern != "" {
+			span.SetName(r.Pattern)
+			span.SetAttributes(semconv.HTTPRoute(r.Pattern))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	})
+}
+
+// RateLimit limits the requests of every client with the limiter of the class of the route, a client is identified
+// by its API key when it is one of apiKeys, or by its address. The route of a request is looked up in mux before
+// serving it. A limited request is answered with 429 and a Retry-After header.
+func RateLimit(limiters map[string]*ratelimit.Limiter, apiKeys []string, mux *http.ServeMux) http.Handler {
+	keys := make(map[string]bool, len(apiKeys))
+	for _, key := range apiKeys {
+		keys[key] = true
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		class := routeClass(r.Method, pattern)
+		if class == "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		client := "ip:" + clientIP(r)
+		if key := r.Header.Get(apiKeyHeader); keys[key] {
+			client = "key:" + key
+		}
+		allowed, wait := limiters[class].Allow(client)
+		if !allowed {
+			// the request is not served by the mux, the pattern is set for the log and the metrics
+			r.Pattern = pattern
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// routeClass returns the class of the rate limit of a route, empty for the routes that are not limited
+func routeClass(method string, pattern string) string {
+	switch {
+	case unlimitedRoutes[pattern]:
+		return ""
+	case publicRoutes[pattern]:
+		return RoutePublic
+	case method == http.MethodGet || method == http.MethodHead:
+		return RouteRead
+	default:
+		return RouteWrite
+	}
+}
+
+// serveWithContext serves the request with a new context, the pattern of the route set by the mux on the new request
+// is copied back, so that the middleware can still read it
+func serveWithContext(next http.Handler, w http.ResponseWriter, r *http.Request, ctx context.Context) {
+	withContext := r.WithContext(ctx)
+	next.ServeHTTP(w, withContext)
+	r.Pattern = withContext.Pattern
+}
+
+// actor returns who makes the request: the X-Actor header, or the address of the client
+func actor(r *http.Request) string {
+	if a := r.Header.Get(actorHeader); a != "" {
+		return a
+	}
+	return clientIP(r)
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// validRequestID accepts the IDs of the clients that are short and printable, so that they are safe to log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"example/logging"
+	"example/models"
+	"example/services"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func GetBusinessDate(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		date, err := services.BusinessDate(r.Context(), dbConnection)
+		if err != nil {
+			http.Error(w, "Unable to get business date", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting business date", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, models.BusinessDateDTO{BusinessDate: date.Format(models.DateFormat)})
+	}
+}
+
+// GetNightAudits returns the daily summaries of the closed business days, the last one first
+func GetNightAudits(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		audits, err := services.GetNightAudits(r.Context(), dbConnection)
+		if err != nil {
+			http.Error(w, "Unable to get night audits", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting night audits", "error", err)
+			return
+		}
+		auditDTOs := []models.NightAuditDTO{}
+		for _, audit := range audits {
+			auditDTOs = append(auditDTOs, audit.ToDTO())
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, auditDTOs)
+	}
+}
+
+func GetNightAudit(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		date, err := models.ParseDate(r.PathValue("date"))
+		if err != nil {
+			http.Error(w, "Invalid date format, use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		audit, err := services.GetNightAudit(r.Context(), dbConnection, date)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Night audit not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to get night audit", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting night audit", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, audit.ToDTO())
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"example/logging"
+	"example/models"
+	"example/services"
+	"net/http"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetNotifications returns the send log, optionally only for a ?booking_id= or a ?customer_id=
+func GetNotifications(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var bookingID, customerID int
+		var err error
+		if r.URL.Query().Has("booking_id") {
+			bookingID, err = strconv.Atoi(r.URL.Query().Get("booking_id"))
+			if err != nil {
+				http.Error(w, "Invalid booking ID", http.StatusBadRequest)
+				return
+			}
+		}
+		if r.URL.Query().Has("customer_id") {
+			customerID, err = strconv.Atoi(r.URL.Query().Get("customer_id"))
+			if err != nil {
+				http.Error(w, "Invalid customer ID", http.StatusBadRequest)
+				return
+			}
+		}
+		notifications, err := services.GetNotifications(r.Context(), dbConnection, bookingID, customerID)
+		if err != nil {
+			http.Error(w, "Unable to get notifications", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting notifications", "error", err)
+			return
+		}
+		notificationDTOs := []models.NotificationDTO{}
+		for _, notification := range notifications {
+			notificationDTOs = append(notificationDTOs, notification.ToDTO())
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, notificationDTOs)
+	}
+}
+
+// SendArrivalReminders reminds the guests arriving on ?date=, tomorrow by default
+func SendArrivalReminders(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		date, err := queryDate(r, "date", services.Today().AddDate(0, 0, 1))
+		if err != nil {
+			http.Error(w, "Invalid date format, use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		reminded, err := services.SendArrivalReminders(r.Context(), dbConnection, date)
+		if err != nil {
+			http.Error(w, "Unable to send reminders", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error sending reminders", "error", err)
+			return
+		}
+		bookingDTOs := []models.BookingDTO{}
+		for _, booking := range reminded {
+			bookingDTOs = append(bookingDTOs, booking.ToDTO())
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, bookingDTOs)
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"example/logging"
+	"example/models"
+	"example/services"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+)
+
+// csvRow is implemented by the report rows that can be exported as CSV
+type csvRow interface {
+	CSVHeader() []string
+	CSVRecord() []string
+}
+
+func GetOccupancyReport(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report, ok := getKPIReport(w, r, dbConnection, validator)
+		if !ok {
+			return
+		}
+		rows := []models.OccupancyReportRow{}
+		for _, row := range report {
+			rows = append(rows, row.ToOccupancyRow())
+		}
+		returnReport(w, r, "occupancy", rows)
+	}
+}
+
+func GetADRReport(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report, ok := getKPIReport(w, r, dbConnection, validator)
+		if !ok {
+			return
+		}
+		rows := []models.ADRReportRow{}
+		for _, row := range report {
+			rows = append(rows, row.ToADRRow())
+		}
+		returnReport(w, r, "adr", rows)
+	}
+}
+
+func GetRevPARReport(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report, ok := getKPIReport(w, r, dbConnection, validator)
+		if !ok {
+			return
+		}
+		rows := []models.RevPARReportRow{}
+		for _, row := range report {
+			rows = append(rows, row.ToRevPARRow())
+		}
+		returnReport(w, r, "revpar", rows)
+	}
+}
+
+// getKPIReport parses the query parameters shared by all reports, on failure it writes the error response and returns false
+func getKPIReport(w http.ResponseWriter, r *http.Request, dbConnection *pgx.Conn, validator *validator.Validate) ([]models.KPIRow, bool) {
+	query := r.URL.Query()
+	paramsDTO := models.ReportParamsDTO{
+		StartDate: query.Get("start_date"),
+		EndDate:   query.Get("end_date"),
+		GroupBy:   query.Get("group_by"),
+		By:        query.Get("by"),
+	}
+	if paramsDTO.GroupBy == "" {
+		paramsDTO.GroupBy = "day"
+	}
+	err := validator.Struct(paramsDTO)
+	if err != nil {
+		http.Error(w, models.ReportValidationError, http.StatusBadRequest)
+		return nil, false
+	}
+	params, err := paramsDTO.ToModel()
+	if err != nil {
+		http.Error(w, "Invalid date format, use YYYY-MM-DD", http.StatusBadRequest)
+		return nil, false
+	}
+	report, err := services.GetKPIReport(r.Context(), dbConnection, params)
+	if err != nil {
+		if errors.As(err, &models.ValidationError{}) {
+			http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+		http.Error(w, "Unable to compute report", http.StatusServiceUnavailable)
+		logging.FromContext(r.Context()).Error("Error computing report", "error", err)
+		return nil, false
+	}
+	return report, true
+}
+
+// returnReport writes the rows as JSON, or as CSV when asked with ?format=csv or the Accept header
+func returnReport[T csvRow](w http.ResponseWriter, r *http.Request, name string, rows []T) {
+	if r.URL.Query().Get("format") != "csv" && !strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, rows)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".csv"))
+	w.WriteHeader(http.StatusOK)
+	var header T
+	writer := csv.NewWriter(w)
+	writer.Write(header.CSVHeader())
+	for _, row := range rows {
+		writer.Write(row.CSVRecord())
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		logging.FromContext(r.Context()).Error("Error writing CSV report", "error", err)
+	}
+}
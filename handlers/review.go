@@ -1,11 +1,10 @@
 package handlers
 
 import (
-	"encoding/json"
 	"errors"
+	"example/logging"
 	"example/models"
 	"example/services"
-	"log"
 	"net/http"
 	"strconv"
 
@@ -13,12 +12,27 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
-func GetAllReviews(dbConnection *pgx.Conn) http.HandlerFunc {
+// GetAllReviews returns the published reviews, or the ones with the status in the query (?status=pending for the moderation queue)
+func GetAllReviews(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		reviews, err := services.GetAllReviews(r.Context(), dbConnection)
+		status := r.URL.Query().Get("status")
+		if status == "" {
+			status = models.ReviewPublished
+		}
+		err := validator.Var(status, "oneof=pending published rejected all")
+		if err != nil {
+			http.Error(w, models.ReviewStatusValidationError, http.StatusBadRequest)
+			return
+		}
+		var reviews []models.Review
+		if status == "all" {
+			reviews, err = services.GetAllReviews(r.Context(), dbConnection)
+		} else {
+			reviews, err = services.GetReviewsByStatus(r.Context(), dbConnection, status)
+		}
 		if err != nil {
 			http.Error(w, "Unable to get all reviews", http.StatusServiceUnavailable)
-			log.Println("Error getting reviews:", err.Error())
+			logging.FromContext(r.Context()).Error("Error getting reviews", "error", err)
 			return
 		}
 
@@ -31,21 +45,28 @@ func GetAllReviews(dbConnection *pgx.Conn) http.HandlerFunc {
 	}
 }
 
-func GetReviewByID(dbConnection *pgx.Conn) http.HandlerFunc {
+// GetReviewByID returns a published review, or a review in any status with ?view=moderation
+func GetReviewByID(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		reviewID, err := strconv.Atoi(r.PathValue("id"))
 		if err != nil {
 			http.Error(w, "Invalid review ID", http.StatusBadRequest)
 			return
 		}
-		review, err := services.GetReviewByID(r.Context(), dbConnection, reviewID)
+		view := r.URL.Query().Get("view")
+		err = validator.Var(view, "omitempty,oneof=moderation")
+		if err != nil {
+			http.Error(w, models.ReviewViewValidationError, http.StatusBadRequest)
+			return
+		}
+		review, err := services.GetReviewByID(r.Context(), dbConnection, reviewID, view == "moderation")
 		if err != nil {
 			if errors.Is(err, pgx.ErrNoRows) {
 				http.Error(w, "Review not found", http.StatusNotFound)
 				return
 			}
 			http.Error(w, "Unable to get review", http.StatusServiceUnavailable)
-			log.Println("Error getting review:", err.Error())
+			logging.FromContext(r.Context()).Error("Error getting review", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusOK)
@@ -53,10 +74,94 @@ func GetReviewByID(dbConnection *pgx.Conn) http.HandlerFunc {
 	}
 }
 
+func GetReviewsByRoomID(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid room ID", http.StatusBadRequest)
+			return
+		}
+		reviews, err := services.GetReviewsByRoomID(r.Context(), dbConnection, roomID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Room not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to get room reviews", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting room reviews", "error", err)
+			return
+		}
+
+		reviewDTOs := []models.ReviewDTO{}
+		for _, review := range reviews {
+			reviewDTOs = append(reviewDTOs, review.ToDTO())
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, reviewDTOs)
+	}
+}
+
+func GetReviewStats(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupBy := r.URL.Query().Get("group_by")
+		if groupBy == "" {
+			groupBy = "room"
+		}
+		err := validator.Var(groupBy, "oneof=room room_type month")
+		if err != nil {
+			http.Error(w, models.ReviewStatsValidationError, http.StatusBadRequest)
+			return
+		}
+		stats, err := services.GetReviewStats(r.Context(), dbConnection, groupBy)
+		if err != nil {
+			http.Error(w, "Unable to get review stats", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting review stats", "error", err)
+			return
+		}
+		if stats == nil {
+			stats = []models.ReviewStats{}
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, stats)
+	}
+}
+
+func GetReviewKeywords(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		sentiment := query.Get("sentiment")
+		limit := 20
+		if query.Has("limit") {
+			var err error
+			limit, err = strconv.Atoi(query.Get("limit"))
+			if err != nil {
+				http.Error(w, models.ReviewKeywordsValidationError, http.StatusBadRequest)
+				return
+			}
+		}
+		if validator.Var(sentiment, "omitempty,oneof=positive negative") != nil || validator.Var(limit, "min=1,max=100") != nil {
+			http.Error(w, models.ReviewKeywordsValidationError, http.StatusBadRequest)
+			return
+		}
+		keywords, err := services.GetReviewKeywords(r.Context(), dbConnection, sentiment, limit)
+		if err != nil {
+			http.Error(w, "Unable to get review keywords", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting review keywords", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, keywords)
+	}
+}
+
 func CreateReview(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var reviewDTO models.ReviewDTO
-		err := json.NewDecoder(r.Body).Decode(&reviewDTO)
+		err := decodeJSON(w, r, &reviewDTO)
+		if bodyTooLarge(err) {
+			invalidJSON(w, err)
+			return
+		}
 		if err != nil {
 			http.Error(w, "Invalid JSON format: "+err.Error(), http.StatusBadRequest)
 			return
@@ -78,7 +183,7 @@ func CreateReview(dbConnection *pgx.Conn, validator *validator.Validate) http.Ha
 				return
 			}
 			http.Error(w, "Unable to create review", http.StatusServiceUnavailable)
-			log.Println("Error creating review:", err.Error())
+			logging.FromContext(r.Context()).Error("Error creating review", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusCreated)
@@ -94,9 +199,9 @@ func UpdateReviewByID(dbConnection *pgx.Conn, validator *validator.Validate) htt
 			return
 		}
 		var reviewDTO models.ReviewDTO
-		err = json.NewDecoder(r.Body).Decode(&reviewDTO)
+		err = decodeJSON(w, r, &reviewDTO)
 		if err != nil {
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			invalidJSON(w, err)
 			return
 		}
 		reviewDTO.BookingID = reviewID
@@ -121,7 +226,7 @@ func UpdateReviewByID(dbConnection *pgx.Conn, validator *validator.Validate) htt
 				return
 			}
 			http.Error(w, "Unable to update review", http.StatusServiceUnavailable)
-			log.Println("Error updating review:", err.Error())
+			logging.FromContext(r.Context()).Error("Error updating review", "error", err)
 			return
 		}
 		w.WriteHeader(status)
@@ -137,9 +242,9 @@ func PatchReviewByID(dbConnection *pgx.Conn, validator *validator.Validate) http
 			return
 		}
 		var patch models.ReviewPatch
-		err = json.NewDecoder(r.Body).Decode(&patch)
+		err = decodeJSON(w, r, &patch)
 		if err != nil {
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			invalidJSON(w, err)
 			return
 		}
 		err = validator.Struct(patch)
@@ -158,7 +263,7 @@ func PatchReviewByID(dbConnection *pgx.Conn, validator *validator.Validate) http
 				return
 			}
 			http.Error(w, "Unable to patch review", http.StatusServiceUnavailable)
-			log.Println("Error patching review:", err.Error())
+			logging.FromContext(r.Context()).Error("Error patching review", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
@@ -179,7 +284,117 @@ func DeleteReviewByID(dbConnection *pgx.Conn) http.HandlerFunc {
 				return
 			}
 			http.Error(w, "Unable to delete review", http.StatusServiceUnavailable)
-			log.Println("Error deleting review:", err.Error())
+			logging.FromContext(r.Context()).Error("Error deleting review", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func ApproveReview(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reviewID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid review ID", http.StatusBadRequest)
+			return
+		}
+		err = services.ApproveReview(r.Context(), dbConnection, reviewID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Review not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to approve review", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error approving review", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func RejectReview(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reviewID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid review ID", http.StatusBadRequest)
+			return
+		}
+		var rejection models.ReviewRejectionDTO
+		err = decodeJSON(w, r, &rejection)
+		if err != nil {
+			invalidJSON(w, err)
+			return
+		}
+		err = validator.Struct(rejection)
+		if err != nil {
+			http.Error(w, models.ReviewRejectionValidationError, http.StatusBadRequest)
+			return
+		}
+		err = services.RejectReview(r.Context(), dbConnection, reviewID, rejection.Reason)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Review not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to reject review", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error rejecting review", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func ReplyToReview(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reviewID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid review ID", http.StatusBadRequest)
+			return
+		}
+		var reply models.ReviewReplyDTO
+		err = decodeJSON(w, r, &reply)
+		if err != nil {
+			invalidJSON(w, err)
+			return
+		}
+		err = validator.Struct(reply)
+		if err != nil {
+			http.Error(w, models.ReviewReplyValidationError, http.StatusBadRequest)
+			return
+		}
+		err = services.ReplyToReview(r.Context(), dbConnection, reviewID, reply.Reply)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Review not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to reply to review", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error replying to review", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func DeleteReviewReply(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reviewID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid review ID", http.StatusBadRequest)
+			return
+		}
+		err = services.DeleteReviewReply(r.Context(), dbConnection, reviewID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Review not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to delete review reply", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error deleting review reply", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
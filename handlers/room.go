@@ -1,11 +1,10 @@
 package handlers
 
 import (
-	"encoding/json"
 	"errors"
+	"example/logging"
 	"example/models"
 	"example/services"
-	"log"
 	"net/http"
 	"strconv"
 
@@ -18,7 +17,7 @@ func GetAllRooms(dbConnection *pgx.Conn) http.HandlerFunc {
 		rooms, err := services.GetAllRooms(r.Context(), dbConnection)
 		if err != nil {
 			http.Error(w, "Unable to get all rooms", http.StatusServiceUnavailable)
-			log.Println("Error getting rooms:", err.Error())
+			logging.FromContext(r.Context()).Error("Error getting rooms", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusOK)
@@ -40,7 +39,7 @@ func GetRoomByID(dbConnection *pgx.Conn) http.HandlerFunc {
 				return
 			}
 			http.Error(w, "Unable to get room", http.StatusServiceUnavailable)
-			log.Println("Error getting room:", err.Error())
+			logging.FromContext(r.Context()).Error("Error getting room", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusOK)
@@ -51,9 +50,9 @@ func GetRoomByID(dbConnection *pgx.Conn) http.HandlerFunc {
 func CreateRoom(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var newRoom models.Room
-		err := json.NewDecoder(r.Body).Decode(&newRoom)
+		err := decodeJSON(w, r, &newRoom)
 		if err != nil {
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			invalidJSON(w, err)
 			return
 		}
 		err = validator.Struct(newRoom)
@@ -64,7 +63,7 @@ func CreateRoom(dbConnection *pgx.Conn, validator *validator.Validate) http.Hand
 		err = services.CreateRoom(r.Context(), dbConnection, &newRoom)
 		if err != nil {
 			http.Error(w, "Unable to create room", http.StatusServiceUnavailable)
-			log.Println("Error creating room:", err.Error())
+			logging.FromContext(r.Context()).Error("Error creating room", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusCreated)
@@ -80,9 +79,9 @@ func UpdateRoomByID(dbConnection *pgx.Conn, validator *validator.Validate) http.
 			return
 		}
 		var updatedRoom models.Room
-		err = json.NewDecoder(r.Body).Decode(&updatedRoom)
+		err = decodeJSON(w, r, &updatedRoom)
 		if err != nil {
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			invalidJSON(w, err)
 			return
 		}
 		updatedRoom.ID = roomID
@@ -98,7 +97,7 @@ func UpdateRoomByID(dbConnection *pgx.Conn, validator *validator.Validate) http.
 				return
 			}
 			http.Error(w, "Unable to update the room", http.StatusServiceUnavailable)
-			log.Println("Error updating room:", err.Error())
+			logging.FromContext(r.Context()).Error("Error updating room", "error", err)
 			return
 		}
 		w.WriteHeader(status)
@@ -114,9 +113,9 @@ func PatchRoomByID(dbConnection *pgx.Conn, validator *validator.Validate) http.H
 			return
 		}
 		var patch models.RoomPatch
-		err = json.NewDecoder(r.Body).Decode(&patch)
+		err = decodeJSON(w, r, &patch)
 		if err != nil {
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			invalidJSON(w, err)
 			return
 		}
 		err = validator.Struct(patch)
@@ -131,7 +130,7 @@ func PatchRoomByID(dbConnection *pgx.Conn, validator *validator.Validate) http.H
 				return
 			}
 			http.Error(w, "Unable to patch room", http.StatusServiceUnavailable)
-			log.Println("Error patching room:", err.Error())
+			logging.FromContext(r.Context()).Error("Error patching room", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
@@ -152,9 +151,59 @@ func DeleteRoomByID(dbConnection *pgx.Conn) http.HandlerFunc {
 				return
 			}
 			http.Error(w, "Unable to delete room", http.StatusServiceUnavailable)
-			log.Println("Error deleting room:", err.Error())
+			logging.FromContext(r.Context()).Error("Error deleting room", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+// GetAvailableRooms searches the rooms free between ?start_date and ?end_date, optionally of a ?type and for a number of ?guests
+func GetAvailableRooms(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		params := models.AvailabilityParamsDTO{
+			StartDate: query.Get("start_date"),
+			EndDate:   query.Get("end_date"),
+			Type:      query.Get("type"),
+		}
+		if query.Has("guests") {
+			guests, err := strconv.Atoi(query.Get("guests"))
+			if err != nil || guests <= 0 {
+				http.Error(w, models.AvailabilityValidationError, http.StatusBadRequest)
+				return
+			}
+			params.Guests = guests
+		}
+		err := validator.Struct(params)
+		if err != nil {
+			http.Error(w, models.AvailabilityValidationError, http.StatusBadRequest)
+			return
+		}
+		startDate, err := models.ParseDate(params.StartDate)
+		if err != nil {
+			http.Error(w, models.AvailabilityValidationError, http.StatusBadRequest)
+			return
+		}
+		endDate, err := models.ParseDate(params.EndDate)
+		if err != nil {
+			http.Error(w, models.AvailabilityValidationError, http.StatusBadRequest)
+			return
+		}
+		rooms, err := services.GetAvailableRooms(r.Context(), dbConnection, startDate, endDate, params.Type, params.Guests)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "Unable to search available rooms", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error searching available rooms", "error", err)
+			return
+		}
+		if rooms == nil {
+			rooms = []models.Room{}
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, rooms)
+	}
+}
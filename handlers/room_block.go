@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"example/logging"
+	"example/models"
+	"example/services"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+)
+
+func GetRoomBlocks(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid room ID", http.StatusBadRequest)
+			return
+		}
+		blocks, err := services.GetRoomBlocksByRoomID(r.Context(), dbConnection, roomID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Room not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to get room blocks", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting room blocks", "error", err)
+			return
+		}
+		blockDTOs := []models.RoomBlockDTO{}
+		for _, block := range blocks {
+			blockDTOs = append(blockDTOs, block.ToDTO())
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, blockDTOs)
+	}
+}
+
+// CreateRoomBlock takes a room out of order, the response lists the bookings to relocate
+func CreateRoomBlock(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid room ID", http.StatusBadRequest)
+			return
+		}
+		var blockDTO models.RoomBlockDTO
+		err = decodeJSON(w, r, &blockDTO)
+		if err != nil {
+			invalidJSON(w, err)
+			return
+		}
+		blockDTO.RoomID = roomID
+		err = validator.Struct(blockDTO)
+		if err != nil {
+			http.Error(w, models.RoomBlockValidationError, http.StatusBadRequest)
+			return
+		}
+		block, err := blockDTO.ToModel()
+		if err != nil {
+			http.Error(w, "Invalid date format, use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		conflicts, err := services.CreateRoomBlock(r.Context(), dbConnection, &block)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Room not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to create room block", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error creating room block", "error", err)
+			return
+		}
+		blockDTO = block.ToDTO()
+		for _, booking := range conflicts {
+			blockDTO.Conflicts = append(blockDTO.Conflicts, booking.ToDTO())
+		}
+		w.WriteHeader(http.StatusCreated)
+		returnJSON(w, blockDTO)
+	}
+}
+
+func DeleteRoomBlock(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid room ID", http.StatusBadRequest)
+			return
+		}
+		blockID, err := strconv.Atoi(r.PathValue("blockID"))
+		if err != nil {
+			http.Error(w, "Invalid room block ID", http.StatusBadRequest)
+			return
+		}
+		err = services.DeleteRoomBlock(r.Context(), dbConnection, roomID, blockID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Room block not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to delete room block", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error deleting room block", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetRoomBlockConflicts lists the bookings that overlap a current or future block
+func GetRoomBlockConflicts(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		conflicts, err := services.GetRoomBlockConflicts(r.Context(), dbConnection)
+		if err != nil {
+			http.Error(w, "Unable to get room block conflicts", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting room block conflicts", "error", err)
+			return
+		}
+		conflictDTOs := []models.RoomBlockConflictDTO{}
+		for _, conflict := range conflicts {
+			conflictDTOs = append(conflictDTOs, conflict.ToDTO())
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, conflictDTOs)
+	}
+}
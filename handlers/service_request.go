@@ -1,11 +1,10 @@
 package handlers
 
 import (
-	"encoding/json"
 	"errors"
+	"example/logging"
 	"example/models"
 	"example/services"
-	"log"
 	"net/http"
 	"strconv"
 
@@ -18,7 +17,7 @@ func GetAllServiceRequests(dbConnection *pgx.Conn) http.HandlerFunc {
 		requests, err := services.GetAllServiceRequests(r.Context(), dbConnection)
 		if err != nil {
 			http.Error(w, "Unable to get all service requests", http.StatusServiceUnavailable)
-			log.Println("Error getting service requests:", err.Error())
+			logging.FromContext(r.Context()).Error("Error getting service requests", "error", err)
 			return
 		}
 
@@ -45,7 +44,7 @@ func GetServiceRequestByID(dbConnection *pgx.Conn) http.HandlerFunc {
 				return
 			}
 			http.Error(w, "Unable to get service request", http.StatusServiceUnavailable)
-			log.Println("Error getting service request:", err.Error())
+			logging.FromContext(r.Context()).Error("Error getting service request", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusOK)
@@ -56,9 +55,9 @@ func GetServiceRequestByID(dbConnection *pgx.Conn) http.HandlerFunc {
 func CreateServiceRequest(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var requestDTO models.ServiceRequestDTO
-		err := json.NewDecoder(r.Body).Decode(&requestDTO)
+		err := decodeJSON(w, r, &requestDTO)
 		if err != nil {
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			invalidJSON(w, err)
 			return
 		}
 		err = validator.Struct(requestDTO)
@@ -79,7 +78,7 @@ func CreateServiceRequest(dbConnection *pgx.Conn, validator *validator.Validate)
 				return
 			}
 			http.Error(w, "Unable to create service request", http.StatusServiceUnavailable)
-			log.Println("Error creating service request:", err.Error())
+			logging.FromContext(r.Context()).Error("Error creating service request", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusCreated)
@@ -95,9 +94,9 @@ func UpdateServiceRequestByID(dbConnection *pgx.Conn, validator *validator.Valid
 			return
 		}
 		var requestDTO models.ServiceRequestDTO
-		err = json.NewDecoder(r.Body).Decode(&requestDTO)
+		err = decodeJSON(w, r, &requestDTO)
 		if err != nil {
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			invalidJSON(w, err)
 			return
 		}
 		requestDTO.ID = requestID
@@ -122,7 +121,7 @@ func UpdateServiceRequestByID(dbConnection *pgx.Conn, validator *validator.Valid
 				return
 			}
 			http.Error(w, "Unable to update service request", http.StatusServiceUnavailable)
-			log.Println("Error updating service request:", err.Error())
+			logging.FromContext(r.Context()).Error("Error updating service request", "error", err)
 			return
 		}
 		w.WriteHeader(status)
@@ -138,9 +137,9 @@ func PatchServiceRequestByID(dbConnection *pgx.Conn, validator *validator.Valida
 			return
 		}
 		var patch models.ServiceRequestPatch
-		err = json.NewDecoder(r.Body).Decode(&patch)
+		err = decodeJSON(w, r, &patch)
 		if err != nil {
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			invalidJSON(w, err)
 			return
 		}
 		err = validator.Struct(patch)
@@ -159,7 +158,7 @@ func PatchServiceRequestByID(dbConnection *pgx.Conn, validator *validator.Valida
 				return
 			}
 			http.Error(w, "Unable to patch service request", http.StatusServiceUnavailable)
-			log.Println("Error patching service request:", err.Error())
+			logging.FromContext(r.Context()).Error("Error patching service request", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
@@ -180,7 +179,91 @@ func DeleteServiceRequestByID(dbConnection *pgx.Conn) http.HandlerFunc {
 				return
 			}
 			http.Error(w, "Unable to delete service request", http.StatusServiceUnavailable)
-			log.Println("Error deleting service request:", err.Error())
+			logging.FromContext(r.Context()).Error("Error deleting service request", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func AssignServiceRequest(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid service request ID", http.StatusBadRequest)
+			return
+		}
+		var assignment models.StaffAssignmentDTO
+		err = decodeJSON(w, r, &assignment)
+		if err != nil {
+			invalidJSON(w, err)
+			return
+		}
+		err = validator.Struct(assignment)
+		if err != nil {
+			http.Error(w, models.StaffAssignmentValidationError, http.StatusBadRequest)
+			return
+		}
+		request, err := services.AssignServiceRequest(r.Context(), dbConnection, requestID, assignment.StaffID)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Service request not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to assign service request", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error assigning service request", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, request.ToDTO())
+	}
+}
+
+func ReassignServiceRequest(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid service request ID", http.StatusBadRequest)
+			return
+		}
+		request, err := services.ReassignServiceRequest(r.Context(), dbConnection, requestID)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Service request not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to reassign service request", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error reassigning service request", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, request.ToDTO())
+	}
+}
+
+func UnassignServiceRequest(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid service request ID", http.StatusBadRequest)
+			return
+		}
+		err = services.UnassignServiceRequest(r.Context(), dbConnection, requestID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Service request not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to unassign service request", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error unassigning service request", "error", err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"errors"
+	"example/logging"
+	"example/models"
+	"example/services"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+)
+
+func GetAllStaff(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		staff, err := services.GetAllStaff(r.Context(), dbConnection)
+		if err != nil {
+			http.Error(w, "Unable to get all staff", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting staff", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, staff)
+	}
+}
+
+func GetStaffByID(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		staffID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid staff ID", http.StatusBadRequest)
+			return
+		}
+		member, err := services.GetStaffByID(r.Context(), dbConnection, staffID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Staff member not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to get staff member", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting staff member", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, member)
+	}
+}
+
+func CreateStaff(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var member models.Staff
+		err := decodeJSON(w, r, &member)
+		if err != nil {
+			invalidJSON(w, err)
+			return
+		}
+		err = validator.Struct(member)
+		if err != nil {
+			http.Error(w, models.StaffValidationError, http.StatusBadRequest)
+			return
+		}
+		err = services.CreateStaff(r.Context(), dbConnection, &member)
+		if err != nil {
+			http.Error(w, "Unable to create staff member", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error creating staff member", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		returnJSON(w, member)
+	}
+}
+
+func UpdateStaffByID(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		staffID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid staff ID", http.StatusBadRequest)
+			return
+		}
+		var member models.Staff
+		err = decodeJSON(w, r, &member)
+		if err != nil {
+			invalidJSON(w, err)
+			return
+		}
+		member.ID = staffID
+		err = validator.Struct(member)
+		if err != nil {
+			http.Error(w, models.StaffValidationError, http.StatusBadRequest)
+			return
+		}
+		status, err := services.UpdateStaffByID(r.Context(), dbConnection, &member)
+		if err != nil {
+			http.Error(w, "Unable to update staff member", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error updating staff member", "error", err)
+			return
+		}
+		w.WriteHeader(status)
+		returnJSON(w, member)
+	}
+}
+
+func DeleteStaffByID(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		staffID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid staff ID", http.StatusBadRequest)
+			return
+		}
+		err = services.DeleteStaffByID(r.Context(), dbConnection, staffID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Staff member not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to delete staff member", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error deleting staff member", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func CreateStaffShift(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		staffID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid staff ID", http.StatusBadRequest)
+			return
+		}
+		var shiftDTO models.StaffShiftDTO
+		err = decodeJSON(w, r, &shiftDTO)
+		if err != nil {
+			invalidJSON(w, err)
+			return
+		}
+		shiftDTO.StaffID = staffID
+		err = validator.Struct(shiftDTO)
+		if err != nil {
+			http.Error(w, models.StaffShiftValidationError, http.StatusBadRequest)
+			return
+		}
+		shift, err := shiftDTO.ToModel()
+		if err != nil {
+			http.Error(w, models.StaffShiftValidationError, http.StatusBadRequest)
+			return
+		}
+		err = services.CreateStaffShift(r.Context(), dbConnection, &shift)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Staff member not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to create shift", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error creating shift", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		returnJSON(w, shift.ToDTO())
+	}
+}
+
+func DeleteStaffShift(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		staffID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid staff ID", http.StatusBadRequest)
+			return
+		}
+		shiftID, err := strconv.Atoi(r.PathValue("shiftID"))
+		if err != nil {
+			http.Error(w, "Invalid shift ID", http.StatusBadRequest)
+			return
+		}
+		err = services.DeleteStaffShift(r.Context(), dbConnection, staffID, shiftID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Shift not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to delete shift", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error deleting shift", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetStaffSchedule returns the shifts and assignments of a staff member, by default for the next 7 days
+func GetStaffSchedule(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		staffID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid staff ID", http.StatusBadRequest)
+			return
+		}
+		from, err := queryDate(r, "from", services.Today())
+		if err != nil {
+			http.Error(w, "Query parameter 'from' must be in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+		to, err := queryDate(r, "to", from.AddDate(0, 0, 6))
+		if err != nil {
+			http.Error(w, "Query parameter 'to' must be in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+		schedule, err := services.GetStaffSchedule(r.Context(), dbConnection, staffID, from, to)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Staff member not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to get staff schedule", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting staff schedule", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, schedule)
+	}
+}
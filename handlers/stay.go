@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"errors"
+	"example/logging"
+	"example/models"
+	"example/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+)
+
+// MoveBooking moves a guest to another room from a date, today by default
+func MoveBooking(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookingID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid booking ID", http.StatusBadRequest)
+			return
+		}
+		var moveDTO models.RoomMoveDTO
+		err = decodeJSON(w, r, &moveDTO)
+		if err != nil {
+			invalidJSON(w, err)
+			return
+		}
+		err = validator.Struct(moveDTO)
+		if err != nil {
+			http.Error(w, models.RoomMoveValidationError, http.StatusBadRequest)
+			return
+		}
+		var from time.Time
+		if moveDTO.Date != "" {
+			from, err = models.ParseDate(moveDTO.Date)
+			if err != nil {
+				http.Error(w, "Invalid date format, use YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+		}
+		booking, err := services.MoveBooking(r.Context(), dbConnection, bookingID, moveDTO.RoomID, from)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Booking not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to move booking", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error moving booking", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, booking.ToDTO())
+	}
+}
+
+// ChangeStay extends or shortens a booking
+func ChangeStay(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookingID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid booking ID", http.StatusBadRequest)
+			return
+		}
+		var stayDTO models.StayChangeDTO
+		err = decodeJSON(w, r, &stayDTO)
+		if err != nil {
+			invalidJSON(w, err)
+			return
+		}
+		err = validator.Struct(stayDTO)
+		if err != nil {
+			http.Error(w, models.StayChangeValidationError, http.StatusBadRequest)
+			return
+		}
+		endDate, err := models.ParseDate(stayDTO.EndDate)
+		if err != nil {
+			http.Error(w, "Invalid date format, use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		booking, err := services.ChangeStay(r.Context(), dbConnection, bookingID, endDate)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Booking not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to change stay", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error changing stay", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, booking.ToDTO())
+	}
+}
+
+// GetBookingSegments lists the rooms of a stay in order
+func GetBookingSegments(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookingID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid booking ID", http.StatusBadRequest)
+			return
+		}
+		segments, err := services.GetBookingSegments(r.Context(), dbConnection, bookingID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Booking not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to get booking segments", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting booking segments", "error", err)
+			return
+		}
+		segmentDTOs := []models.BookingSegmentDTO{}
+		for _, segment := range segments {
+			segmentDTOs = append(segmentDTOs, segment.ToDTO())
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, segmentDTOs)
+	}
+}
+
+func GetFolio(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bookingID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid booking ID", http.StatusBadRequest)
+			return
+		}
+		charges, err := services.GetFolio(r.Context(), dbConnection, bookingID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Booking not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to get folio", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting folio", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, models.NewFolioDTO(bookingID, charges))
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"example/logging"
+	"example/models"
+	"example/services"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetWaitlist lists the waitlist in order of arrival, optionally only the entries with ?status=
+func GetWaitlist(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := r.URL.Query().Get("status")
+		if status != "" && status != models.WaitlistWaiting && status != models.WaitlistOffered && status != models.WaitlistExpired && status != models.WaitlistBooked {
+			http.Error(w, "Invalid status, use waiting, offered, expired or booked", http.StatusBadRequest)
+			return
+		}
+		entries, err := services.GetWaitlist(r.Context(), dbConnection, status)
+		if err != nil {
+			http.Error(w, "Unable to get waitlist", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting waitlist", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, waitlistEntryDTOs(entries))
+	}
+}
+
+func GetWaitlistEntryByID(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		entryID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid waitlist entry ID", http.StatusBadRequest)
+			return
+		}
+		entry, err := services.GetWaitlistEntryByID(r.Context(), dbConnection, entryID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Waitlist entry not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to get waitlist entry", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error getting waitlist entry", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, entry.ToDTO())
+	}
+}
+
+func CreateWaitlistEntry(dbConnection *pgx.Conn, validator *validator.Validate) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var entryDTO models.WaitlistEntryDTO
+		err := decodeJSON(w, r, &entryDTO)
+		if err != nil {
+			invalidJSON(w, err)
+			return
+		}
+		err = validator.Struct(entryDTO)
+		if err != nil {
+			http.Error(w, models.WaitlistValidationError, http.StatusBadRequest)
+			return
+		}
+		entry, err := entryDTO.ToModel()
+		if err != nil {
+			http.Error(w, "Invalid date format, use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		err = services.CreateWaitlistEntry(r.Context(), dbConnection, &entry)
+		if err != nil {
+			if errors.As(err, &models.ValidationError{}) {
+				http.Error(w, "Validation error: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "Unable to create waitlist entry", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error creating waitlist entry", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		returnJSON(w, entry.ToDTO())
+	}
+}
+
+func DeleteWaitlistEntry(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		entryID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "Invalid waitlist entry ID", http.StatusBadRequest)
+			return
+		}
+		err = services.DeleteWaitlistEntry(r.Context(), dbConnection, entryID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, "Waitlist entry not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Unable to delete waitlist entry", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error deleting waitlist entry", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// MatchWaitlist offers the available rooms to the waiting customers and returns the new offers
+func MatchWaitlist(dbConnection *pgx.Conn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		offered, err := services.MatchWaitlist(r.Context(), dbConnection)
+		if err != nil {
+			http.Error(w, "Unable to match waitlist", http.StatusServiceUnavailable)
+			logging.FromContext(r.Context()).Error("Error matching waitlist", "error", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		returnJSON(w, waitlistEntryDTOs(offered))
+	}
+}
+
+func waitlistEntryDTOs(entries []models.WaitlistEntry) []models.WaitlistEntryDTO {
+	entryDTOs := []models.WaitlistEntryDTO{}
+	for _, entry := range entries {
+		entryDTOs = append(entryDTOs, entry.ToDTO())
+	}
+	return entryDTOs
+}
@@ -2,26 +2,55 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"example/config"
+	"example/dal"
 	"example/handlers"
-	"fmt"
+	"example/logging"
+	"example/metrics"
+	"example/models"
+	"example/ratelimit"
+	"example/services"
+	"example/tracing"
+	"flag"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+	_ "time/tzdata" // the hotel timezone does not depend on the zoneinfo of the system
 
 	"github.com/go-playground/validator/v10"
 	"github.com/jackc/pgx/v5"
 )
 
-func getDBConnStr(host string, dbName string) string {
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-
-	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s", user, password, host, port, dbName)
+// newHandler serves the routes of the API, every request is traced, logged with its ID, measured and rate limited.
+// The probes and the metrics use monitorConn, the other routes conn.
+func newHandler(conn *pgx.Conn, monitorConn *pgx.Conn, validator *validator.Validate, limits config.RateLimitConfig) http.Handler {
+	mux := http.NewServeMux()
+	setupRoutes(mux, conn, handlers.NewMonitor(monitorConn), validator)
+	limiters := map[string]*ratelimit.Limiter{
+		handlers.RoutePublic: ratelimit.New(limits.PublicRate, limits.PublicBurst),
+		handlers.RouteWrite:  ratelimit.New(limits.WriteRate, limits.WriteBurst),
+		handlers.RouteRead:   ratelimit.New(limits.ReadRate, limits.ReadBurst),
+	}
+	return handlers.Tracing(handlers.Logging(handlers.Metrics(handlers.RateLimit(limiters, limits.APIKeys, mux))))
 }
 
-func setupRoutes(mux *http.ServeMux, conn *pgx.Conn, validator *validator.Validate) {
+// shuttingDown fails the readiness probe while the server drains its requests
+var shuttingDown atomic.Bool
+
+func setupRoutes(mux *http.ServeMux, conn *pgx.Conn, monitor *handlers.Monitor, validator *validator.Validate) {
 	mux.HandleFunc("GET /", helloWorld)
+	mux.HandleFunc("GET /healthz", handlers.Healthz())
+	mux.HandleFunc("GET /readyz", handlers.Readyz(monitor, &shuttingDown))
+	mux.HandleFunc("GET /metrics", handlers.GetMetrics(monitor))
 
 	// Customers
 	mux.HandleFunc("GET /customers", handlers.GetAllCustomers(conn))
@@ -38,14 +67,67 @@ func setupRoutes(mux *http.ServeMux, conn *pgx.Conn, validator *validator.Valida
 	mux.HandleFunc("PUT /bookings/{id}", handlers.UpdateBookingByID(conn, validator))
 	mux.HandleFunc("PATCH /bookings/{id}", handlers.PatchBookingByID(conn, validator))
 	mux.HandleFunc("DELETE /bookings/{id}", handlers.DeleteBookingByID(conn))
+	mux.HandleFunc("POST /bookings/{id}/check-in", handlers.CheckInBooking(conn, validator))
+	mux.HandleFunc("PUT /bookings/{id}/occupants", handlers.UpdateBookingOccupants(conn, validator))
+	mux.HandleFunc("POST /bookings/{id}/check-out", handlers.CheckOutBooking(conn))
+	mux.HandleFunc("POST /bookings/{id}/cancel", handlers.CancelBookingByID(conn))
+	mux.HandleFunc("POST /bookings/{id}/move", handlers.MoveBooking(conn, validator))
+	mux.HandleFunc("PUT /bookings/{id}/stay", handlers.ChangeStay(conn, validator))
+	mux.HandleFunc("GET /bookings/{id}/segments", handlers.GetBookingSegments(conn))
+	mux.HandleFunc("GET /bookings/{id}/folio", handlers.GetFolio(conn))
+	// under a path of its own, GET /bookings/{code} and GET /bookings/by-code/{code} would conflict with the routes above
+	mux.HandleFunc("GET /booking-codes/{code}", handlers.GetBookingByCode(conn))
+
+	// Holds
+	mux.HandleFunc("GET /holds", handlers.GetActiveHolds(conn))
+	mux.HandleFunc("GET /holds/{id}", handlers.GetHoldByID(conn))
+	mux.HandleFunc("POST /holds", handlers.CreateHold(conn, validator))
+	mux.HandleFunc("DELETE /holds/{id}", handlers.DeleteHold(conn))
+
+	// Groups
+	mux.HandleFunc("GET /groups", handlers.GetAllGroups(conn))
+	mux.HandleFunc("GET /groups/{id}", handlers.GetGroupByID(conn))
+	mux.HandleFunc("POST /groups", handlers.CreateGroup(conn, validator))
+	mux.HandleFunc("POST /groups/{id}/cancel", handlers.CancelGroup(conn))
+	mux.HandleFunc("PUT /groups/{id}/rooms/{bookingID}/guest", handlers.SetGroupRoomGuest(conn, validator))
+	mux.HandleFunc("POST /groups/release-allotments", handlers.ReleaseAllotments(conn))
+
+	// Waitlist
+	mux.HandleFunc("GET /waitlist", handlers.GetWaitlist(conn))
+	mux.HandleFunc("GET /waitlist/{id}", handlers.GetWaitlistEntryByID(conn))
+	mux.HandleFunc("POST /waitlist", handlers.CreateWaitlistEntry(conn, validator))
+	mux.HandleFunc("DELETE /waitlist/{id}", handlers.DeleteWaitlistEntry(conn))
+	mux.HandleFunc("POST /waitlist/match", handlers.MatchWaitlist(conn))
+
+	// Notifications
+	mux.HandleFunc("GET /notifications", handlers.GetNotifications(conn))
+	mux.HandleFunc("POST /notifications/reminders", handlers.SendArrivalReminders(conn))
+
+	// Night audit
+	mux.HandleFunc("GET /business-date", handlers.GetBusinessDate(conn))
+	mux.HandleFunc("GET /night-audits", handlers.GetNightAudits(conn))
+	mux.HandleFunc("GET /night-audits/{date}", handlers.GetNightAudit(conn))
+
+	// Background jobs
+	mux.HandleFunc("GET /jobs", handlers.GetJobs(conn))
+	mux.HandleFunc("GET /jobs/{name}/runs", handlers.GetJobRuns(conn))
+	mux.HandleFunc("POST /jobs/{name}/run", handlers.TriggerJob())
+	mux.HandleFunc("POST /jobs/{name}/pause", handlers.PauseJob(conn, true))
+	mux.HandleFunc("POST /jobs/{name}/resume", handlers.PauseJob(conn, false))
 
 	// Reviews
-	mux.HandleFunc("GET /reviews", handlers.GetAllReviews(conn))
-	mux.HandleFunc("GET /reviews/{id}", handlers.GetReviewByID(conn))
+	mux.HandleFunc("GET /reviews", handlers.GetAllReviews(conn, validator))
+	mux.HandleFunc("GET /reviews/{id}", handlers.GetReviewByID(conn, validator))
+	mux.HandleFunc("GET /reviews/stats", handlers.GetReviewStats(conn, validator))
+	mux.HandleFunc("GET /reviews/keywords", handlers.GetReviewKeywords(conn, validator))
 	mux.HandleFunc("POST /reviews", handlers.CreateReview(conn, validator))
 	mux.HandleFunc("PUT /reviews/{id}", handlers.UpdateReviewByID(conn, validator))
 	mux.HandleFunc("PATCH /reviews/{id}", handlers.PatchReviewByID(conn, validator))
 	mux.HandleFunc("DELETE /reviews/{id}", handlers.DeleteReviewByID(conn))
+	mux.HandleFunc("POST /reviews/{id}/approve", handlers.ApproveReview(conn))
+	mux.HandleFunc("POST /reviews/{id}/reject", handlers.RejectReview(conn, validator))
+	mux.HandleFunc("PUT /reviews/{id}/reply", handlers.ReplyToReview(conn, validator))
+	mux.HandleFunc("DELETE /reviews/{id}/reply", handlers.DeleteReviewReply(conn))
 
 	// Rooms
 	mux.HandleFunc("GET /rooms", handlers.GetAllRooms(conn))
@@ -54,6 +136,19 @@ func setupRoutes(mux *http.ServeMux, conn *pgx.Conn, validator *validator.Valida
 	mux.HandleFunc("PUT /rooms/{id}", handlers.UpdateRoomByID(conn, validator))
 	mux.HandleFunc("PATCH /rooms/{id}", handlers.PatchRoomByID(conn, validator))
 	mux.HandleFunc("DELETE /rooms/{id}", handlers.DeleteRoomByID(conn))
+	mux.HandleFunc("GET /rooms/{id}/reviews", handlers.GetReviewsByRoomID(conn))
+	mux.HandleFunc("PUT /rooms/{id}/housekeeping", handlers.UpdateRoomHousekeepingStatus(conn, validator))
+	mux.HandleFunc("GET /rooms/available", handlers.GetAvailableRooms(conn, validator))
+	mux.HandleFunc("GET /rooms/{id}/blocks", handlers.GetRoomBlocks(conn))
+	mux.HandleFunc("POST /rooms/{id}/blocks", handlers.CreateRoomBlock(conn, validator))
+	mux.HandleFunc("DELETE /rooms/{id}/blocks/{blockID}", handlers.DeleteRoomBlock(conn))
+	mux.HandleFunc("GET /room-blocks/conflicts", handlers.GetRoomBlockConflicts(conn))
+
+	// Housekeeping
+	mux.HandleFunc("GET /housekeeping/board", handlers.GetHousekeepingBoard(conn))
+	mux.HandleFunc("GET /housekeeping/tasks", handlers.GetHousekeepingTasks(conn))
+	mux.HandleFunc("POST /housekeeping/tasks/generate", handlers.GenerateHousekeepingTasks(conn))
+	mux.HandleFunc("POST /housekeeping/tasks/{id}/complete", handlers.CompleteHousekeepingTask(conn))
 
 	// Services
 	mux.HandleFunc("GET /services", handlers.GetAllHotelServices(conn))
@@ -62,6 +157,7 @@ func setupRoutes(mux *http.ServeMux, conn *pgx.Conn, validator *validator.Valida
 	mux.HandleFunc("PUT /services/{id}", handlers.UpdateHotelServiceByID(conn, validator))
 	mux.HandleFunc("PATCH /services/{id}", handlers.PatchHotelServiceByID(conn, validator))
 	mux.HandleFunc("DELETE /services/{id}", handlers.DeleteHotelServiceByID(conn))
+	mux.HandleFunc("GET /services/{id}/slots", handlers.GetServiceSlots(conn, validator))
 
 	// Service Requests
 	mux.HandleFunc("GET /service-requests", handlers.GetAllServiceRequests(conn))
@@ -70,27 +166,206 @@ func setupRoutes(mux *http.ServeMux, conn *pgx.Conn, validator *validator.Valida
 	mux.HandleFunc("PUT /service-requests/{id}", handlers.UpdateServiceRequestByID(conn, validator))
 	mux.HandleFunc("PATCH /service-requests/{id}", handlers.PatchServiceRequestByID(conn, validator))
 	mux.HandleFunc("DELETE /service-requests/{id}", handlers.DeleteServiceRequestByID(conn))
+	mux.HandleFunc("PUT /service-requests/{id}/staff", handlers.AssignServiceRequest(conn, validator))
+	mux.HandleFunc("DELETE /service-requests/{id}/staff", handlers.UnassignServiceRequest(conn))
+	mux.HandleFunc("POST /service-requests/{id}/reassign", handlers.ReassignServiceRequest(conn))
+
+	// Staff
+	mux.HandleFunc("GET /staff", handlers.GetAllStaff(conn))
+	mux.HandleFunc("GET /staff/{id}", handlers.GetStaffByID(conn))
+	mux.HandleFunc("POST /staff", handlers.CreateStaff(conn, validator))
+	mux.HandleFunc("PUT /staff/{id}", handlers.UpdateStaffByID(conn, validator))
+	mux.HandleFunc("DELETE /staff/{id}", handlers.DeleteStaffByID(conn))
+	mux.HandleFunc("POST /staff/{id}/shifts", handlers.CreateStaffShift(conn, validator))
+	mux.HandleFunc("DELETE /staff/{id}/shifts/{shiftID}", handlers.DeleteStaffShift(conn))
+	mux.HandleFunc("GET /staff/{id}/schedule", handlers.GetStaffSchedule(conn))
+
+	// Reports
+	mux.HandleFunc("GET /reports/occupancy", handlers.GetOccupancyReport(conn, validator))
+	mux.HandleFunc("GET /reports/adr", handlers.GetADRReport(conn, validator))
+	mux.HandleFunc("GET /reports/revpar", handlers.GetRevPARReport(conn, validator))
 }
 
 func main() {
-	ctx := context.Background()
-	conn, err := pgx.Connect(ctx, getDBConnStr(os.Getenv("DB_HOST"), os.Getenv("DB_NAME")))
+	// "config print" shows the effective configuration instead of starting the server
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "print" {
+		printConfig(os.Args[3:])
+		return
+	}
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.Fatal("Unable to connect to database:", err)
+		log.Fatal("Invalid configuration:\n", err)
 	}
-	defer conn.Close(ctx)
+	logger, _ := logging.New(os.Stderr, cfg.Log.Format, cfg.Log.Level)
+	slog.SetDefault(logger)
 
-	val := validator.New()
-	mux := http.NewServeMux()
-	setupRoutes(mux, conn, val)
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// the context is done on SIGTERM or SIGINT, then the server shuts down
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		fatal("Unable to set up tracing", err)
+	}
+	conn, err := connect(ctx, cfg.Database)
+	if err != nil {
+		fatal("Unable to connect to database", err)
+	}
+	defer closeConn(conn)
+	metrics.TrackConnection("api", conn)
+
+	// the days of the hotel start at midnight in its timezone, the local time of the server by default
+	location, _ := cfg.Hotel.Location()
+	services.SetLocation(location)
+	handlers.SetMaxBodyBytes(int64(cfg.Server.MaxBodyBytes))
+
+	moderation := services.DefaultReviewModeration()
+	moderation.BannedWords = append(moderation.BannedWords, cfg.Reviews.BannedWords...)
+	moderation.HoldAll = cfg.Reviews.Moderation == "all"
+	services.SetReviewModeration(moderation)
+	services.SetReviewWindow(cfg.Reviews.WindowDays)
+
+	codeFormat := services.DefaultBookingCodeFormat()
+	codeFormat.Prefix = cfg.BookingCodes.Prefix
+	codeFormat.Length = cfg.BookingCodes.Length
+	err = services.SetBookingCodeFormat(codeFormat)
+	if err != nil {
+		fatal("Invalid booking code format", err)
+	}
+
+	services.OnEvent(func(ctx context.Context, _ *pgx.Conn, event models.Event) {
+		logging.FromContext(ctx).Info("Event", "type", event.Type, "customer_id", event.CustomerID)
+	})
+	// emails go to an SMTP server, or to .eml files in a directory during development
+	var sender services.Sender
+	if cfg.Mail.SMTPAddr != "" {
+		sender = &services.SMTPSender{Addr: cfg.Mail.SMTPAddr, From: cfg.Mail.From, Username: cfg.Mail.SMTPUser, Password: cfg.Mail.SMTPPassword, Timeout: cfg.Mail.SMTPTimeout}
+	} else if cfg.Mail.Dir != "" {
+		sender = &services.FileSender{Dir: cfg.Mail.Dir, From: cfg.Mail.From}
+	}
+	if sender != nil {
+		// the requests only save the emails, the job sends them on the worker connection
+		notifier := services.NewNotifier(sender)
+		services.OnEvent(notifier.HandleEvent)
+		err = services.RegisterJob("send-notifications", "@every 15s", notifier.SendPending)
+		if err != nil {
+			fatal("Unable to register jobs", err)
+		}
+	} else {
+		slog.Warn("No SMTP_ADDR or MAIL_DIR, emails are not sent")
+	}
+
+	// the scheduler has its own connection, a pgx connection cannot be shared between goroutines
+	err = services.RegisterDefaultJobs()
+	if err != nil {
+		fatal("Unable to register jobs", err)
+	}
+	workerConn, err := connect(ctx, cfg.Database)
+	if err != nil {
+		fatal("Unable to connect to database", err)
+	}
+	defer closeConn(workerConn)
+	metrics.TrackConnection("worker", workerConn)
+	schedulerDone := make(chan struct{})
+	go func() {
+		services.RunScheduler(ctx, workerConn, 15*time.Second)
+		close(schedulerDone)
+	}()
+
+	// the probes and the scrapes run every few seconds, on the connection of the requests they would collide with them
+	monitorConn, err := connect(ctx, cfg.Database)
+	if err != nil {
+		fatal("Unable to connect to database", err)
+	}
+	defer closeConn(monitorConn)
+	metrics.TrackConnection("monitor", monitorConn)
+
+	server := &http.Server{
+		Addr:              net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port)),
+		Handler:           newHandler(conn, monitorConn, validator.New(), cfg.RateLimit),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		if cfg.Server.TLS.Enabled() {
+			minVersion, _ := cfg.Server.TLS.Version()
+			server.TLSConfig = &tls.Config{MinVersion: minVersion}
+			slog.Info("Server listening on https://" + server.Addr)
+			serverErr <- server.ListenAndServeTLS(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
+			return
+		}
+		slog.Info("Server listening on http://" + server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+	select {
+	case err = <-serverErr:
+		fatal("Server failed to start", err)
+	case <-ctx.Done():
+	}
+
+	// fail the readiness probe and keep serving for the drain delay, so that the load balancer stops sending requests,
+	// then stop accepting connections, let the requests in flight and the running jobs finish and close the database
+	slog.Info("Shutting down", "drain_delay", cfg.Server.DrainDelay)
+	shuttingDown.Store(true)
+	time.Sleep(cfg.Server.DrainDelay)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	err = server.Shutdown(shutdownCtx)
+	if err != nil {
+		slog.Error("Error shutting down server", "error", err)
+	}
+	select {
+	case <-schedulerDone:
+	case <-shutdownCtx.Done():
+		slog.Warn("Background jobs still running at shutdown")
 	}
-	fmt.Printf("Server listening on http://0.0.0.0:%s\n", port)
-	err = http.ListenAndServe("0.0.0.0:"+port, mux)
+	// export the spans still buffered, the ones of the last requests and jobs
+	err = shutdownTracing(shutdownCtx)
+	if err != nil {
+		slog.Error("Error shutting down tracing", "error", err)
+	}
+}
+
+// printConfig writes the effective configuration with the secrets redacted, and the errors when it is invalid
+func printConfig(args []string) {
+	cfg, err := config.Load(args)
+	if cfg != nil {
+		printErr := cfg.Print(os.Stdout)
+		if printErr != nil {
+			log.Fatal("Unable to print configuration:", printErr)
+		}
+	}
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		log.Fatal("Invalid configuration:\n", err)
+	}
+}
+
+// connect opens a connection that measures its queries and logs them at debug level
+func connect(ctx context.Context, db config.DatabaseConfig) (*pgx.Conn, error) {
+	connConfig, err := pgx.ParseConfig(db.URL())
+	if err != nil {
+		return nil, err
+	}
+	connConfig.Tracer = dal.QueryTracer{}
+	return pgx.ConnectConfig(ctx, connConfig)
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+func closeConn(conn *pgx.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := conn.Close(ctx)
 	if err != nil {
-		log.Fatal("Server failed to start:", err)
+		slog.Error("Error closing database connection", "error", err)
 	}
 }
 
@@ -4,24 +4,38 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"example/config"
+	"example/dal"
 	"example/models"
+	"example/services"
+	"example/tracing"
 	"fmt"
 	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 var (
 	testDBName  = "testdb"
 	schemaPath  = "schema.sql"
 	conn        *pgx.Conn
+	monitorConn *pgx.Conn
 	client      = &http.Client{}
 	baseURI     string
 	roomURI     string
@@ -30,6 +44,9 @@ var (
 	reviewURI   string
 	serviceURI  string
 	requestURI  string
+	reportURI   string
+	staffURI    string
+	mailbox     = &services.MemorySender{}
 	sampleRoom  = models.Room{
 		Number:   101,
 		Type:     "basic",
@@ -49,29 +66,52 @@ var (
 		StartDate:  time.Now().AddDate(0, 0, 1).Format("2006-01-02"),
 		EndDate:    time.Now().AddDate(0, 0, 8).Format("2006-01-02"),
 	}
+	sampleOccupant = models.OccupantDTO{
+		FirstName:      "Testino",
+		LastName:       "Rossi",
+		BirthDate:      "1990-05-12",
+		Nationality:    "IT",
+		DocumentType:   "id_card",
+		DocumentNumber: "CA12345AB",
+	}
 	sampleReviewDTO = models.ReviewDTO{
-		BookingID: -1,
-		Comment:   "comment",
-		Rating:    3,
-		Date:      time.Now().AddDate(0, 0, 9).Format("2006-01-02"),
+		BookingID:  -1,
+		CustomerID: -1,
+		Comment:    "comment",
+		Rating:     3,
+		Date:       time.Now().Format("2006-01-02"),
 	}
 	sampleService = models.HotelService{
 		Type:        "room_service",
 		Description: "Sample description",
 		Duration:    30,
+		OpeningTime: "08:00",
+		ClosingTime: "20:00",
+		Capacity:    1,
+	}
+	sampleStaff = models.Staff{
+		Name:   "Tester",
+		Email:  "teststaff@example.com",
+		Skills: []string{"room_service"},
 	}
 	sampleServiceRequestDTO = models.ServiceRequestDTO{
 		CustomerID: -1,
 		ServiceID:  -1,
 		Date:       time.Now().AddDate(0, 0, 3).Format("2006-01-02"),
+		StartTime:  "10:00",
 	}
 )
 
 func TestMain(m *testing.M) {
 	// connect to the admin database and create a new test database
 	ctx := context.Background()
-	adminConnStr := getDBConnStr("localhost", os.Getenv("DB_NAME"))
-	adminConn, err := pgx.Connect(ctx, adminConnStr)
+	cfg, err := config.Load(nil)
+	if err != nil {
+		fmt.Println("Invalid configuration:", err)
+		os.Exit(1)
+	}
+	cfg.Database.Host = "localhost"
+	adminConn, err := pgx.Connect(ctx, cfg.Database.URL())
 	if err != nil {
 		fmt.Println("Unable to connect to admin database:", err)
 		os.Exit(1)
@@ -88,7 +128,8 @@ func TestMain(m *testing.M) {
 		fmt.Println("Unable to create test database:", err)
 		os.Exit(1)
 	}
-	conn, err = pgx.Connect(ctx, getDBConnStr("localhost", testDBName))
+	cfg.Database.Name = testDBName
+	conn, err = connect(ctx, cfg.Database)
 	if err != nil {
 		fmt.Println("Unable to connect to test database:", err)
 		os.Exit(1)
@@ -107,10 +148,41 @@ func TestMain(m *testing.M) {
 		os.Exit(1)
 	}
 
-	val := validator.New()
-	mux := http.NewServeMux()
-	setupRoutes(mux, conn, val)
-	testServer := httptest.NewServer(mux)
+	_, err = tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		fmt.Println("Unable to set up tracing:", err)
+		os.Exit(1)
+	}
+	notifier := services.NewNotifier(mailbox)
+	services.OnEvent(notifier.HandleEvent)
+	err = services.RegisterJob("send-notifications", "@every 15s", notifier.SendPending)
+	if err != nil {
+		fmt.Println("Unable to register jobs:", err)
+		os.Exit(1)
+	}
+	err = services.RegisterDefaultJobs()
+	if err != nil {
+		fmt.Println("Unable to register jobs:", err)
+		os.Exit(1)
+	}
+	// the scheduler runs the jobs triggered by hand on a connection of its own, the interval is long enough for the
+	// jobs not to run on their schedule during the tests
+	workerConn, err := connect(ctx, cfg.Database)
+	if err != nil {
+		fmt.Println("Unable to connect to test database:", err)
+		os.Exit(1)
+	}
+	defer workerConn.Close(ctx)
+	go services.RunScheduler(ctx, workerConn, time.Hour)
+	monitorConn, err = connect(ctx, cfg.Database)
+	if err != nil {
+		fmt.Println("Unable to connect to test database:", err)
+		os.Exit(1)
+	}
+	defer monitorConn.Close(ctx)
+
+	// the tests send many requests at once, the rate limits are tested with a server of their own
+	testServer := httptest.NewServer(newHandler(conn, monitorConn, validator.New(), config.RateLimitConfig{}))
 	baseURI = testServer.URL
 	roomURI = baseURI + "/rooms"
 	customerURI = baseURI + "/customers"
@@ -118,6 +190,8 @@ func TestMain(m *testing.M) {
 	reviewURI = baseURI + "/reviews"
 	serviceURI = baseURI + "/services"
 	requestURI = baseURI + "/service-requests"
+	reportURI = baseURI + "/reports"
+	staffURI = baseURI + "/staff"
 	defer testServer.Close()
 
 	code := m.Run()
@@ -134,8 +208,9 @@ func TestHelloWorld(t *testing.T) {
 // truncate all tables
 func resetDatabase(t *testing.T) {
 	ctx := context.Background()
-	_, err := conn.Exec(ctx, "TRUNCATE TABLE customer, booking, review, service_request, hotel_service, room RESTART IDENTITY CASCADE")
+	_, err := conn.Exec(ctx, "TRUNCATE TABLE customer, booking_group, booking, booking_occupant, review, service_request, hotel_service, room, staff, staff_skill, staff_shift, housekeeping_task, room_block, booking_segment, folio_charge, booking_hold, waitlist_entry, notification, scheduled_job, job_run, business_day, night_audit RESTART IDENTITY CASCADE")
 	require.NoError(t, err, "Failed to truncate tables: %v", err)
+	mailbox.Reset()
 }
 
 // helper function to make HTTP requests, returns response and body
@@ -174,6 +249,15 @@ func createSample[T any](t *testing.T, uri string, model T) T {
 	return createdModel
 }
 
+// helper function to move a booking in the past and check it out, so that it can be reviewed
+func completeStay(t *testing.T, bookingID int) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	_, err := conn.Exec(context.Background(), "UPDATE booking SET start_date = $1, end_date = $2, status = 'checked_out', checked_in_at = $3, checked_out_at = $4 WHERE id = $5",
+		today.AddDate(0, 0, -7), today, now.AddDate(0, 0, -7), now, bookingID)
+	require.NoError(t, err, "Failed to complete stay: %v", err)
+}
+
 func TestCustomerEndpoints(t *testing.T) {
 	t.Run("POST/customers", func(t *testing.T) {
 		resetDatabase(t)
@@ -450,6 +534,62 @@ func TestBookingEndpoints(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, "PatchedCode123", b.Code)
 	})
+	t.Run("POST/bookings - generated code", func(t *testing.T) {
+		booking := setupDependencies(t)
+		booking.Code = ""
+		booking = createSample(t, bookingURI, booking)
+		require.Regexp(t, `^[2-9A-HJKMNP-TV-Z]{4}-[2-9A-HJKMNP-TV-Z]{4}$`, booking.Code)
+
+		// updating without a code keeps the generated one
+		update := booking
+		update.Code = ""
+		update.Children = 1
+		resp, body := makeRequest(t, http.MethodPut, fmt.Sprintf("%s/%d", bookingURI, booking.ID), update)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var b models.BookingDTO
+		err := json.Unmarshal(body, &b)
+		require.NoError(t, err)
+		require.Equal(t, booking.Code, b.Code)
+	})
+	t.Run("GET/booking-codes/{code}", func(t *testing.T) {
+		booking := setupDependencies(t)
+		booking = createSample(t, bookingURI, booking)
+
+		resp, body := makeRequest(t, http.MethodGet, baseURI+"/booking-codes/"+booking.Code, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var b models.BookingDTO
+		err := json.Unmarshal(body, &b)
+		require.NoError(t, err)
+		require.Equal(t, booking, b)
+
+		resp, _ = makeRequest(t, http.MethodGet, baseURI+"/booking-codes/UNKNOWN", nil)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+	t.Run("GET/booking-codes/{code} - generated code", func(t *testing.T) {
+		booking := setupDependencies(t)
+		booking.Code = ""
+		booking = createSample(t, bookingURI, booking)
+
+		// the guest may type the code in lower case and without the dash
+		typed := strings.ToLower(strings.ReplaceAll(booking.Code, "-", ""))
+		resp, body := makeRequest(t, http.MethodGet, baseURI+"/booking-codes/"+typed, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var b models.BookingDTO
+		err := json.Unmarshal(body, &b)
+		require.NoError(t, err)
+		require.Equal(t, booking.ID, b.ID)
+
+		// a mistyped character breaks the check character
+		chars := []byte(booking.Code)
+		if chars[0] == 'A' {
+			chars[0] = 'B'
+		} else {
+			chars[0] = 'A'
+		}
+		resp, body = makeRequest(t, http.MethodGet, baseURI+"/booking-codes/"+string(chars), nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Contains(t, string(body), "invalid_booking_code")
+	})
 	t.Run("DELETE/bookings/{id}", func(t *testing.T) {
 		booking := setupDependencies(t)
 		booking = createSample(t, bookingURI, booking)
@@ -462,6 +602,122 @@ func TestBookingEndpoints(t *testing.T) {
 	})
 }
 
+func TestBookingStatusEndpoints(t *testing.T) {
+	setupDependencies := func(t *testing.T) models.BookingDTO {
+		resetDatabase(t)
+		booking := sampleBookingDTO
+		booking.CustomerID = createSample(t, customerURI, sampleCustomer).ID
+		booking.RoomID = createSample(t, roomURI, sampleRoom).ID
+		return createSample(t, bookingURI, booking)
+	}
+	t.Run("POST/bookings/{id}/cancel", func(t *testing.T) {
+		booking := setupDependencies(t)
+		require.Equal(t, models.BookingConfirmed, booking.Status)
+
+		resp, body := makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/cancel", bookingURI, booking.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var b models.BookingDTO
+		err := json.Unmarshal(body, &b)
+		require.NoError(t, err)
+		require.Equal(t, models.BookingCancelled, b.Status)
+
+		resp, _ = makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/cancel", bookingURI, booking.ID), nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		// a cancelled booking frees the room
+		booking.ID = 0
+		booking.Code = "AFTERCANCEL"
+		createSample(t, bookingURI, booking)
+	})
+	t.Run("POST/bookings/{id}/check-in - too early", func(t *testing.T) {
+		booking := setupDependencies(t)
+		resp, body := makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/check-in", bookingURI, booking.ID), nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		fmt.Print(string(body))
+	})
+	t.Run("POST/bookings/{id}/check-in and check-out", func(t *testing.T) {
+		booking := setupDependencies(t)
+		_, err := conn.Exec(context.Background(), "UPDATE booking SET start_date = start_date - 1 WHERE id = $1", booking.ID)
+		require.NoError(t, err)
+
+		resp, _ := makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/check-out", bookingURI, booking.ID), nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		// the guests must be registered
+		resp, _ = makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/check-in", bookingURI, booking.ID), nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		checkIn := models.OccupantsDTO{Occupants: []models.OccupantDTO{sampleOccupant}}
+		resp, body := makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/check-in", bookingURI, booking.ID), checkIn)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		resp, body = makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/check-out", bookingURI, booking.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		var b models.BookingDTO
+		err = json.Unmarshal(body, &b)
+		require.NoError(t, err)
+		require.Equal(t, models.BookingCheckedOut, b.Status)
+		require.NotEmpty(t, b.CheckedInAt)
+		require.NotEmpty(t, b.CheckedOutAt)
+	})
+	t.Run("POST/bookings - guests over the room capacity", func(t *testing.T) {
+		booking := setupDependencies(t)
+		booking.Adults = sampleRoom.Capacity
+		booking.Children = 1
+		resp, body := makeRequest(t, http.MethodPost, bookingURI, booking)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		fmt.Print(string(body))
+
+		booking.Children = 0
+		newBooking := createSample(t, bookingURI, booking)
+		require.Equal(t, sampleRoom.Capacity, newBooking.Adults)
+
+		patch := map[string]any{"children": 1}
+		resp, _ = makeRequest(t, http.MethodPatch, fmt.Sprintf("%s/%d", bookingURI, newBooking.ID), patch)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("PUT/bookings/{id}/occupants", func(t *testing.T) {
+		booking := setupDependencies(t)
+		booking.Children = 1
+		booking = createSample(t, bookingURI, booking)
+		require.Equal(t, 1, booking.Adults)
+		uri := fmt.Sprintf("%s/%d/occupants", bookingURI, booking.ID)
+
+		child := sampleOccupant
+		child.FirstName = "Bimbo"
+		child.BirthDate = time.Now().AddDate(-6, 0, 0).Format("2006-01-02")
+		child.DocumentType = ""
+		child.DocumentNumber = ""
+		resp, body := makeRequest(t, http.MethodPut, uri, models.OccupantsDTO{Occupants: []models.OccupantDTO{sampleOccupant, child}})
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+
+		resp, body = makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d", bookingURI, booking.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var b models.BookingDTO
+		err := json.Unmarshal(body, &b)
+		require.NoError(t, err)
+		require.Equal(t, []models.OccupantDTO{sampleOccupant, child}, b.Occupants)
+
+		// no more occupants than guests
+		resp, _ = makeRequest(t, http.MethodPut, uri, models.OccupantsDTO{Occupants: []models.OccupantDTO{sampleOccupant, child, child}})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		invalid := sampleOccupant
+		invalid.Nationality = "Italy"
+		resp, _ = makeRequest(t, http.MethodPut, uri, models.OccupantsDTO{Occupants: []models.OccupantDTO{invalid}})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		// the registered occupants are used at check-in, adults need a document
+		_, err = conn.Exec(context.Background(), "UPDATE booking SET start_date = start_date - 1 WHERE id = $1", booking.ID)
+		require.NoError(t, err)
+		noDocument := sampleOccupant
+		noDocument.DocumentType = ""
+		noDocument.DocumentNumber = ""
+		resp, body = makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/check-in", bookingURI, booking.ID), models.OccupantsDTO{Occupants: []models.OccupantDTO{noDocument, child}})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		fmt.Print(string(body))
+		resp, body = makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/check-in", bookingURI, booking.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	})
+}
+
 func TestReviewEndpoints(t *testing.T) {
 	setupDependencies := func(t *testing.T) models.ReviewDTO {
 		resetDatabase(t)
@@ -470,11 +726,14 @@ func TestReviewEndpoints(t *testing.T) {
 		booking.CustomerID = createSample(t, customerURI, sampleCustomer).ID
 		booking.RoomID = createSample(t, roomURI, sampleRoom).ID
 		review.BookingID = createSample(t, bookingURI, booking).ID
+		review.CustomerID = booking.CustomerID
+		completeStay(t, review.BookingID)
 		return review
 	}
 	t.Run("POST/reviews - success", func(t *testing.T) {
 		review := setupDependencies(t)
 		newReview := createSample(t, reviewURI, review)
+		review.Status = models.ReviewPublished
 		require.Equal(t, review, newReview)
 	})
 	// test for validation logic
@@ -486,22 +745,49 @@ func TestReviewEndpoints(t *testing.T) {
 		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "Expected validation error, got: %s", string(body))
 		fmt.Print(string(body))
 	})
-	t.Run("POST/reviews - review date must be after booking start date", func(t *testing.T) {
-		resetDatabase(t)
-		booking := sampleBookingDTO
-		newCustomer := createSample(t, customerURI, sampleCustomer)
-		booking.CustomerID = newCustomer.ID
-		newRoom := createSample(t, roomURI, sampleRoom)
-		booking.RoomID = newRoom.ID
-		newBookingDTO := createSample(t, bookingURI, booking)
-
-		bdate, err := time.Parse("2006-01-02", newBookingDTO.StartDate)
+	t.Run("POST/reviews - review date must not be before check-out", func(t *testing.T) {
+		invalidReview := setupDependencies(t)
+		invalidReview.Date = time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+		resp, body := makeRequest(t, http.MethodPost, reviewURI, invalidReview)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "Expected validation error, got: %s", string(body))
+		fmt.Print(string(body))
+	})
+	t.Run("POST/reviews - review date in the future", func(t *testing.T) {
+		invalidReview := setupDependencies(t)
+		invalidReview.Date = time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+		resp, body := makeRequest(t, http.MethodPost, reviewURI, invalidReview)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "Expected validation error, got: %s", string(body))
+		require.Contains(t, string(body), "review_date_in_future")
+	})
+	t.Run("POST/reviews - stay not completed", func(t *testing.T) {
+		invalidReview := setupDependencies(t)
+		_, err := conn.Exec(context.Background(), "UPDATE booking SET status = 'checked_in', checked_out_at = NULL, end_date = end_date + 2 WHERE id = $1", invalidReview.BookingID)
+		require.NoError(t, err)
+		resp, body := makeRequest(t, http.MethodPost, reviewURI, invalidReview)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "Expected validation error, got: %s", string(body))
+		fmt.Print(string(body))
+	})
+	t.Run("POST/reviews - cancelled booking", func(t *testing.T) {
+		invalidReview := setupDependencies(t)
+		_, err := conn.Exec(context.Background(), "UPDATE booking SET status = 'cancelled' WHERE id = $1", invalidReview.BookingID)
 		require.NoError(t, err)
-
-		invalidReview := sampleReviewDTO
-		invalidReview.Date = bdate.AddDate(0, 0, -1).Format("2006-01-02")
-
-		invalidReview.BookingID = newBookingDTO.ID
+		resp, body := makeRequest(t, http.MethodPost, reviewURI, invalidReview)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "Expected validation error, got: %s", string(body))
+		fmt.Print(string(body))
+	})
+	t.Run("POST/reviews - review window closed", func(t *testing.T) {
+		invalidReview := setupDependencies(t)
+		_, err := conn.Exec(context.Background(), "UPDATE booking SET start_date = start_date - 60, end_date = end_date - 60, checked_out_at = checked_out_at - interval '60 days' WHERE id = $1", invalidReview.BookingID)
+		require.NoError(t, err)
+		resp, body := makeRequest(t, http.MethodPost, reviewURI, invalidReview)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "Expected validation error, got: %s", string(body))
+		fmt.Print(string(body))
+	})
+	t.Run("POST/reviews - reviewer is not the customer of the booking", func(t *testing.T) {
+		invalidReview := setupDependencies(t)
+		other := sampleCustomer
+		other.CF = "OTHERCF12345"
+		invalidReview.CustomerID = createSample(t, customerURI, other).ID
 		resp, body := makeRequest(t, http.MethodPost, reviewURI, invalidReview)
 		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "Expected validation error, got: %s", string(body))
 		fmt.Print(string(body))
@@ -580,12 +866,229 @@ func TestReviewEndpoints(t *testing.T) {
 	})
 }
 
+func TestReviewModerationEndpoints(t *testing.T) {
+	setupDependencies := func(t *testing.T) models.ReviewDTO {
+		resetDatabase(t)
+		review := sampleReviewDTO
+		booking := sampleBookingDTO
+		booking.CustomerID = createSample(t, customerURI, sampleCustomer).ID
+		booking.RoomID = createSample(t, roomURI, sampleRoom).ID
+		review.BookingID = createSample(t, bookingURI, booking).ID
+		review.CustomerID = booking.CustomerID
+		completeStay(t, review.BookingID)
+		return review
+	}
+	getReviews := func(t *testing.T, status string) []models.ReviewDTO {
+		resp, body := makeRequest(t, http.MethodGet, reviewURI+"?status="+status, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var reviews []models.ReviewDTO
+		err := json.Unmarshal(body, &reviews)
+		require.NoError(t, err)
+		return reviews
+	}
+	t.Run("POST/reviews - flagged review is pending", func(t *testing.T) {
+		review := setupDependencies(t)
+		review.Comment = "Visit www.spam.example for cheap rooms!!!!!"
+		review = createSample(t, reviewURI, review)
+		require.Equal(t, models.ReviewPending, review.Status)
+		require.NotEmpty(t, review.ModerationReason)
+
+		require.Empty(t, getReviews(t, models.ReviewPublished))
+		require.Contains(t, getReviews(t, models.ReviewPending), review)
+
+		// a pending review is only visible in the moderation view
+		resp, _ := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d", reviewURI, review.BookingID), nil)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp, _ = makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d?view=other", reviewURI, review.BookingID), nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d?view=moderation", reviewURI, review.BookingID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var r models.ReviewDTO
+		err := json.Unmarshal(body, &r)
+		require.NoError(t, err)
+		require.Equal(t, review, r)
+	})
+	t.Run("POST/reviews/{id}/approve", func(t *testing.T) {
+		review := setupDependencies(t)
+		review.Comment = "What a shit view, shit"
+		review = createSample(t, reviewURI, review)
+		require.Equal(t, models.ReviewPending, review.Status)
+		require.Equal(t, "comment contains the banned word 'shit'", review.ModerationReason)
+
+		resp, _ := makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/approve", reviewURI, review.BookingID), nil)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		review.Status = models.ReviewPublished
+		review.ModerationReason = ""
+		require.Contains(t, getReviews(t, models.ReviewPublished), review)
+
+		// the approval holds as long as the comment does not change
+		review.Rating = 4
+		resp, _ = makeRequest(t, http.MethodPut, fmt.Sprintf("%s/%d", reviewURI, review.BookingID), review)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, getReviews(t, models.ReviewPublished), review)
+	})
+	t.Run("POST/reviews/{id}/reject", func(t *testing.T) {
+		review := setupDependencies(t)
+		review = createSample(t, reviewURI, review)
+
+		resp, _ := makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/reject", reviewURI, review.BookingID), map[string]any{})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp, _ = makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/reject", reviewURI, review.BookingID), models.ReviewRejectionDTO{Reason: "off topic"})
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		review.Status = models.ReviewRejected
+		review.ModerationReason = "off topic"
+		require.Contains(t, getReviews(t, models.ReviewRejected), review)
+
+		// the hotel cannot reply to a rejected review
+		resp, _ = makeRequest(t, http.MethodPut, fmt.Sprintf("%s/%d/reply", reviewURI, review.BookingID), models.ReviewReplyDTO{Reply: "Thanks"})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		// an edited comment does not publish a rejected review
+		review.Comment = "A clean comment"
+		resp, _ = makeRequest(t, http.MethodPut, fmt.Sprintf("%s/%d", reviewURI, review.BookingID), review)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		comment := "Another clean comment"
+		resp, _ = makeRequest(t, http.MethodPatch, fmt.Sprintf("%s/%d", reviewURI, review.BookingID), models.ReviewPatch{Comment: &comment})
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		review.Comment = comment
+		require.Contains(t, getReviews(t, models.ReviewRejected), review)
+	})
+	t.Run("PUT/reviews/{id}/reply", func(t *testing.T) {
+		review := setupDependencies(t)
+		review = createSample(t, reviewURI, review)
+
+		resp, _ := makeRequest(t, http.MethodPut, fmt.Sprintf("%s/%d/reply", reviewURI, review.BookingID), models.ReviewReplyDTO{Reply: "Thank you for staying with us"})
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d", reviewURI, review.BookingID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var r models.ReviewDTO
+		err := json.Unmarshal(body, &r)
+		require.NoError(t, err)
+		require.Equal(t, "Thank you for staying with us", r.Reply)
+		require.NotEmpty(t, r.ReplyDate)
+
+		resp, _ = makeRequest(t, http.MethodDelete, fmt.Sprintf("%s/%d/reply", reviewURI, review.BookingID), nil)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		require.Contains(t, getReviews(t, models.ReviewPublished), review)
+	})
+}
+
+func TestReviewAnalyticsEndpoints(t *testing.T) {
+	setupDependencies := func(t *testing.T) (models.Room, models.ReviewDTO) {
+		resetDatabase(t)
+		review := sampleReviewDTO
+		booking := sampleBookingDTO
+		booking.CustomerID = createSample(t, customerURI, sampleCustomer).ID
+		room := createSample(t, roomURI, sampleRoom)
+		booking.RoomID = room.ID
+		review.BookingID = createSample(t, bookingURI, booking).ID
+		review.CustomerID = booking.CustomerID
+		completeStay(t, review.BookingID)
+		review.Comment = "Room was dirty and noisy, staff friendly"
+		review.Rating = 2
+		return room, createSample(t, reviewURI, review)
+	}
+	t.Run("GET/rooms/{id}/reviews", func(t *testing.T) {
+		room, review := setupDependencies(t)
+
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d/reviews", roomURI, room.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var reviews []models.ReviewDTO
+		err := json.Unmarshal(body, &reviews)
+		require.NoError(t, err)
+		require.Equal(t, []models.ReviewDTO{review}, reviews)
+
+		resp, _ = makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d/reviews", roomURI, room.ID+1), nil)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+	t.Run("GET/reviews/stats", func(t *testing.T) {
+		room, _ := setupDependencies(t)
+
+		resp, body := makeRequest(t, http.MethodGet, reviewURI+"/stats?group_by=room_type", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var stats []models.ReviewStats
+		err := json.Unmarshal(body, &stats)
+		require.NoError(t, err)
+		require.Len(t, stats, 1)
+		require.Equal(t, room.Type, stats[0].Group)
+		require.Equal(t, 2.0, stats[0].AverageRating)
+		require.Equal(t, 1, stats[0].Distribution[2])
+
+		resp, _ = makeRequest(t, http.MethodGet, reviewURI+"/stats?group_by=year", nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("GET/reviews/keywords", func(t *testing.T) {
+		setupDependencies(t)
+
+		resp, body := makeRequest(t, http.MethodGet, reviewURI+"/keywords?sentiment=negative", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var keywords []models.ReviewKeyword
+		err := json.Unmarshal(body, &keywords)
+		require.NoError(t, err)
+		var words []string
+		for _, k := range keywords {
+			words = append(words, k.Keyword)
+		}
+		require.Contains(t, words, "dirty")
+		require.Contains(t, words, "noisy")
+		require.NotContains(t, words, "and")
+
+		resp, _ = makeRequest(t, http.MethodGet, reviewURI+"/keywords?limit=0", nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("GET/reviews/keywords - negation", func(t *testing.T) {
+		_, review := setupDependencies(t)
+		keywordSentiment := func(t *testing.T, comment string, keyword string) float64 {
+			resp, _ := makeRequest(t, http.MethodPatch, fmt.Sprintf("%s/%d", reviewURI, review.BookingID), models.ReviewPatch{Comment: &comment})
+			require.Equal(t, http.StatusNoContent, resp.StatusCode)
+			resp, body := makeRequest(t, http.MethodGet, reviewURI+"/keywords", nil)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			var keywords []models.ReviewKeyword
+			err := json.Unmarshal(body, &keywords)
+			require.NoError(t, err)
+			for _, k := range keywords {
+				if k.Keyword == keyword {
+					return k.Sentiment
+				}
+			}
+			require.Failf(t, "keyword not found", "%s in %v", keyword, keywords)
+			return 0
+		}
+
+		// the negation reaches a sentiment word a few words later
+		require.Equal(t, -1.0, keywordSentiment(t, "Not very good", "good"))
+		require.Equal(t, -1.0, keywordSentiment(t, "Non era molto pulita", "pulita"))
+		// but ends at the punctuation
+		require.Equal(t, 1.0, keywordSentiment(t, "Not a problem, clean breakfast", "breakfast"))
+	})
+}
+
 func TestHotelServiceEndpoints(t *testing.T) {
 	t.Run("POST/services", func(t *testing.T) {
 		resetDatabase(t)
 		newService := createSample(t, serviceURI, sampleService)
 		require.Equal(t, sampleService.Type, newService.Type)
 	})
+	t.Run("POST/services - default opening hours and capacity", func(t *testing.T) {
+		resetDatabase(t)
+		service := sampleService
+		service.OpeningTime = ""
+		service.ClosingTime = ""
+		service.Capacity = 0
+		newService := createSample(t, serviceURI, service)
+		require.Equal(t, "08:00", newService.OpeningTime)
+		require.Equal(t, "20:00", newService.ClosingTime)
+		require.Equal(t, 1, newService.Capacity)
+
+		resp, body := makeRequest(t, http.MethodPut, fmt.Sprintf("%s/%d", serviceURI, newService.ID), service)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var updated models.HotelService
+		err := json.Unmarshal(body, &updated)
+		require.NoError(t, err)
+		require.Equal(t, newService, updated)
+	})
 	t.Run("POST/services - service type already exists", func(t *testing.T) {
 		resetDatabase(t)
 		newService := createSample(t, serviceURI, sampleService)
@@ -723,69 +1226,1041 @@ func TestServiceRequestEndpoints(t *testing.T) {
 		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
 		fmt.Print(string(body))
 	})
-	t.Run("GET/service-requests", func(t *testing.T) {
+	t.Run("POST/service-requests - outside opening hours", func(t *testing.T) {
 		request := setupDependencies(t)
-		request = createSample(t, requestURI, request)
+		request.StartTime = "19:45"
+		resp, body := makeRequest(t, http.MethodPost, requestURI, request)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		fmt.Print(string(body))
+	})
+	t.Run("POST/service-requests - slot full", func(t *testing.T) {
+		request := setupDependencies(t)
+		createSample(t, requestURI, request)
 
-		resp, body := makeRequest(t, http.MethodGet, requestURI, nil)
-		require.Equal(t, http.StatusOK, resp.StatusCode)
-		var requests []models.ServiceRequestDTO
-		err := json.Unmarshal(body, &requests)
-		require.NoError(t, err)
-		require.Contains(t, requests, request)
+		// another guest in the same room, the service has capacity 1
+		other := sampleCustomer
+		other.CF = "OTHERCF12345"
+		booking := sampleBookingDTO
+		booking.Code = "OTHERBOOK"
+		booking.CustomerID = createSample(t, customerURI, other).ID
+		room := sampleRoom
+		room.Number++
+		booking.RoomID = createSample(t, roomURI, room).ID
+		createSample(t, bookingURI, booking)
+		request.CustomerID = booking.CustomerID
+		request.StartTime = "10:15"
+		resp, body := makeRequest(t, http.MethodPost, requestURI, request)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		fmt.Print(string(body))
+
+		request.StartTime = "10:30"
+		createSample(t, requestURI, request)
 	})
-	t.Run("GET/service-requests/{id}", func(t *testing.T) {
+	t.Run("POST/service-requests - slot full - peak of concurrent requests", func(t *testing.T) {
 		request := setupDependencies(t)
-		request = createSample(t, requestURI, request)
+		_, err := conn.Exec(context.Background(), "UPDATE hotel_service SET capacity = 2 WHERE id = $1", request.ServiceID)
+		require.NoError(t, err)
+		createSample(t, requestURI, request)
+		later := request
+		later.StartTime = "10:30"
+		createSample(t, requestURI, later)
 
-		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d", requestURI, request.ID), nil)
+		// 10:15-10:45 overlaps both requests, but they never run together, one place is still free
+		request.StartTime = "10:15"
+		createSample(t, requestURI, request)
+
+		// at 10:30 the requests of 10:15 and 10:30 take both places
+		request.StartTime = "10:20"
+		resp, body := makeRequest(t, http.MethodPost, requestURI, request)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		fmt.Print(string(body))
+	})
+	t.Run("GET/services/{id}/slots", func(t *testing.T) {
+		request := setupDependencies(t)
+		createSample(t, requestURI, request)
+
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d/slots?date=%s", serviceURI, request.ServiceID, request.Date), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var slots []models.ServiceSlot
+		err := json.Unmarshal(body, &slots)
+		require.NoError(t, err)
+		require.Len(t, slots, 24) // 08:00-20:00 in slots of 30 minutes
+		for _, slot := range slots {
+			if slot.StartTime == request.StartTime {
+				require.Equal(t, 0, slot.Available)
+			} else {
+				require.Equal(t, 1, slot.Available)
+			}
+		}
+
+		resp, _ = makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d/slots", serviceURI, request.ServiceID), nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("GET/service-requests", func(t *testing.T) {
+		request := setupDependencies(t)
+		request = createSample(t, requestURI, request)
+
+		resp, body := makeRequest(t, http.MethodGet, requestURI, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var requests []models.ServiceRequestDTO
+		err := json.Unmarshal(body, &requests)
+		require.NoError(t, err)
+		require.Contains(t, requests, request)
+	})
+	t.Run("GET/service-requests/{id}", func(t *testing.T) {
+		request := setupDependencies(t)
+		request = createSample(t, requestURI, request)
+
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d", requestURI, request.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var r models.ServiceRequestDTO
+		err := json.Unmarshal(body, &r)
+		require.NoError(t, err)
+		require.Equal(t, request, r)
+	})
+	t.Run("PUT/service-requests/{id} - update", func(t *testing.T) {
+		request := setupDependencies(t)
+		request = createSample(t, requestURI, request)
+		request.Date = time.Now().AddDate(0, 0, 4).Format("2006-01-02")
+
+		resp, body := makeRequest(t, http.MethodPut, fmt.Sprintf("%s/%d", requestURI, request.ID), request)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var r models.ServiceRequestDTO
+		err := json.Unmarshal(body, &r)
+		require.NoError(t, err)
+		require.Equal(t, request, r)
+	})
+	t.Run("PUT/service-requests/{id} - create", func(t *testing.T) {
+		request := setupDependencies(t)
+		resp, _ := makeRequest(t, http.MethodPut, fmt.Sprintf("%s/%d", requestURI, request.ID), request)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+	t.Run("PATCH/service-requests/{id}", func(t *testing.T) {
+		request := setupDependencies(t)
+		request = createSample(t, requestURI, request)
+
+		patch := map[string]any{"date": time.Now().AddDate(0, 0, 4).Format("2006-01-02")}
+		resp, _ := makeRequest(t, http.MethodPatch, fmt.Sprintf("%s/%d", requestURI, request.ID), patch)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d", requestURI, request.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var r models.ServiceRequestDTO
+		err := json.Unmarshal(body, &r)
+		require.NoError(t, err)
+		require.Equal(t, time.Now().AddDate(0, 0, 4).Format("2006-01-02"), r.Date)
+	})
+	t.Run("DELETE/service-requests/{id}", func(t *testing.T) {
+		request := setupDependencies(t)
+		request = createSample(t, requestURI, request)
+
+		resp, _ := makeRequest(t, http.MethodDelete, fmt.Sprintf("%s/%d", requestURI, request.ID), nil)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		resp, _ = makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d", requestURI, request.ID), nil)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestStaffEndpoints(t *testing.T) {
+	// creates two qualified staff members on shift and a service request to assign
+	setupDependencies := func(t *testing.T) (models.ServiceRequestDTO, []models.Staff) {
+		resetDatabase(t)
+		booking := sampleBookingDTO
+		request := sampleServiceRequestDTO
+		booking.CustomerID = createSample(t, customerURI, sampleCustomer).ID
+		booking.RoomID = createSample(t, roomURI, sampleRoom).ID
+		createSample(t, bookingURI, booking)
+		request.CustomerID = booking.CustomerID
+		request.ServiceID = createSample(t, serviceURI, sampleService).ID
+
+		var staff []models.Staff
+		for _, name := range []string{"First", "Second"} {
+			member := sampleStaff
+			member.Name = name
+			member = createSample(t, staffURI, member)
+			shift := models.StaffShiftDTO{Date: request.Date, StartTime: "08:00", EndTime: "16:00"}
+			createSample(t, fmt.Sprintf("%s/%d/shifts", staffURI, member.ID), shift)
+			staff = append(staff, member)
+		}
+		return request, staff
+	}
+	t.Run("POST/staff", func(t *testing.T) {
+		resetDatabase(t)
+		member := createSample(t, staffURI, sampleStaff)
+		require.Equal(t, sampleStaff.Skills, member.Skills)
+
+		member.Skills = []string{"cleaning", "cleaning"}
+		resp, _ := makeRequest(t, http.MethodPost, staffURI, member)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("PUT/staff/{id}", func(t *testing.T) {
+		resetDatabase(t)
+		member := createSample(t, staffURI, sampleStaff)
+		member.Skills = []string{"cleaning", "massage"}
+
+		resp, _ := makeRequest(t, http.MethodPut, fmt.Sprintf("%s/%d", staffURI, member.ID), member)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d", staffURI, member.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var m models.Staff
+		err := json.Unmarshal(body, &m)
+		require.NoError(t, err)
+		require.Equal(t, member, m)
+	})
+	t.Run("POST/staff/{id}/shifts - overlap", func(t *testing.T) {
+		request, staff := setupDependencies(t)
+		shift := models.StaffShiftDTO{Date: request.Date, StartTime: "15:00", EndTime: "20:00"}
+		resp, body := makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/shifts", staffURI, staff[0].ID), shift)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		fmt.Print(string(body))
+	})
+	t.Run("POST/service-requests - automatic assignment", func(t *testing.T) {
+		request, staff := setupDependencies(t)
+		first := createSample(t, requestURI, request)
+		require.NotNil(t, first.StaffID)
+		require.Equal(t, staff[0].ID, *first.StaffID)
+
+		// the least loaded staff member gets the next request
+		request.StartTime = "12:00"
+		second := createSample(t, requestURI, request)
+		require.NotNil(t, second.StaffID)
+		require.Equal(t, staff[1].ID, *second.StaffID)
+
+		// nobody is on shift in the evening
+		request.StartTime = "18:00"
+		third := createSample(t, requestURI, request)
+		require.Nil(t, third.StaffID)
+	})
+	t.Run("GET/staff/{id}/schedule", func(t *testing.T) {
+		request, staff := setupDependencies(t)
+		request = createSample(t, requestURI, request)
+
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d/schedule?from=%s&to=%s", staffURI, staff[0].ID, request.Date, request.Date), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var schedule models.StaffSchedule
+		err := json.Unmarshal(body, &schedule)
+		require.NoError(t, err)
+		require.Len(t, schedule.Shifts, 1)
+		require.Equal(t, []models.ServiceRequestDTO{request}, schedule.Assignments)
+
+		resp, _ = makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d/schedule", staffURI, -1), nil)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+	t.Run("reassign service request", func(t *testing.T) {
+		request, staff := setupDependencies(t)
+		request = createSample(t, requestURI, request)
+
+		resp, body := makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/reassign", requestURI, request.ID), nil)
 		require.Equal(t, http.StatusOK, resp.StatusCode)
 		var r models.ServiceRequestDTO
 		err := json.Unmarshal(body, &r)
 		require.NoError(t, err)
-		require.Equal(t, request, r)
+		require.Equal(t, staff[1].ID, *r.StaffID)
+
+		resp, _ = makeRequest(t, http.MethodPut, fmt.Sprintf("%s/%d/staff", requestURI, request.ID), models.StaffAssignmentDTO{StaffID: staff[0].ID})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// the second staff member is busy with another request, no one else is available
+		other := request
+		other.ID = 0
+		other.StaffID = nil
+		other.StartTime = "10:15"
+		_, err = conn.Exec(context.Background(), "UPDATE hotel_service SET capacity = 2 WHERE id = $1", request.ServiceID)
+		require.NoError(t, err)
+		other = createSample(t, requestURI, other)
+		require.Equal(t, staff[1].ID, *other.StaffID)
+		resp, body = makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/reassign", requestURI, request.ID), nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		fmt.Print(string(body))
+
+		resp, _ = makeRequest(t, http.MethodDelete, fmt.Sprintf("%s/%d/staff", requestURI, request.ID), nil)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		resp, body = makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d", requestURI, request.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		err = json.Unmarshal(body, &r)
+		require.NoError(t, err)
+		require.Nil(t, r.StaffID)
+	})
+	t.Run("PUT/service-requests/{id}/staff - not qualified", func(t *testing.T) {
+		request, _ := setupDependencies(t)
+		request = createSample(t, requestURI, request)
+		member := sampleStaff
+		member.Skills = []string{"massage"}
+		member = createSample(t, staffURI, member)
+
+		resp, body := makeRequest(t, http.MethodPut, fmt.Sprintf("%s/%d/staff", requestURI, request.ID), models.StaffAssignmentDTO{StaffID: member.ID})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		fmt.Print(string(body))
+	})
+	t.Run("DELETE/staff/{id}/shifts/{shiftID} - reassigns the requests", func(t *testing.T) {
+		request, staff := setupDependencies(t)
+		request = createSample(t, requestURI, request)
+		require.Equal(t, staff[0].ID, *request.StaffID)
+
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d/schedule?from=%s", staffURI, staff[0].ID, request.Date), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var schedule models.StaffSchedule
+		err := json.Unmarshal(body, &schedule)
+		require.NoError(t, err)
+
+		resp, _ = makeRequest(t, http.MethodDelete, fmt.Sprintf("%s/%d/shifts/%d", staffURI, staff[0].ID, schedule.Shifts[0].ID), nil)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		resp, body = makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d", requestURI, request.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var r models.ServiceRequestDTO
+		err = json.Unmarshal(body, &r)
+		require.NoError(t, err)
+		require.Equal(t, staff[1].ID, *r.StaffID)
+	})
+}
+
+func TestHousekeepingEndpoints(t *testing.T) {
+	today := time.Now().Format("2006-01-02")
+	// creates a booking and moves it so that the guest is in the room today
+	setupStay := func(t *testing.T, room models.Room, code string) models.BookingDTO {
+		booking := sampleBookingDTO
+		booking.Code = code
+		customer := sampleCustomer
+		customer.CF = code
+		booking.CustomerID = createSample(t, customerURI, customer).ID
+		booking.RoomID = createSample(t, roomURI, room).ID
+		booking = createSample(t, bookingURI, booking)
+		_, err := conn.Exec(context.Background(), "UPDATE booking SET start_date = $1::date - 1, end_date = $1::date + 1 WHERE id = $2", today, booking.ID)
+		require.NoError(t, err)
+		return booking
+	}
+	getBoard := func(t *testing.T) map[int]models.HousekeepingBoardRow {
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/housekeeping/board?date=%s", baseURI, today), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var board []models.HousekeepingBoardRow
+		err := json.Unmarshal(body, &board)
+		require.NoError(t, err)
+		rows := make(map[int]models.HousekeepingBoardRow)
+		for _, row := range board {
+			rows[row.RoomID] = row
+		}
+		return rows
+	}
+	t.Run("PUT/rooms/{id}/housekeeping", func(t *testing.T) {
+		resetDatabase(t)
+		room := createSample(t, roomURI, sampleRoom)
+		require.Equal(t, models.HousekeepingClean, getBoard(t)[room.ID].Status)
+
+		uri := fmt.Sprintf("%s/%d/housekeeping", roomURI, room.ID)
+		resp, _ := makeRequest(t, http.MethodPut, uri, models.HousekeepingStatusDTO{Status: models.HousekeepingInspected})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, models.HousekeepingInspected, getBoard(t)[room.ID].Status)
+
+		resp, body := makeRequest(t, http.MethodPut, uri, models.HousekeepingStatusDTO{Status: models.HousekeepingClean})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		fmt.Print(string(body))
+
+		resp, _ = makeRequest(t, http.MethodPut, uri, models.HousekeepingStatusDTO{Status: "sparkling"})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("POST/bookings/{id}/check-out - room becomes dirty", func(t *testing.T) {
+		resetDatabase(t)
+		booking := setupStay(t, sampleRoom, "STAY1")
+		row := getBoard(t)[booking.RoomID]
+		require.True(t, row.Occupied)
+		require.False(t, row.Departure)
+
+		checkIn := models.OccupantsDTO{Occupants: []models.OccupantDTO{sampleOccupant}}
+		resp, _ := makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/check-in", bookingURI, booking.ID), checkIn)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/check-out", bookingURI, booking.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, models.HousekeepingDirty, getBoard(t)[booking.RoomID].Status)
+	})
+	t.Run("POST/housekeeping/tasks/generate", func(t *testing.T) {
+		resetDatabase(t)
+		stayOver := setupStay(t, sampleRoom, "STAY1")
+		room := sampleRoom
+		room.Number++
+		departure := setupStay(t, room, "STAY2")
+		_, err := conn.Exec(context.Background(), "UPDATE booking SET end_date = $1 WHERE id = $2", today, departure.ID)
+		require.NoError(t, err)
+
+		member := sampleStaff
+		member.Skills = []string{"cleaning"}
+		member = createSample(t, staffURI, member)
+		createSample(t, fmt.Sprintf("%s/%d/shifts", staffURI, member.ID), models.StaffShiftDTO{Date: today, StartTime: "08:00", EndTime: "16:00"})
+
+		uri := fmt.Sprintf("%s/housekeeping/tasks/generate?date=%s", baseURI, today)
+		resp, body := makeRequest(t, http.MethodPost, uri, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var tasks []models.HousekeepingTaskDTO
+		err = json.Unmarshal(body, &tasks)
+		require.NoError(t, err)
+		require.Len(t, tasks, 2)
+		types := map[int]string{}
+		for _, task := range tasks {
+			types[task.BookingID] = task.Type
+			require.Equal(t, member.ID, *task.StaffID)
+		}
+		require.Equal(t, models.HousekeepingStayOver, types[stayOver.ID])
+		require.Equal(t, models.HousekeepingDeparture, types[departure.ID])
+
+		// generating again does not duplicate the tasks
+		resp, body = makeRequest(t, http.MethodPost, uri, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		err = json.Unmarshal(body, &tasks)
+		require.NoError(t, err)
+		require.Len(t, tasks, 2)
+
+		resp, body = makeRequest(t, http.MethodGet, fmt.Sprintf("%s/housekeeping/tasks?date=%s&staff_id=%d", baseURI, today, member.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		err = json.Unmarshal(body, &tasks)
+		require.NoError(t, err)
+		require.Len(t, tasks, 2)
+	})
+	t.Run("POST/housekeeping/tasks/{id}/complete", func(t *testing.T) {
+		resetDatabase(t)
+		booking := setupStay(t, sampleRoom, "STAY1")
+		_, err := conn.Exec(context.Background(), "UPDATE room SET housekeeping_status = 'dirty' WHERE id = $1", booking.RoomID)
+		require.NoError(t, err)
+
+		resp, body := makeRequest(t, http.MethodPost, fmt.Sprintf("%s/housekeeping/tasks/generate?date=%s", baseURI, today), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var tasks []models.HousekeepingTaskDTO
+		err = json.Unmarshal(body, &tasks)
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		require.Nil(t, tasks[0].StaffID) // nobody on shift
+
+		uri := fmt.Sprintf("%s/housekeeping/tasks/%d/complete", baseURI, tasks[0].ID)
+		resp, body = makeRequest(t, http.MethodPost, uri, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var task models.HousekeepingTaskDTO
+		err = json.Unmarshal(body, &task)
+		require.NoError(t, err)
+		require.NotEmpty(t, task.CompletedAt)
+		require.Equal(t, models.HousekeepingClean, getBoard(t)[booking.RoomID].Status)
+
+		resp, _ = makeRequest(t, http.MethodPost, uri, nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestRoomBlockEndpoints(t *testing.T) {
+	sampleBlock := models.RoomBlockDTO{
+		StartDate: sampleBookingDTO.StartDate,
+		EndDate:   sampleBookingDTO.EndDate,
+		Reason:    "broken shower",
+	}
+	t.Run("POST/rooms/{id}/blocks - booking not allowed", func(t *testing.T) {
+		resetDatabase(t)
+		booking := sampleBookingDTO
+		booking.CustomerID = createSample(t, customerURI, sampleCustomer).ID
+		booking.RoomID = createSample(t, roomURI, sampleRoom).ID
+		block := createSample(t, fmt.Sprintf("%s/%d/blocks", roomURI, booking.RoomID), sampleBlock)
+		require.Empty(t, block.Conflicts)
+
+		resp, body := makeRequest(t, http.MethodPost, bookingURI, booking)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		fmt.Print(string(body))
+
+		// overlapping blocks are rejected
+		resp, _ = makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/blocks", roomURI, booking.RoomID), sampleBlock)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		// lifting the block frees the room
+		resp, _ = makeRequest(t, http.MethodDelete, fmt.Sprintf("%s/%d/blocks/%d", roomURI, booking.RoomID, block.ID), nil)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		createSample(t, bookingURI, booking)
+	})
+	t.Run("POST/rooms/{id}/blocks - conflicts", func(t *testing.T) {
+		resetDatabase(t)
+		booking := sampleBookingDTO
+		booking.CustomerID = createSample(t, customerURI, sampleCustomer).ID
+		booking.RoomID = createSample(t, roomURI, sampleRoom).ID
+		booking = createSample(t, bookingURI, booking)
+
+		block := createSample(t, fmt.Sprintf("%s/%d/blocks", roomURI, booking.RoomID), sampleBlock)
+		require.Equal(t, []models.BookingDTO{booking}, block.Conflicts)
+
+		resp, body := makeRequest(t, http.MethodGet, baseURI+"/room-blocks/conflicts", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var conflicts []models.RoomBlockConflictDTO
+		err := json.Unmarshal(body, &conflicts)
+		require.NoError(t, err)
+		require.Len(t, conflicts, 1)
+		require.Equal(t, booking, conflicts[0].Booking)
+		require.Equal(t, block.ID, conflicts[0].Block.ID)
+
+		// cancelled bookings are not conflicts anymore
+		resp, _ = makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/cancel", bookingURI, booking.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, body = makeRequest(t, http.MethodGet, baseURI+"/room-blocks/conflicts", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		err = json.Unmarshal(body, &conflicts)
+		require.NoError(t, err)
+		require.Empty(t, conflicts)
+	})
+	t.Run("GET/rooms/available", func(t *testing.T) {
+		resetDatabase(t)
+		var rooms []models.Room
+		for i := range 3 {
+			room := sampleRoom
+			room.Number += i
+			rooms = append(rooms, createSample(t, roomURI, room))
+		}
+		booking := sampleBookingDTO
+		booking.CustomerID = createSample(t, customerURI, sampleCustomer).ID
+		booking.RoomID = rooms[0].ID
+		createSample(t, bookingURI, booking)
+		createSample(t, fmt.Sprintf("%s/%d/blocks", roomURI, rooms[1].ID), sampleBlock)
+
+		uri := fmt.Sprintf("%s/available?start_date=%s&end_date=%s", roomURI, booking.StartDate, booking.EndDate)
+		resp, body := makeRequest(t, http.MethodGet, uri, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var available []models.Room
+		err := json.Unmarshal(body, &available)
+		require.NoError(t, err)
+		require.Equal(t, []models.Room{rooms[2]}, available)
+
+		resp, body = makeRequest(t, http.MethodGet, fmt.Sprintf("%s&guests=%d", uri, sampleRoom.Capacity+1), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		err = json.Unmarshal(body, &available)
+		require.NoError(t, err)
+		require.Empty(t, available)
+
+		resp, _ = makeRequest(t, http.MethodGet, roomURI+"/available", nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestStayEndpoints(t *testing.T) {
+	// a 7 nights booking in a room at 100 and a free room at 200
+	setupDependencies := func(t *testing.T) (models.BookingDTO, models.Room) {
+		resetDatabase(t)
+		booking := sampleBookingDTO
+		booking.CustomerID = createSample(t, customerURI, sampleCustomer).ID
+		booking.RoomID = createSample(t, roomURI, sampleRoom).ID
+		suite := sampleRoom
+		suite.Number++
+		suite.Type = "suite"
+		suite.Price = 200
+		return createSample(t, bookingURI, booking), createSample(t, roomURI, suite)
+	}
+	getFolio := func(t *testing.T, bookingID int) models.FolioDTO {
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d/folio", bookingURI, bookingID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var folio models.FolioDTO
+		err := json.Unmarshal(body, &folio)
+		require.NoError(t, err)
+		return folio
+	}
+	night := func(days int) string {
+		return time.Now().AddDate(0, 0, days).Format("2006-01-02")
+	}
+	t.Run("GET/bookings/{id}/folio", func(t *testing.T) {
+		booking, _ := setupDependencies(t)
+		folio := getFolio(t, booking.ID)
+		require.Len(t, folio.Charges, 7)
+		require.Equal(t, 700, folio.Total)
+		resp, _ := makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/folio", bookingURI, booking.ID), nil)
+		require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+		// cancelling the booking drops the room charges
+		resp, _ = makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/cancel", bookingURI, booking.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		folio = getFolio(t, booking.ID)
+		require.Empty(t, folio.Charges)
+		require.Zero(t, folio.Total)
+	})
+	t.Run("POST/bookings/{id}/move", func(t *testing.T) {
+		booking, suite := setupDependencies(t)
+		move := models.RoomMoveDTO{RoomID: suite.ID, Date: night(4)}
+		resp, body := makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/move", bookingURI, booking.ID), move)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var moved models.BookingDTO
+		err := json.Unmarshal(body, &moved)
+		require.NoError(t, err)
+		require.Equal(t, suite.ID, moved.RoomID)
+
+		resp, body = makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d/segments", bookingURI, booking.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var segments []models.BookingSegmentDTO
+		err = json.Unmarshal(body, &segments)
+		require.NoError(t, err)
+		require.Equal(t, []models.BookingSegmentDTO{
+			{RoomID: booking.RoomID, StartDate: booking.StartDate, EndDate: night(4)},
+			{RoomID: suite.ID, StartDate: night(4), EndDate: booking.EndDate},
+		}, segments)
+		require.Equal(t, 3*100+4*200, getFolio(t, booking.ID).Total)
+
+		// the first room is free again from the move date
+		other := sampleBookingDTO
+		other.Code = "OTHER"
+		other.CustomerID = booking.CustomerID
+		other.RoomID = booking.RoomID
+		other.StartDate = night(4)
+		createSample(t, bookingURI, other)
+
+		// and taken before it
+		move = models.RoomMoveDTO{RoomID: booking.RoomID, Date: night(2)}
+		resp, _ = makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/move", bookingURI, booking.ID), move)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		move = models.RoomMoveDTO{RoomID: suite.ID, Date: night(5)}
+		resp, _ = makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/move", bookingURI, booking.ID), move)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("PATCH/bookings/{id} - after a move", func(t *testing.T) {
+		booking, suite := setupDependencies(t)
+		_, err := conn.Exec(context.Background(), "UPDATE room SET capacity = 4 WHERE id = $1", suite.ID)
+		require.NoError(t, err)
+		// another guest has the suite before the move date
+		other := sampleBookingDTO
+		other.Code = "OTHER"
+		other.CustomerID = booking.CustomerID
+		other.RoomID = suite.ID
+		other.EndDate = night(4)
+		createSample(t, bookingURI, other)
+		move := models.RoomMoveDTO{RoomID: suite.ID, Date: night(4)}
+		resp, _ := makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/move", bookingURI, booking.ID), move)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// the booking is checked against the rooms of its segments, not the suite for the whole stay
+		resp, body := makeRequest(t, http.MethodPatch, fmt.Sprintf("%s/%d", bookingURI, booking.ID), map[string]any{"children": 1})
+		require.Equal(t, http.StatusNoContent, resp.StatusCode, string(body))
+		resp, body = makeRequest(t, http.MethodPatch, fmt.Sprintf("%s/%d", bookingURI, booking.ID), map[string]any{"adults": 3, "children": 0})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Contains(t, string(body), "over_capacity")
+
+		resp, body = makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d/segments", bookingURI, booking.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var segments []models.BookingSegmentDTO
+		err = json.Unmarshal(body, &segments)
+		require.NoError(t, err)
+		require.Len(t, segments, 2)
+	})
+	t.Run("PUT/bookings/{id}/stay", func(t *testing.T) {
+		booking, suite := setupDependencies(t)
+		uri := fmt.Sprintf("%s/%d/stay", bookingURI, booking.ID)
+		resp, _ := makeRequest(t, http.MethodPut, uri, models.StayChangeDTO{EndDate: night(10)})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 900, getFolio(t, booking.ID).Total)
+
+		resp, _ = makeRequest(t, http.MethodPut, uri, models.StayChangeDTO{EndDate: night(3)})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 200, getFolio(t, booking.ID).Total)
+
+		// the extension must fit before the next booking of the room
+		other := sampleBookingDTO
+		other.Code = "OTHER"
+		other.CustomerID = booking.CustomerID
+		other.RoomID = booking.RoomID
+		other.StartDate = night(5)
+		createSample(t, bookingURI, other)
+		resp, _ = makeRequest(t, http.MethodPut, uri, models.StayChangeDTO{EndDate: night(6)})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		// after a move the stay is extended in the last room
+		resp, _ = makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/move", bookingURI, booking.ID), models.RoomMoveDTO{RoomID: suite.ID, Date: night(2)})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = makeRequest(t, http.MethodPut, uri, models.StayChangeDTO{EndDate: night(6)})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 100+4*200, getFolio(t, booking.ID).Total)
+
+		resp, _ = makeRequest(t, http.MethodPut, uri, models.StayChangeDTO{EndDate: booking.StartDate})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestGroupEndpoints(t *testing.T) {
+	groupURI := baseURI + "/groups"
+	// a group of three rooms, the first one with a guest and the others held for the lead customer
+	setupDependencies := func(t *testing.T) (models.GroupDTO, []models.Room, int) {
+		resetDatabase(t)
+		lead := createSample(t, customerURI, sampleCustomer).ID
+		guest := createSample(t, customerURI, sampleCustomer).ID
+		var rooms []models.Room
+		for i := range 4 {
+			room := sampleRoom
+			room.Number += i
+			rooms = append(rooms, createSample(t, roomURI, room))
+		}
+		group := models.GroupDTO{
+			Code:           "CONF2025",
+			Name:           "Conference",
+			LeadCustomerID: lead,
+			StartDate:      sampleBookingDTO.StartDate,
+			EndDate:        sampleBookingDTO.EndDate,
+			CutoffDate:     sampleBookingDTO.StartDate,
+			Rooms:          []models.GroupRoomDTO{{RoomID: rooms[0].ID, CustomerID: guest}, {RoomID: rooms[1].ID}, {RoomID: rooms[2].ID}},
+		}
+		return group, rooms, guest
+	}
+	t.Run("POST/groups", func(t *testing.T) {
+		group, rooms, guest := setupDependencies(t)
+		newGroup := createSample(t, groupURI, group)
+		require.Equal(t, models.GroupActive, newGroup.Status)
+		require.Len(t, newGroup.Rooms, 3)
+		require.Equal(t, "CONF2025-1", newGroup.Rooms[0].Code)
+		require.Equal(t, guest, newGroup.Rooms[0].CustomerID)
+		require.False(t, newGroup.Rooms[0].Allotment)
+		require.True(t, newGroup.Rooms[1].Allotment)
+
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d", groupURI, newGroup.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var fetched models.GroupDTO
+		err := json.Unmarshal(body, &fetched)
+		require.NoError(t, err)
+		require.Equal(t, newGroup, fetched)
+
+		// the rooms of a group are reserved all together or not at all
+		other := group
+		other.Code = "OTHER"
+		other.Rooms = []models.GroupRoomDTO{{RoomID: rooms[3].ID}, {RoomID: rooms[2].ID}}
+		resp, body = makeRequest(t, http.MethodPost, groupURI, other)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		fmt.Print(string(body))
+		resp, body = makeRequest(t, http.MethodGet, bookingURI, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var bookings []models.BookingDTO
+		err = json.Unmarshal(body, &bookings)
+		require.NoError(t, err)
+		require.Len(t, bookings, 3)
+
+		// a room cannot be in the group twice
+		other.Rooms = []models.GroupRoomDTO{{RoomID: rooms[3].ID}, {RoomID: rooms[3].ID}}
+		resp, _ = makeRequest(t, http.MethodPost, groupURI, other)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("POST/groups/release-allotments", func(t *testing.T) {
+		group, _, guest := setupDependencies(t)
+		newGroup := createSample(t, groupURI, group)
+
+		// naming the guest keeps the room after the cut-off date
+		uri := fmt.Sprintf("%s/%d/rooms/%d/guest", groupURI, newGroup.ID, newGroup.Rooms[1].BookingID)
+		resp, _ := makeRequest(t, http.MethodPut, uri, models.GroupGuestDTO{CustomerID: guest})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// nothing to release before the cut-off date
+		resp, body := makeRequest(t, http.MethodPost, groupURI+"/release-allotments", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var released []models.BookingDTO
+		err := json.Unmarshal(body, &released)
+		require.NoError(t, err)
+		require.Empty(t, released)
+
+		resp, body = makeRequest(t, http.MethodPost, groupURI+"/release-allotments?date="+group.CutoffDate, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		err = json.Unmarshal(body, &released)
+		require.NoError(t, err)
+		require.Len(t, released, 1)
+		require.Equal(t, newGroup.Rooms[2].BookingID, released[0].ID)
+		require.Equal(t, models.BookingCancelled, released[0].Status)
+	})
+	t.Run("POST/groups/{id}/cancel", func(t *testing.T) {
+		group, _, _ := setupDependencies(t)
+		newGroup := createSample(t, groupURI, group)
+		resp, body := makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/cancel", groupURI, newGroup.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var cancelled models.GroupDTO
+		err := json.Unmarshal(body, &cancelled)
+		require.NoError(t, err)
+		require.Equal(t, models.GroupCancelled, cancelled.Status)
+		for _, room := range cancelled.Rooms {
+			require.Equal(t, models.BookingCancelled, room.Status)
+		}
+
+		resp, _ = makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/cancel", groupURI, newGroup.ID), nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp, _ = makeRequest(t, http.MethodPost, groupURI+"/0/cancel", nil)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestWaitlistEndpoints(t *testing.T) {
+	waitlistURI := baseURI + "/waitlist"
+	getEntry := func(t *testing.T, entryID int) models.WaitlistEntryDTO {
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d", waitlistURI, entryID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var entry models.WaitlistEntryDTO
+		err := json.Unmarshal(body, &entry)
+		require.NoError(t, err)
+		return entry
+	}
+	t.Run("POST/bookings/{id}/cancel - first come first served", func(t *testing.T) {
+		resetDatabase(t)
+		booking := sampleBookingDTO
+		booking.CustomerID = createSample(t, customerURI, sampleCustomer).ID
+		booking.RoomID = createSample(t, roomURI, sampleRoom).ID
+		booking = createSample(t, bookingURI, booking)
+
+		entry := models.WaitlistEntryDTO{CustomerID: booking.CustomerID, StartDate: booking.StartDate, EndDate: booking.EndDate}
+		first := createSample(t, waitlistURI, entry)
+		require.Equal(t, models.WaitlistWaiting, first.Status)
+		require.Equal(t, 1, first.Guests)
+		second := createSample(t, waitlistURI, entry)
+
+		// nothing is available yet
+		resp, body := makeRequest(t, http.MethodPost, waitlistURI+"/match", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var offered []models.WaitlistEntryDTO
+		err := json.Unmarshal(body, &offered)
+		require.NoError(t, err)
+		require.Empty(t, offered)
+
+		resp, _ = makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/cancel", bookingURI, booking.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		first = getEntry(t, first.ID)
+		require.Equal(t, models.WaitlistOffered, first.Status)
+		require.Equal(t, booking.RoomID, *first.RoomID)
+		require.NotEmpty(t, first.OfferedAt)
+		require.Equal(t, models.WaitlistWaiting, getEntry(t, second.ID).Status)
+
+		resp, body = makeRequest(t, http.MethodGet, waitlistURI+"?status=waiting", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var waiting []models.WaitlistEntryDTO
+		err = json.Unmarshal(body, &waiting)
+		require.NoError(t, err)
+		require.Equal(t, []models.WaitlistEntryDTO{second}, waiting)
+
+		// the first customer lets the offer go, the room goes to the next one
+		resp, _ = makeRequest(t, http.MethodDelete, fmt.Sprintf("%s/holds/%d", baseURI, *first.HoldID), nil)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		require.Equal(t, models.WaitlistExpired, getEntry(t, first.ID).Status)
+		require.Equal(t, models.WaitlistOffered, getEntry(t, second.ID).Status)
+	})
+	t.Run("POST/bookings - with the hold of an offer", func(t *testing.T) {
+		resetDatabase(t)
+		booking := sampleBookingDTO
+		booking.CustomerID = createSample(t, customerURI, sampleCustomer).ID
+		booking.RoomID = createSample(t, roomURI, sampleRoom).ID
+		booking = createSample(t, bookingURI, booking)
+		entry := models.WaitlistEntryDTO{CustomerID: booking.CustomerID, StartDate: booking.StartDate, EndDate: booking.EndDate}
+		entry = createSample(t, waitlistURI, entry)
+		resp, _ := makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/cancel", bookingURI, booking.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		entry = getEntry(t, entry.ID)
+		require.Equal(t, models.WaitlistOffered, entry.Status)
+
+		// the customer books the offered room, the offer is over and the hold gone
+		offer := sampleBookingDTO
+		offer.Code = "OFFERED"
+		offer.CustomerID = entry.CustomerID
+		offer.RoomID = *entry.RoomID
+		offer.HoldID = *entry.HoldID
+		offer = createSample(t, bookingURI, offer)
+		require.Equal(t, models.WaitlistBooked, getEntry(t, entry.ID).Status)
+		resp, _ = makeRequest(t, http.MethodGet, fmt.Sprintf("%s/holds/%d", baseURI, *entry.HoldID), nil)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d/folio", bookingURI, offer.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var folio models.FolioDTO
+		err := json.Unmarshal(body, &folio)
+		require.NoError(t, err)
+		require.Len(t, folio.Charges, 7)
+
+		// a retry does not book the room twice
+		offer.ID = 0
+		offer.Code = "RETRY"
+		resp, body = makeRequest(t, http.MethodPost, bookingURI, offer)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Contains(t, string(body), "hold_expired")
+
+		resp, body = makeRequest(t, http.MethodGet, waitlistURI+"?status=booked", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var booked []models.WaitlistEntryDTO
+		err = json.Unmarshal(body, &booked)
+		require.NoError(t, err)
+		require.Len(t, booked, 1)
 	})
-	t.Run("PUT/service-requests/{id} - update", func(t *testing.T) {
-		request := setupDependencies(t)
-		request = createSample(t, requestURI, request)
-		request.Date = time.Now().AddDate(0, 0, 4).Format("2006-01-02")
+	t.Run("DELETE/rooms/{id}/blocks/{blockID} - room type", func(t *testing.T) {
+		resetDatabase(t)
+		customerID := createSample(t, customerURI, sampleCustomer).ID
+		basic := createSample(t, roomURI, sampleRoom)
+		suite := sampleRoom
+		suite.Number++
+		suite.Type = "suite"
+		suite = createSample(t, roomURI, suite)
+		block := models.RoomBlockDTO{StartDate: sampleBookingDTO.StartDate, EndDate: sampleBookingDTO.EndDate, Reason: "new floor"}
+		block = createSample(t, fmt.Sprintf("%s/%d/blocks", roomURI, suite.ID), block)
+
+		// the basic room is free but a suite is wanted
+		entry := createSample(t, waitlistURI, models.WaitlistEntryDTO{CustomerID: customerID, RoomType: "suite", StartDate: block.StartDate, EndDate: block.EndDate, Guests: 2})
+		resp, _ := makeRequest(t, http.MethodPost, waitlistURI+"/match", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, models.WaitlistWaiting, getEntry(t, entry.ID).Status)
 
-		resp, body := makeRequest(t, http.MethodPut, fmt.Sprintf("%s/%d", requestURI, request.ID), request)
+		resp, _ = makeRequest(t, http.MethodDelete, fmt.Sprintf("%s/%d/blocks/%d", roomURI, suite.ID, block.ID), nil)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		entry = getEntry(t, entry.ID)
+		require.Equal(t, models.WaitlistOffered, entry.Status)
+		require.Equal(t, suite.ID, *entry.RoomID)
+		require.NotEqual(t, basic.ID, *entry.RoomID)
+
+		resp, _ = makeRequest(t, http.MethodDelete, fmt.Sprintf("%s/%d", waitlistURI, entry.ID), nil)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		resp, _ = makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d", waitlistURI, entry.ID), nil)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+	t.Run("POST/waitlist - invalid", func(t *testing.T) {
+		resetDatabase(t)
+		entry := models.WaitlistEntryDTO{CustomerID: -1, StartDate: sampleBookingDTO.StartDate, EndDate: sampleBookingDTO.EndDate}
+		resp, _ := makeRequest(t, http.MethodPost, waitlistURI, entry)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		entry.CustomerID = createSample(t, customerURI, sampleCustomer).ID
+		entry.StartDate, entry.EndDate = entry.EndDate, entry.StartDate
+		resp, _ = makeRequest(t, http.MethodPost, waitlistURI, entry)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestHoldEndpoints(t *testing.T) {
+	holdURI := baseURI + "/holds"
+	setupDependencies := func(t *testing.T) (models.BookingDTO, models.HoldDTO) {
+		resetDatabase(t)
+		booking := sampleBookingDTO
+		booking.CustomerID = createSample(t, customerURI, sampleCustomer).ID
+		booking.RoomID = createSample(t, roomURI, sampleRoom).ID
+		hold := models.HoldDTO{RoomID: booking.RoomID, StartDate: booking.StartDate, EndDate: booking.EndDate}
+		return booking, hold
+	}
+	available := func(t *testing.T, booking models.BookingDTO) []models.Room {
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/available?start_date=%s&end_date=%s", roomURI, booking.StartDate, booking.EndDate), nil)
 		require.Equal(t, http.StatusOK, resp.StatusCode)
-		var r models.ServiceRequestDTO
-		err := json.Unmarshal(body, &r)
+		var rooms []models.Room
+		err := json.Unmarshal(body, &rooms)
 		require.NoError(t, err)
-		require.Equal(t, request, r)
+		return rooms
+	}
+	t.Run("POST/holds and booking", func(t *testing.T) {
+		booking, hold := setupDependencies(t)
+		hold = createSample(t, holdURI, hold)
+		require.NotEmpty(t, hold.ExpiresAt)
+		require.Empty(t, available(t, booking))
+
+		// the room cannot be held or booked by someone else
+		resp, _ := makeRequest(t, http.MethodPost, holdURI, hold)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp, body := makeRequest(t, http.MethodPost, bookingURI, booking)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		fmt.Print(string(body))
+
+		// the booking must match the hold
+		booking.HoldID = hold.ID
+		other := booking
+		other.EndDate = time.Now().AddDate(0, 0, 5).Format("2006-01-02")
+		resp, _ = makeRequest(t, http.MethodPost, bookingURI, other)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		newBooking := createSample(t, bookingURI, booking)
+		require.Equal(t, booking.RoomID, newBooking.RoomID)
+		resp, _ = makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d", holdURI, hold.ID), nil)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
 	})
-	t.Run("PUT/service-requests/{id} - create", func(t *testing.T) {
-		request := setupDependencies(t)
-		resp, _ := makeRequest(t, http.MethodPut, fmt.Sprintf("%s/%d", requestURI, request.ID), request)
-		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	t.Run("DELETE/holds/{id}", func(t *testing.T) {
+		booking, hold := setupDependencies(t)
+		hold = createSample(t, holdURI, hold)
+		resp, _ := makeRequest(t, http.MethodDelete, fmt.Sprintf("%s/%d", holdURI, hold.ID), nil)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		require.Len(t, available(t, booking), 1)
+		createSample(t, bookingURI, booking)
 	})
-	t.Run("PATCH/service-requests/{id}", func(t *testing.T) {
-		request := setupDependencies(t)
-		request = createSample(t, requestURI, request)
+	t.Run("expired holds", func(t *testing.T) {
+		booking, hold := setupDependencies(t)
+		hold.TTL = 60
+		hold = createSample(t, holdURI, hold)
+		_, err := conn.Exec(context.Background(), "UPDATE booking_hold SET expires_at = now() - interval '1 second' WHERE id = $1", hold.ID)
+		require.NoError(t, err)
 
-		patch := map[string]any{"date": time.Now().AddDate(0, 0, 4).Format("2006-01-02")}
-		resp, _ := makeRequest(t, http.MethodPatch, fmt.Sprintf("%s/%d", requestURI, request.ID), patch)
-		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		// an expired hold does not count even before the worker removes it
+		require.Len(t, available(t, booking), 1)
+		booking.HoldID = hold.ID
+		resp, _ := makeRequest(t, http.MethodPost, bookingURI, booking)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
 
-		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d", requestURI, request.ID), nil)
+		expired, err := services.ExpireHolds(context.Background(), conn)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), expired)
+		resp, body := makeRequest(t, http.MethodGet, holdURI, nil)
 		require.Equal(t, http.StatusOK, resp.StatusCode)
-		var r models.ServiceRequestDTO
-		err := json.Unmarshal(body, &r)
+		var holds []models.HoldDTO
+		err = json.Unmarshal(body, &holds)
 		require.NoError(t, err)
-		require.Equal(t, time.Now().AddDate(0, 0, 4).Format("2006-01-02"), r.Date)
+		require.Empty(t, holds)
 	})
-	t.Run("DELETE/service-requests/{id}", func(t *testing.T) {
-		request := setupDependencies(t)
-		request = createSample(t, requestURI, request)
+	t.Run("expired holds - clock of the services", func(t *testing.T) {
+		booking, hold := setupDependencies(t)
+		hold.TTL = 60
+		hold = createSample(t, holdURI, hold)
+		require.Empty(t, available(t, booking))
+
+		// the holds expire by the clock of the services, not by the one of the database
+		services.SetClock(services.ClockFunc(func() time.Time { return time.Now().Add(2 * time.Minute) }))
+		t.Cleanup(func() { services.SetClock(nil) })
+		require.Len(t, available(t, booking), 1)
+		resp, _ := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d", holdURI, hold.ID), nil)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
 
-		resp, _ := makeRequest(t, http.MethodDelete, fmt.Sprintf("%s/%d", requestURI, request.ID), nil)
-		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+func TestReportEndpoints(t *testing.T) {
+	// one room fully booked from tomorrow for 7 nights
+	setupDependencies := func(t *testing.T) (string, string) {
+		resetDatabase(t)
+		booking := sampleBookingDTO
+		booking.CustomerID = createSample(t, customerURI, sampleCustomer).ID
+		booking.RoomID = createSample(t, roomURI, sampleRoom).ID
+		createSample(t, bookingURI, booking)
+		lastNight, err := time.Parse("2006-01-02", booking.EndDate)
+		require.NoError(t, err)
+		return booking.StartDate, lastNight.AddDate(0, 0, -1).Format("2006-01-02")
+	}
+	t.Run("GET/reports/occupancy", func(t *testing.T) {
+		start, end := setupDependencies(t)
 
-		resp, _ = makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d", requestURI, request.ID), nil)
-		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/occupancy?start_date=%s&end_date=%s", reportURI, start, end), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		var rows []models.OccupancyReportRow
+		err := json.Unmarshal(body, &rows)
+		require.NoError(t, err)
+		require.Len(t, rows, 7)
+		for _, row := range rows {
+			require.Equal(t, "all", row.RoomType)
+			require.Equal(t, 100.0, row.Occupancy)
+		}
+	})
+	t.Run("GET/reports/adr", func(t *testing.T) {
+		start, end := setupDependencies(t)
+
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/adr?start_date=%s&end_date=%s&group_by=month&by=room_type", reportURI, start, end), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		var rows []models.ADRReportRow
+		err := json.Unmarshal(body, &rows)
+		require.NoError(t, err)
+		sold := 0
+		for _, row := range rows {
+			require.Equal(t, sampleRoom.Type, row.RoomType)
+			require.Equal(t, float64(sampleRoom.Price), row.ADR)
+			sold += row.RoomsSold
+		}
+		require.Equal(t, 7, sold)
+	})
+	t.Run("GET/reports/revpar - csv", func(t *testing.T) {
+		start, end := setupDependencies(t)
+
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/revpar?start_date=%s&end_date=%s&group_by=week&format=csv", reportURI, start, end), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		require.Equal(t, "text/csv", resp.Header.Get("Content-Type"))
+		require.True(t, bytes.HasPrefix(body, []byte("period,room_type,rooms_available,room_revenue,revpar")), string(body))
+	})
+	t.Run("GET/reports/occupancy - invalid parameters", func(t *testing.T) {
+		start, end := setupDependencies(t)
+
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/occupancy?start_date=%s&end_date=%s&group_by=year", reportURI, start, end), nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		fmt.Print(string(body))
+		resp, body = makeRequest(t, http.MethodGet, fmt.Sprintf("%s/occupancy?start_date=%s&end_date=%s", reportURI, end, start), nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		fmt.Print(string(body))
 	})
 }
 
@@ -890,3 +2365,631 @@ func TestServiceRequestEndpoints(t *testing.T) {
 // 		})
 // 	}
 // }
+
+func TestNotificationEndpoints(t *testing.T) {
+	setupDependencies := func(t *testing.T, language string) models.BookingDTO {
+		resetDatabase(t)
+		customer := sampleCustomer
+		customer.Language = language
+		booking := sampleBookingDTO
+		booking.CustomerID = createSample(t, customerURI, customer).ID
+		booking.RoomID = createSample(t, roomURI, sampleRoom).ID
+		return booking
+	}
+	getNotifications := func(t *testing.T, bookingID int) []models.NotificationDTO {
+		resp, body := makeRequest(t, http.MethodGet, fmt.Sprintf("%s/notifications?booking_id=%d", baseURI, bookingID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var notifications []models.NotificationDTO
+		err := json.Unmarshal(body, &notifications)
+		require.NoError(t, err)
+		return notifications
+	}
+	// the emails are sent by the send-notifications job
+	sendNotifications := func(t *testing.T) {
+		resp, body := makeRequest(t, http.MethodPost, baseURI+"/jobs/send-notifications/run", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	}
+	t.Run("booking confirmation and cancellation", func(t *testing.T) {
+		booking := createSample(t, bookingURI, setupDependencies(t, ""))
+		notifications := getNotifications(t, booking.ID)
+		require.Len(t, notifications, 1)
+		require.Equal(t, models.NotificationPending, notifications[0].Status)
+		require.Empty(t, notifications[0].SentAt)
+		require.Empty(t, mailbox.Sent())
+
+		sendNotifications(t)
+		emails := mailbox.Sent()
+		require.Len(t, emails, 1)
+		require.Equal(t, sampleCustomer.Email, emails[0].To)
+		require.Equal(t, "Your booking TESTBOOK123 is confirmed", emails[0].Subject)
+		require.Contains(t, emails[0].Body, "Dear Testino")
+
+		resp, _ := makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/cancel", bookingURI, booking.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		sendNotifications(t)
+		require.Len(t, mailbox.Sent(), 2)
+
+		notifications = getNotifications(t, booking.ID)
+		require.Len(t, notifications, 2)
+		require.Equal(t, models.NotificationCancellation, notifications[0].Kind)
+		require.Equal(t, models.NotificationConfirmation, notifications[1].Kind)
+		require.Equal(t, models.NotificationSent, notifications[0].Status)
+		require.Equal(t, "en", notifications[0].Language)
+		require.NotEmpty(t, notifications[0].SentAt)
+
+		// the notifications are sent once
+		sendNotifications(t)
+		require.Len(t, mailbox.Sent(), 2)
+	})
+	t.Run("language of the customer", func(t *testing.T) {
+		createSample(t, bookingURI, setupDependencies(t, "it"))
+		sendNotifications(t)
+
+		emails := mailbox.Sent()
+		require.Len(t, emails, 1)
+		require.Equal(t, "La prenotazione TESTBOOK123 è confermata", emails[0].Subject)
+		require.Contains(t, emails[0].Body, "Gentile Testino")
+	})
+	t.Run("POST/notifications/reminders", func(t *testing.T) {
+		booking := createSample(t, bookingURI, setupDependencies(t, ""))
+		sendNotifications(t)
+		mailbox.Reset()
+
+		uri := fmt.Sprintf("%s/notifications/reminders?date=%s", baseURI, booking.StartDate)
+		resp, body := makeRequest(t, http.MethodPost, uri, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var reminded []models.BookingDTO
+		err := json.Unmarshal(body, &reminded)
+		require.NoError(t, err)
+		require.Len(t, reminded, 1)
+		sendNotifications(t)
+		require.Equal(t, "See you on "+booking.StartDate, mailbox.Sent()[0].Subject)
+
+		// the reminder is sent once
+		resp, body = makeRequest(t, http.MethodPost, uri, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		err = json.Unmarshal(body, &reminded)
+		require.NoError(t, err)
+		require.Empty(t, reminded)
+		sendNotifications(t)
+		require.Len(t, mailbox.Sent(), 1)
+	})
+	t.Run("review invitation at check-out", func(t *testing.T) {
+		booking := createSample(t, bookingURI, setupDependencies(t, ""))
+		_, err := conn.Exec(context.Background(), "UPDATE booking SET status = 'checked_in' WHERE id = $1", booking.ID)
+		require.NoError(t, err)
+
+		resp, _ := makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/check-out", bookingURI, booking.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		notifications := getNotifications(t, booking.ID)
+		require.Equal(t, models.NotificationReviewInvitation, notifications[0].Kind)
+	})
+}
+
+func TestSMTPSenderTimeout(t *testing.T) {
+	// a server that accepts the connection and never answers
+	listener, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	require.NoError(t, err)
+	defer listener.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, err := listener.Accept()
+		if err == nil {
+			accepted <- c
+		}
+	}()
+
+	sender := &services.SMTPSender{Addr: listener.Addr().String(), From: "hotel@example.com", Timeout: 200 * time.Millisecond}
+	start := time.Now()
+	err = sender.Send(context.Background(), models.Email{To: "guest@example.com", Subject: "Hi", Body: "Hi"})
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 2*time.Second)
+	select {
+	case c := <-accepted:
+		c.Close()
+	default:
+	}
+}
+
+func TestJobEndpoints(t *testing.T) {
+	jobURI := baseURI + "/jobs"
+	getJob := func(t *testing.T, name string) models.JobDTO {
+		resp, body := makeRequest(t, http.MethodGet, jobURI, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var jobs []models.JobDTO
+		err := json.Unmarshal(body, &jobs)
+		require.NoError(t, err)
+		for _, job := range jobs {
+			if job.Name == name {
+				return job
+			}
+		}
+		require.FailNow(t, "job not found", name)
+		return models.JobDTO{}
+	}
+	t.Run("GET/jobs", func(t *testing.T) {
+		resetDatabase(t)
+		job := getJob(t, "expire-holds")
+		require.Equal(t, "@every 1m", job.Schedule)
+		require.False(t, job.Paused)
+		require.Nil(t, job.LastRun)
+	})
+	t.Run("POST/jobs/{name}/run", func(t *testing.T) {
+		resetDatabase(t)
+		booking := sampleBookingDTO
+		hold := models.HoldDTO{
+			RoomID:    createSample(t, roomURI, sampleRoom).ID,
+			StartDate: booking.StartDate,
+			EndDate:   booking.EndDate,
+		}
+		hold = createSample(t, baseURI+"/holds", hold)
+		_, err := conn.Exec(context.Background(), "UPDATE booking_hold SET expires_at = now() - interval '1 second' WHERE id = $1", hold.ID)
+		require.NoError(t, err)
+
+		resp, body := makeRequest(t, http.MethodPost, jobURI+"/expire-holds/run", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var run models.JobRunDTO
+		err = json.Unmarshal(body, &run)
+		require.NoError(t, err)
+		require.Equal(t, models.JobRunSucceeded, run.Status)
+		require.Equal(t, models.JobTriggerManual, run.Trigger)
+		require.Equal(t, "1 holds expired", run.Result)
+
+		resp, body = makeRequest(t, http.MethodGet, jobURI+"/expire-holds/runs", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var runs []models.JobRunDTO
+		err = json.Unmarshal(body, &runs)
+		require.NoError(t, err)
+		require.Equal(t, []models.JobRunDTO{run}, runs)
+		require.Equal(t, run, *getJob(t, "expire-holds").LastRun)
+	})
+	t.Run("POST/jobs/{name}/run - already running", func(t *testing.T) {
+		resetDatabase(t)
+		// another replica runs the job
+		locked, err := dal.TryLockJob(context.Background(), conn, "expire-holds")
+		require.NoError(t, err)
+		require.True(t, locked)
+		resp, body := makeRequest(t, http.MethodPost, jobURI+"/expire-holds/run", nil)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Contains(t, string(body), "already running")
+		err = dal.UnlockJob(context.Background(), conn, "expire-holds")
+		require.NoError(t, err)
+
+		// the run releases the lock when it is over
+		resp, _ = makeRequest(t, http.MethodPost, jobURI+"/expire-holds/run", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		locked, err = dal.TryLockJob(context.Background(), conn, "expire-holds")
+		require.NoError(t, err)
+		require.True(t, locked)
+		err = dal.UnlockJob(context.Background(), conn, "expire-holds")
+		require.NoError(t, err)
+	})
+	t.Run("POST/jobs/{name}/pause", func(t *testing.T) {
+		resetDatabase(t)
+		resp, body := makeRequest(t, http.MethodPost, jobURI+"/arrival-reminders/pause", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var job models.JobDTO
+		err := json.Unmarshal(body, &job)
+		require.NoError(t, err)
+		require.True(t, job.Paused)
+		require.True(t, getJob(t, "arrival-reminders").Paused)
+
+		resp, _ = makeRequest(t, http.MethodPost, jobURI+"/arrival-reminders/resume", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.False(t, getJob(t, "arrival-reminders").Paused)
+	})
+	t.Run("unknown job", func(t *testing.T) {
+		resetDatabase(t)
+		resp, _ := makeRequest(t, http.MethodPost, jobURI+"/unknown/run", nil)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp, _ = makeRequest(t, http.MethodPost, jobURI+"/unknown/pause", nil)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestNightAuditEndpoints(t *testing.T) {
+	today := time.Now().Format("2006-01-02")
+	getBusinessDate := func(t *testing.T) string {
+		resp, body := makeRequest(t, http.MethodGet, baseURI+"/business-date", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var date models.BusinessDateDTO
+		err := json.Unmarshal(body, &date)
+		require.NoError(t, err)
+		return date.BusinessDate
+	}
+	runAudit := func(t *testing.T) models.JobRunDTO {
+		resp, body := makeRequest(t, http.MethodPost, baseURI+"/jobs/night-audit/run", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var run models.JobRunDTO
+		err := json.Unmarshal(body, &run)
+		require.NoError(t, err)
+		return run
+	}
+	t.Run("POST/jobs/night-audit/run", func(t *testing.T) {
+		resetDatabase(t)
+		require.Equal(t, today, getBusinessDate(t))
+		customerID := createSample(t, customerURI, sampleCustomer).ID
+		room := sampleRoom
+		noShow := sampleBookingDTO
+		noShow.Code = "NOSHOW1"
+		noShow.CustomerID = customerID
+		noShow.RoomID = createSample(t, roomURI, room).ID
+		noShow.StartDate = today
+		noShow = createSample(t, bookingURI, noShow)
+		room.Number++
+		inHouse := noShow
+		inHouse.ID = 0
+		inHouse.Code = "INHOUSE1"
+		inHouse.RoomID = createSample(t, roomURI, room).ID
+		inHouse.Status = ""
+		inHouse = createSample(t, bookingURI, inHouse)
+		checkIn := models.OccupantsDTO{Occupants: []models.OccupantDTO{sampleOccupant}}
+		resp, _ := makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/check-in", bookingURI, inHouse.ID), checkIn)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		run := runAudit(t)
+		require.Equal(t, models.JobRunSucceeded, run.Status, run.Error)
+
+		resp, body := makeRequest(t, http.MethodGet, baseURI+"/night-audits/"+today, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var audit models.NightAuditDTO
+		err := json.Unmarshal(body, &audit)
+		require.NoError(t, err)
+		require.Equal(t, 1, audit.Arrivals)
+		require.Equal(t, 1, audit.InHouse)
+		require.Equal(t, 1, audit.NoShows)
+		require.Equal(t, 1, audit.ChargesPosted)
+		require.Equal(t, 1, audit.RoomsOccupied)
+		require.Equal(t, 2, audit.RoomsTotal)
+		require.Equal(t, 50.0, audit.Occupancy)
+		require.Equal(t, sampleRoom.Price, audit.RoomRevenue)
+
+		// the booking that did not arrive has no charges, the night of the guest in house is final
+		resp, body = makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d", bookingURI, noShow.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var b models.BookingDTO
+		err = json.Unmarshal(body, &b)
+		require.NoError(t, err)
+		require.Equal(t, models.BookingNoShow, b.Status)
+		resp, body = makeRequest(t, http.MethodGet, fmt.Sprintf("%s/%d/folio", bookingURI, inHouse.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var folio models.FolioDTO
+		err = json.Unmarshal(body, &folio)
+		require.NoError(t, err)
+		require.True(t, folio.Charges[0].Posted)
+		require.False(t, folio.Charges[1].Posted)
+
+		// the day is closed, bookings start from the next one
+		tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+		require.Equal(t, tomorrow, getBusinessDate(t))
+		late := sampleBookingDTO
+		late.Code = "LATE1"
+		late.CustomerID = customerID
+		late.RoomID = noShow.RoomID
+		late.StartDate = today
+		resp, _ = makeRequest(t, http.MethodPost, bookingURI, late)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp, body = makeRequest(t, http.MethodGet, baseURI+"/night-audits", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var audits []models.NightAuditDTO
+		err = json.Unmarshal(body, &audits)
+		require.NoError(t, err)
+		require.Equal(t, []models.NightAuditDTO{audit}, audits)
+	})
+	t.Run("business day not started", func(t *testing.T) {
+		resetDatabase(t)
+		require.Equal(t, models.JobRunSucceeded, runAudit(t).Status)
+		run := runAudit(t)
+		require.Equal(t, models.JobRunFailed, run.Status)
+		require.Contains(t, run.Error, "business_day_not_started")
+	})
+}
+
+func TestHotelTimezone(t *testing.T) {
+	location, err := time.LoadLocation("Pacific/Kiritimati") // UTC+14
+	require.NoError(t, err)
+	services.SetLocation(location)
+	services.SetClock(services.ClockFunc(func() time.Time {
+		return time.Date(2030, 6, 15, 23, 30, 0, 0, time.UTC)
+	}))
+	t.Cleanup(func() {
+		services.SetLocation(time.Local)
+		services.SetClock(nil)
+	})
+
+	t.Run("today in the hotel", func(t *testing.T) {
+		resetDatabase(t)
+		resp, body := makeRequest(t, http.MethodGet, baseURI+"/business-date", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var date models.BusinessDateDTO
+		err := json.Unmarshal(body, &date)
+		require.NoError(t, err)
+		require.Equal(t, "2030-06-16", date.BusinessDate)
+	})
+	t.Run("bookings start today", func(t *testing.T) {
+		resetDatabase(t)
+		booking := sampleBookingDTO
+		booking.CustomerID = createSample(t, customerURI, sampleCustomer).ID
+		booking.RoomID = createSample(t, roomURI, sampleRoom).ID
+		booking.StartDate = "2030-06-15"
+		booking.EndDate = "2030-06-18"
+		resp, _ := makeRequest(t, http.MethodPost, bookingURI, booking)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		booking.StartDate = "2030-06-16"
+		booking = createSample(t, bookingURI, booking)
+		checkIn := models.OccupantsDTO{Occupants: []models.OccupantDTO{sampleOccupant}}
+		resp, _ = makeRequest(t, http.MethodPost, fmt.Sprintf("%s/%d/check-in", bookingURI, booking.ID), checkIn)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	for _, path := range []string{"/healthz", "/readyz"} {
+		t.Run("GET"+path, func(t *testing.T) {
+			resp, body := makeRequest(t, http.MethodGet, baseURI+path, nil)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			var health models.HealthDTO
+			err := json.Unmarshal(body, &health)
+			require.NoError(t, err)
+			require.Equal(t, models.HealthOK, health.Status)
+		})
+	}
+	t.Run("not ready while shutting down", func(t *testing.T) {
+		shuttingDown.Store(true)
+		t.Cleanup(func() { shuttingDown.Store(false) })
+		resp, body := makeRequest(t, http.MethodGet, baseURI+"/readyz", nil)
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		var health models.HealthDTO
+		err := json.Unmarshal(body, &health)
+		require.NoError(t, err)
+		require.Equal(t, models.HealthShuttingDown, health.Status)
+
+		resp, _ = makeRequest(t, http.MethodGet, baseURI+"/healthz", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestConfig(t *testing.T) {
+	dir := t.TempDir()
+	file := dir + "/hotel.yaml"
+	err := os.WriteFile(file, []byte("server:\n  port: 9000\n  read_timeout: 5s\nhotel:\n  timezone: Europe/Rome\nreviews:\n  banned_words: [spam]\n"), 0o600)
+	require.NoError(t, err)
+	secret := dir + "/db_password"
+	err = os.WriteFile(secret, []byte("s3cret\n"), 0o600)
+	require.NoError(t, err)
+	t.Setenv("DB_PASSWORD", "")
+	t.Setenv("DB_PASSWORD_FILE", secret)
+	t.Setenv("DB_NAME", "hotel")
+	t.Setenv("PORT", "")
+	t.Setenv("REVIEW_WINDOW_DAYS", "10")
+
+	t.Run("precedence", func(t *testing.T) {
+		cfg, err := config.Load([]string{"-config", file})
+		require.NoError(t, err)
+		require.Equal(t, 9000, cfg.Server.Port)
+		require.Equal(t, 5*time.Second, cfg.Server.ReadTimeout)
+		require.Equal(t, 2*time.Minute, cfg.Server.WriteTimeout)
+		require.Equal(t, "Europe/Rome", cfg.Hotel.Timezone)
+		require.Equal(t, []string{"spam"}, cfg.Reviews.BannedWords)
+		require.Equal(t, 10, cfg.Reviews.WindowDays)
+		require.Equal(t, "s3cret", cfg.Database.Password)
+
+		t.Setenv("PORT", "9001")
+		cfg, err = config.Load([]string{"-config", file})
+		require.NoError(t, err)
+		require.Equal(t, 9001, cfg.Server.Port)
+		cfg, err = config.Load([]string{"-config", file, "-port", "9002"})
+		require.NoError(t, err)
+		require.Equal(t, 9002, cfg.Server.Port)
+	})
+	t.Run("print redacts secrets", func(t *testing.T) {
+		cfg, err := config.Load(nil)
+		require.NoError(t, err)
+		var out bytes.Buffer
+		err = cfg.Print(&out)
+		require.NoError(t, err)
+		require.Contains(t, out.String(), "password: '********'")
+		require.NotContains(t, out.String(), "s3cret")
+		require.Contains(t, out.String(), "read_timeout: 15s")
+		require.Equal(t, "s3cret", cfg.Database.Password)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := config.Load([]string{"-port", "0", "-timezone", "Mars/Olympus", "-tls-cert", "cert.pem"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "server.port")
+		require.Contains(t, err.Error(), "hotel.timezone")
+		require.Contains(t, err.Error(), "server.tls")
+
+		t.Setenv("DB_PASSWORD", "plain")
+		_, err = config.Load(nil)
+		require.ErrorContains(t, err, "both DB_PASSWORD and DB_PASSWORD_FILE are set")
+	})
+}
+
+// logBuffer collects the logs written by the server goroutines
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// request returns the log of a request, which is written once the response is sent
+func (b *logBuffer) request(t *testing.T, requestID string) map[string]any {
+	var entry map[string]any
+	require.Eventually(t, func() bool {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for _, line := range strings.Split(b.buf.String(), "\n") {
+			entry = nil
+			if json.Unmarshal([]byte(line), &entry) == nil && entry["msg"] == "Request" && entry["request_id"] == requestID {
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+	return entry
+}
+
+func TestRequestLogging(t *testing.T) {
+	logs := &logBuffer{}
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	t.Run("request ID from the client", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, roomURI+"/999", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Request-ID", "test-request-1")
+		req.Header.Set("X-Actor", "reception")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, "test-request-1", resp.Header.Get("X-Request-ID"))
+
+		entry := logs.request(t, "test-request-1")
+		require.Equal(t, "GET /rooms/{id}", entry["route"])
+		require.Equal(t, float64(resp.StatusCode), entry["status"])
+		require.Equal(t, "reception", entry["actor"])
+		require.Contains(t, entry, "latency")
+	})
+	t.Run("generated request ID", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, baseURI+"/healthz", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Request-ID", "not valid")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		id := resp.Header.Get("X-Request-ID")
+		require.Regexp(t, "^[0-9a-f]{32}$", id)
+		require.Equal(t, "GET /healthz", logs.request(t, id)["route"])
+	})
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	resetDatabase(t)
+	booking := sampleBookingDTO
+	booking.CustomerID = createSample(t, customerURI, sampleCustomer).ID
+	booking.RoomID = createSample(t, roomURI, sampleRoom).ID
+	booking.StartDate = time.Now().Format("2006-01-02")
+	createSample(t, bookingURI, booking)
+	resp, _ := makeRequest(t, http.MethodGet, roomURI, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, body := makeRequest(t, http.MethodGet, baseURI+"/metrics", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	metrics := string(body)
+	require.Contains(t, metrics, `hotel_http_requests_total{method="GET",route="GET /rooms",status="200"}`)
+	require.Contains(t, metrics, `hotel_http_request_duration_seconds_count{method="GET",route="GET /rooms"}`)
+	require.Contains(t, metrics, `hotel_db_query_duration_seconds_count{function="GetAllRooms"}`)
+	require.Contains(t, metrics, `hotel_db_queries_total{connection="other"}`)
+	require.Contains(t, metrics, "hotel_stats_up 1")
+	require.Contains(t, metrics, "hotel_arrivals_today 1")
+	require.Contains(t, metrics, "hotel_in_house_guests 0")
+	require.Contains(t, metrics, "go_goroutines")
+}
+
+func TestTracing(t *testing.T) {
+	resetDatabase(t)
+	room := createSample(t, roomURI, sampleRoom)
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	// the span of the request continues the trace of the client
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/%d", roomURI, room.ID), nil)
+	require.NoError(t, err)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	require.Eventually(t, func() bool {
+		for _, span := range recorder.Ended() {
+			spans[span.Name()] = span
+		}
+		return spans["GET /rooms/{id}"] != nil
+	}, time.Second, 10*time.Millisecond)
+	server := spans["GET /rooms/{id}"]
+	require.Equal(t, trace.SpanKindServer, server.SpanKind())
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+
+	service := spans["services.GetRoomByID"]
+	require.NotNil(t, service)
+	require.Equal(t, server.SpanContext().SpanID(), service.Parent().SpanID())
+	query := spans["dal.GetRoomByID"]
+	require.NotNil(t, query)
+	require.Equal(t, service.SpanContext().SpanID(), query.Parent().SpanID())
+	require.Equal(t, trace.SpanKindClient, query.SpanKind())
+	attributes := map[string]string{}
+	for _, attribute := range query.Attributes() {
+		attributes[string(attribute.Key)] = attribute.Value.Emit()
+	}
+	require.Equal(t, "postgresql", attributes["db.system.name"])
+	require.Equal(t, "SELECT", attributes["db.operation.name"])
+}
+
+func TestRateLimit(t *testing.T) {
+	resetDatabase(t)
+	server := httptest.NewServer(newHandler(conn, monitorConn, validator.New(), config.RateLimitConfig{
+		PublicRate: 0.01, PublicBurst: 2,
+		ReadRate: 0.01, ReadBurst: 1,
+		APIKeys: []string{"reception-key"},
+	}))
+	defer server.Close()
+
+	t.Run("public routes", func(t *testing.T) {
+		for range 2 {
+			resp, _ := makeRequest(t, http.MethodPost, server.URL+"/customers", sampleCustomer)
+			require.NotEqual(t, http.StatusTooManyRequests, resp.StatusCode)
+		}
+		resp, _ := makeRequest(t, http.MethodPost, server.URL+"/customers", sampleCustomer)
+		require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		require.Equal(t, "100", resp.Header.Get("Retry-After"))
+	})
+	t.Run("clients with an API key", func(t *testing.T) {
+		resp, _ := makeRequest(t, http.MethodGet, server.URL+"/rooms", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, _ = makeRequest(t, http.MethodGet, server.URL+"/rooms", nil)
+		require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+		get := func(key string) int {
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/rooms", nil)
+			require.NoError(t, err)
+			req.Header.Set("X-API-Key", key)
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			return resp.StatusCode
+		}
+		require.Equal(t, http.StatusTooManyRequests, get("unknown-key"))
+		require.Equal(t, http.StatusOK, get("reception-key"))
+		require.Equal(t, http.StatusTooManyRequests, get("reception-key"))
+	})
+	t.Run("unlimited routes", func(t *testing.T) {
+		for range 3 {
+			resp, _ := makeRequest(t, http.MethodGet, server.URL+"/healthz", nil)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+	})
+	t.Run("body size", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, roomURI, strings.NewReader(`{"number": "`+strings.Repeat("1", 2<<20)+`"}`))
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	})
+}
@@ -0,0 +1,175 @@
+// Package metrics exposes the Prometheus metrics of the server: the HTTP requests, the database queries and the
+// figures of the hotel
+package metrics
+
+import (
+	"context"
+	"errors"
+	"example/models"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// the route is the pattern of setupRoutes, never the path, so that the number of series stays bounded
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hotel_http_requests_total",
+		Help: "HTTP requests served, by route pattern and status.",
+	}, []string{"method", "route", "status"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "hotel_http_request_duration_seconds",
+		Help:    "Latency of the HTTP requests, by route pattern.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "hotel_db_query_duration_seconds",
+		Help:    "Duration of the SQL queries, by dal function.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"function"})
+	queryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hotel_db_query_errors_total",
+		Help: "SQL queries that failed, by dal function.",
+	}, []string{"function"})
+	// the server has a connection per role instead of a pool, these are the figures of a pool for each connection
+	queries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hotel_db_queries_total",
+		Help: "SQL queries run, by connection.",
+	}, []string{"connection"})
+	queriesInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hotel_db_queries_in_flight",
+		Help: "SQL queries running, by connection. A connection runs one query at a time, more means it is shared.",
+	}, []string{"connection"})
+	connectionBusy = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "hotel_db_connection_busy_seconds",
+		Help:    "Time the connection was busy with a query, by connection.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"connection"})
+)
+
+// ObserveRequest records a served request, route is empty when no route matched
+func ObserveRequest(method string, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// QueryStarted records a query started on a connection, named as in TrackConnection
+func QueryStarted(connection string) {
+	queries.WithLabelValues(connection).Inc()
+	queriesInFlight.WithLabelValues(connection).Inc()
+}
+
+// ObserveQuery records a query of a dal function on a connection once it is over
+func ObserveQuery(connection string, function string, duration time.Duration, err error) {
+	queriesInFlight.WithLabelValues(connection).Dec()
+	connectionBusy.WithLabelValues(connection).Observe(duration.Seconds())
+	queryDuration.WithLabelValues(function).Observe(duration.Seconds())
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		queryErrors.WithLabelValues(function).Inc()
+	}
+}
+
+var (
+	connectionsMu sync.Mutex
+	connections   = map[string]*pgx.Conn{}
+)
+
+// TrackConnection exposes whether a database connection of the server is open, the server has a connection per
+// role (e.g. api and worker) instead of a pool
+func TrackConnection(name string, conn *pgx.Conn) {
+	connectionsMu.Lock()
+	defer connectionsMu.Unlock()
+	connections[name] = conn
+}
+
+// ConnectionName returns the name a connection is tracked with, "other" for the connections not tracked
+func ConnectionName(conn *pgx.Conn) string {
+	connectionsMu.Lock()
+	defer connectionsMu.Unlock()
+	for name, c := range connections {
+		if c == conn {
+			return name
+		}
+	}
+	return "other"
+}
+
+// Handler serves the metrics, the hotel figures are read with stats at every scrape
+func Handler(stats func(ctx context.Context) (*models.HotelStats, error)) http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration, queryDuration, queryErrors, queries, queriesInFlight, connectionBusy,
+		connectionCollector{},
+		hotelCollector{stats: stats},
+	)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+var connectionOpenDesc = prometheus.NewDesc("hotel_db_connection_open", "Whether the database connection is open.", []string{"connection"}, nil)
+
+type connectionCollector struct{}
+
+func (connectionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- connectionOpenDesc
+}
+
+func (connectionCollector) Collect(ch chan<- prometheus.Metric) {
+	connectionsMu.Lock()
+	defer connectionsMu.Unlock()
+	for name, conn := range connections {
+		open := 1.0
+		if conn.IsClosed() {
+			open = 0
+		}
+		ch <- prometheus.MustNewConstMetric(connectionOpenDesc, prometheus.GaugeValue, open, name)
+	}
+}
+
+var (
+	statsUpDesc             = prometheus.NewDesc("hotel_stats_up", "Whether the hotel figures could be read.", nil, nil)
+	inHouseGuestsDesc       = prometheus.NewDesc("hotel_in_house_guests", "Guests checked in.", nil, nil)
+	arrivalsDesc            = prometheus.NewDesc("hotel_arrivals_today", "Bookings starting today.", nil, nil)
+	departuresDesc          = prometheus.NewDesc("hotel_departures_today", "Bookings ending today.", nil, nil)
+	openServiceRequestsDesc = prometheus.NewDesc("hotel_open_service_requests", "Service requests scheduled from today on.", nil, nil)
+)
+
+// hotelCollector reads the figures of the hotel when scraped, so that they are always current
+type hotelCollector struct {
+	stats func(ctx context.Context) (*models.HotelStats, error)
+}
+
+func (c hotelCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- statsUpDesc
+	ch <- inHouseGuestsDesc
+	ch <- arrivalsDesc
+	ch <- departuresDesc
+	ch <- openServiceRequestsDesc
+}
+
+func (c hotelCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stats, err := c.stats(ctx)
+	if err != nil {
+		slog.Error("Error reading hotel stats", "error", err)
+		ch <- prometheus.MustNewConstMetric(statsUpDesc, prometheus.GaugeValue, 0)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(statsUpDesc, prometheus.GaugeValue, 1)
+	ch <- prometheus.MustNewConstMetric(inHouseGuestsDesc, prometheus.GaugeValue, float64(stats.InHouseGuests))
+	ch <- prometheus.MustNewConstMetric(arrivalsDesc, prometheus.GaugeValue, float64(stats.Arrivals))
+	ch <- prometheus.MustNewConstMetric(departuresDesc, prometheus.GaugeValue, float64(stats.Departures))
+	ch <- prometheus.MustNewConstMetric(openServiceRequestsDesc, prometheus.GaugeValue, float64(stats.OpenServiceRequests))
+}
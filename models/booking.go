@@ -4,20 +4,54 @@ import "time"
 
 type BookingDTO struct {
 	ID         int    `json:"id,omitempty"`
-	Code       string `json:"code" validate:"required"`
+	Code       string `json:"code" validate:"omitempty,max=16"` // generated by the server when empty
 	CustomerID int    `json:"customer_id" validate:"required"`
 	RoomID     int    `json:"room_id" validate:"required"`
 	StartDate  string `json:"start_date" validate:"required,datetime=2006-01-02"`
 	EndDate    string `json:"end_date" validate:"required,datetime=2006-01-02"`
+	Adults     int    `json:"adults" validate:"omitempty,gt=0"` // 1 by default
+	Children   int    `json:"children" validate:"gte=0"`
+	// the guests in the room, all of them must be registered at check-in
+	Occupants []OccupantDTO `json:"occupants,omitempty" validate:"omitempty,dive"`
+	HoldID    int           `json:"hold_id,omitempty"` // books the room held by a hold, ignored on update
+	// set by the server, ignored on input
+	Status       string `json:"status,omitempty"`
+	CheckedInAt  string `json:"checked_in_at,omitempty"`
+	CheckedOutAt string `json:"checked_out_at,omitempty"`
 }
 
 type Booking struct {
-	ID         int
-	Code       string
-	CustomerID int
-	RoomID     int
-	StartDate  time.Time
-	EndDate    time.Time
+	ID           int
+	Code         string
+	CustomerID   int
+	RoomID       int
+	StartDate    time.Time
+	EndDate      time.Time
+	Status       string
+	CheckedInAt  *time.Time
+	CheckedOutAt *time.Time
+	Adults       int
+	Children     int
+	Occupants    []Occupant
+	HoldID       int
+}
+
+const (
+	BookingConfirmed  = "confirmed"
+	BookingCheckedIn  = "checked_in"
+	BookingCheckedOut = "checked_out"
+	BookingCancelled  = "cancelled"
+	BookingNoShow     = "no_show"
+)
+
+// Guests returns the number of people staying in the room
+func (b *Booking) Guests() int {
+	return b.Adults + b.Children
+}
+
+// Active reports whether the booking still holds its room
+func (b *Booking) Active() bool {
+	return b.Status != BookingCancelled && b.Status != BookingNoShow
 }
 
 type Patch interface {
@@ -25,48 +59,78 @@ type Patch interface {
 }
 
 type BookingPatch struct {
-	Code       *string `json:"code,omitempty"`
+	Code       *string `json:"code,omitempty" validate:"omitempty,max=16"`
 	CustomerID *int    `json:"customer_id,omitempty"`
 	RoomID     *int    `json:"room_id,omitempty"`
 	StartDate  *string `json:"start_date,omitempty" validate:"omitempty,datetime=2006-01-02"`
 	EndDate    *string `json:"end_date,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	Adults     *int    `json:"adults,omitempty" validate:"omitempty,gt=0"`
+	Children   *int    `json:"children,omitempty" validate:"omitempty,gte=0"`
 }
 
 const BookingValidationError = `Invalid booking data:
-- String field 'code' is required
+- String field 'code' must be at most 16 characters, a confirmation code is generated when it is missing
 - Integer field 'customer_id' is required
 - Integer field 'room_id' is required
 - String field 'start_date' is required and must be in YYYY-MM-DD format
-- String field 'end_date' is required and must be in YYYY-MM-DD format`
+- String field 'end_date' is required and must be in YYYY-MM-DD format
+- Integer field 'adults' must be greater than 0, 1 by default
+- Integer field 'children' cannot be negative
+- Array field 'occupants' lists the guests: 'first_name', 'last_name', 'birth_date' and 'nationality' are required, 'document_type' and 'document_number' are optional`
 
 func (b *Booking) ToDTO() BookingDTO {
-	return BookingDTO{
+	bookingDTO := BookingDTO{
 		ID:         b.ID,
 		Code:       b.Code,
 		CustomerID: b.CustomerID,
 		RoomID:     b.RoomID,
-		StartDate:  b.StartDate.Format("2006-01-02"),
-		EndDate:    b.EndDate.Format("2006-01-02"),
+		StartDate:  b.StartDate.Format(DateFormat),
+		EndDate:    b.EndDate.Format(DateFormat),
+		Status:     b.Status,
+		Adults:     b.Adults,
+		Children:   b.Children,
+	}
+	for _, occupant := range b.Occupants {
+		bookingDTO.Occupants = append(bookingDTO.Occupants, occupant.ToDTO())
+	}
+	if b.CheckedInAt != nil {
+		bookingDTO.CheckedInAt = b.CheckedInAt.Format(time.RFC3339)
 	}
+	if b.CheckedOutAt != nil {
+		bookingDTO.CheckedOutAt = b.CheckedOutAt.Format(time.RFC3339)
+	}
+	return bookingDTO
 }
 
 func (b *BookingDTO) ToModel() (Booking, error) {
-	startDate, err := time.Parse("2006-01-02", b.StartDate)
+	startDate, err := ParseDate(b.StartDate)
+	if err != nil {
+		return Booking{}, err
+	}
+	endDate, err := ParseDate(b.EndDate)
 	if err != nil {
 		return Booking{}, err
 	}
-	endDate, err := time.Parse("2006-01-02", b.EndDate)
+	occupants, err := OccupantsToModel(b.Occupants)
 	if err != nil {
 		return Booking{}, err
 	}
-	return Booking{
+	booking := Booking{
 		ID:         b.ID,
 		Code:       b.Code,
 		CustomerID: b.CustomerID,
 		RoomID:     b.RoomID,
 		StartDate:  startDate,
 		EndDate:    endDate,
-	}, nil
+		Adults:     b.Adults,
+		Children:   b.Children,
+		Occupants:  occupants,
+		HoldID:     b.HoldID,
+	}
+	if booking.Adults == 0 {
+		booking.Adults = 1
+	}
+	return booking, nil
 }
 
 func (p BookingPatch) FromStructToDBAttr() map[string]string {
@@ -76,5 +140,7 @@ func (p BookingPatch) FromStructToDBAttr() map[string]string {
 		"RoomID":     "room_id",
 		"StartDate":  "start_date",
 		"EndDate":    "end_date",
+		"Adults":     "adults",
+		"Children":   "children",
 	}
 }
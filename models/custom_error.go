@@ -2,9 +2,13 @@ package models
 
 // ValidationError custom error type for validation errors in services
 type ValidationError struct {
+	Code    string // optional machine readable code, e.g. review_window_closed
 	Message string
 }
 
 func (e ValidationError) Error() string {
+	if e.Code != "" {
+		return e.Code + ": " + e.Message
+	}
 	return e.Message
 }
@@ -6,21 +6,25 @@ type Customer struct {
 	Name  string `json:"name" validate:"required"`
 	Age   int    `json:"age" validate:"required,gt=0"`
 	Email string `json:"email" validate:"required,email"`
+	// language of the emails sent to the customer, English when empty
+	Language string `json:"language,omitempty" validate:"omitempty,oneof=en it"`
 }
 
 type CustomerPatch struct {
-	CF    *string `json:"cf,omitempty"`
-	Name  *string `json:"name,omitempty"`
-	Age   *int    `json:"age,omitempty" validate:"omitempty,gt=0"`
-	Email *string `json:"email,omitempty" validate:"omitempty,email"`
+	CF       *string `json:"cf,omitempty"`
+	Name     *string `json:"name,omitempty"`
+	Age      *int    `json:"age,omitempty" validate:"omitempty,gt=0"`
+	Email    *string `json:"email,omitempty" validate:"omitempty,email"`
+	Language *string `json:"language,omitempty" validate:"omitempty,oneof=en it"`
 }
 
 func (p CustomerPatch) FromStructToDBAttr() map[string]string {
 	return map[string]string{
-		"CF":    "cf",
-		"Name":  "customer_name",
-		"Age":   "age",
-		"Email": "email",
+		"CF":       "cf",
+		"Name":     "customer_name",
+		"Age":      "age",
+		"Email":    "email",
+		"Language": "lang",
 	}
 }
 
@@ -28,4 +32,5 @@ const CustomerValidationError = `Invalid customer data:
 - String field 'cf' is required
 - String field 'name' is required
 - Integer field 'age' is required and must be greater than 0
-- String field 'email' is required and must be a valid email address`
+- String field 'email' is required and must be a valid email address
+- String field 'language' can be one of: en, it`
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// DateFormat is the format of the dates of the API, a date has no time and no timezone
+const DateFormat = "2006-01-02"
+
+// ParseDate reads a date as midnight UTC, like the dates read from the database, so that dates compare with each
+// other whatever the timezone of the server
+func ParseDate(value string) (time.Time, error) {
+	return time.Parse(DateFormat, value)
+}
+
+// DateOf returns the calendar date of an instant in a timezone, as midnight UTC
+func DateOf(t time.Time, location *time.Location) time.Time {
+	t = t.In(location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,17 @@
+package models
+
+const (
+	EventWaitlistRoomAvailable = "waitlist.room_available"
+	EventBookingConfirmed      = "booking.confirmed"
+	EventBookingCancelled      = "booking.cancelled"
+	EventBookingCheckedOut     = "booking.checked_out"
+	EventArrivalReminder       = "booking.arrival_reminder"
+)
+
+// Event is something that happened in the hotel that other parts of the system react to, e.g. by notifying a customer
+type Event struct {
+	Type       string
+	CustomerID int
+	// the entity the event is about, e.g. a WaitlistEntry
+	Payload any
+}
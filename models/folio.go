@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+const (
+	FolioRoomCharge = "room"
+)
+
+type FolioChargeDTO struct {
+	ID          int    `json:"id"`
+	Date        string `json:"date"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Amount      int    `json:"amount"`
+	Posted      bool   `json:"posted"` // posted charges are final, the others follow the changes of the stay
+}
+
+type FolioCharge struct {
+	ID          int
+	BookingID   int
+	Date        time.Time
+	Type        string
+	Description string
+	Amount      int
+	PostedAt    *time.Time
+}
+
+// FolioDTO is the bill of a booking
+type FolioDTO struct {
+	BookingID int              `json:"booking_id"`
+	Charges   []FolioChargeDTO `json:"charges"`
+	Total     int              `json:"total"`
+}
+
+func (c *FolioCharge) ToDTO() FolioChargeDTO {
+	return FolioChargeDTO{
+		ID:          c.ID,
+		Date:        c.Date.Format(DateFormat),
+		Type:        c.Type,
+		Description: c.Description,
+		Amount:      c.Amount,
+		Posted:      c.PostedAt != nil,
+	}
+}
+
+func NewFolioDTO(bookingID int, charges []FolioCharge) FolioDTO {
+	folio := FolioDTO{BookingID: bookingID, Charges: []FolioChargeDTO{}}
+	for _, charge := range charges {
+		folio.Charges = append(folio.Charges, charge.ToDTO())
+		folio.Total += charge.Amount
+	}
+	return folio
+}
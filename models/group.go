@@ -0,0 +1,144 @@
+package models
+
+import "time"
+
+const (
+	GroupActive    = "active"
+	GroupCancelled = "cancelled"
+)
+
+// GroupDTO is a reservation of many rooms for the same dates, every room is a booking
+type GroupDTO struct {
+	ID             int            `json:"id,omitempty"`
+	Code           string         `json:"code" validate:"required,alphanum,max=12"`
+	Name           string         `json:"name" validate:"required,max=64"`
+	LeadCustomerID int            `json:"lead_customer_id" validate:"required"`
+	StartDate      string         `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate        string         `json:"end_date" validate:"required,datetime=2006-01-02"`
+	CutoffDate     string         `json:"cutoff_date,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	Rooms          []GroupRoomDTO `json:"rooms" validate:"required,min=1,unique=RoomID,dive"`
+	// set by the server, ignored on input
+	Status string `json:"status,omitempty"`
+}
+
+// GroupRoomDTO is a room of a group, without a customer the room is an allotment held for the lead customer
+type GroupRoomDTO struct {
+	RoomID     int `json:"room_id" validate:"required"`
+	CustomerID int `json:"customer_id,omitempty"`
+	// set by the server, ignored on input
+	BookingID int    `json:"booking_id,omitempty"`
+	Code      string `json:"code,omitempty"`
+	Allotment bool   `json:"allotment"`
+	Status    string `json:"status,omitempty"`
+}
+
+type Group struct {
+	ID             int
+	Code           string
+	Name           string
+	LeadCustomerID int
+	StartDate      time.Time
+	EndDate        time.Time
+	CutoffDate     *time.Time
+	Status         string
+	Rooms          []GroupRoom
+}
+
+type GroupRoom struct {
+	Booking   Booking
+	Allotment bool
+}
+
+type GroupGuestDTO struct {
+	CustomerID int `json:"customer_id" validate:"required"`
+}
+
+const GroupValidationError = `Invalid group data:
+- String field 'code' is required, it must be alphanumeric and at most 12 characters
+- String field 'name' is required and must be at most 64 characters
+- Integer field 'lead_customer_id' is required
+- String field 'start_date' is required and must be in YYYY-MM-DD format
+- String field 'end_date' is required and must be in YYYY-MM-DD format
+- String field 'cutoff_date' must be in YYYY-MM-DD format, the rooms without a guest are released on that date
+- Array field 'rooms' is required, each room needs a 'room_id' and can have a 'customer_id', a room can appear only once`
+
+const GroupGuestValidationError = `Invalid guest data:
+- Integer field 'customer_id' is required`
+
+func (g *Group) ToDTO() GroupDTO {
+	groupDTO := GroupDTO{
+		ID:             g.ID,
+		Code:           g.Code,
+		Name:           g.Name,
+		LeadCustomerID: g.LeadCustomerID,
+		StartDate:      g.StartDate.Format(DateFormat),
+		EndDate:        g.EndDate.Format(DateFormat),
+		Rooms:          []GroupRoomDTO{},
+		Status:         g.Status,
+	}
+	if g.CutoffDate != nil {
+		groupDTO.CutoffDate = g.CutoffDate.Format(DateFormat)
+	}
+	for _, room := range g.Rooms {
+		groupDTO.Rooms = append(groupDTO.Rooms, room.ToDTO())
+	}
+	return groupDTO
+}
+
+func (r *GroupRoom) ToDTO() GroupRoomDTO {
+	roomDTO := GroupRoomDTO{
+		RoomID:    r.Booking.RoomID,
+		BookingID: r.Booking.ID,
+		Code:      r.Booking.Code,
+		Allotment: r.Allotment,
+		Status:    r.Booking.Status,
+	}
+	if !r.Allotment {
+		roomDTO.CustomerID = r.Booking.CustomerID
+	}
+	return roomDTO
+}
+
+func (g *GroupDTO) ToModel() (Group, error) {
+	startDate, err := ParseDate(g.StartDate)
+	if err != nil {
+		return Group{}, err
+	}
+	endDate, err := ParseDate(g.EndDate)
+	if err != nil {
+		return Group{}, err
+	}
+	group := Group{
+		ID:             g.ID,
+		Code:           g.Code,
+		Name:           g.Name,
+		LeadCustomerID: g.LeadCustomerID,
+		StartDate:      startDate,
+		EndDate:        endDate,
+	}
+	if g.CutoffDate != "" {
+		cutoffDate, err := ParseDate(g.CutoffDate)
+		if err != nil {
+			return Group{}, err
+		}
+		group.CutoffDate = &cutoffDate
+	}
+	// the rooms without a guest are held for the lead customer
+	for _, room := range g.Rooms {
+		groupRoom := GroupRoom{
+			Booking: Booking{
+				CustomerID: room.CustomerID,
+				RoomID:     room.RoomID,
+				StartDate:  startDate,
+				EndDate:    endDate,
+				Adults:     1,
+			},
+			Allotment: room.CustomerID == 0,
+		}
+		if groupRoom.Allotment {
+			groupRoom.Booking.CustomerID = g.LeadCustomerID
+		}
+		group.Rooms = append(group.Rooms, groupRoom)
+	}
+	return group, nil
+}
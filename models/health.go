@@ -0,0 +1,13 @@
+package models
+
+const (
+	HealthOK           = "ok"
+	HealthShuttingDown = "shutting_down"
+	HealthUnavailable  = "unavailable"
+)
+
+// HealthDTO is the state of the server for the probes of the container orchestrator
+type HealthDTO struct {
+	Status   string `json:"status"`
+	Database string `json:"database,omitempty"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// HoldDTO reserves a room for a short time, e.g. while the guest pays, holds count against availability until they expire
+type HoldDTO struct {
+	ID         int    `json:"id,omitempty"`
+	RoomID     int    `json:"room_id" validate:"required"`
+	CustomerID int    `json:"customer_id,omitempty"` // when set only this customer can book with the hold
+	StartDate  string `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate    string `json:"end_date" validate:"required,datetime=2006-01-02"`
+	TTL        int    `json:"ttl_seconds,omitempty" validate:"omitempty,min=60,max=86400"` // 15 minutes by default
+	// set by the server, ignored on input
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+type Hold struct {
+	ID         int
+	RoomID     int
+	CustomerID *int
+	StartDate  time.Time
+	EndDate    time.Time
+	ExpiresAt  time.Time
+}
+
+const DefaultHoldTTL = 15 * time.Minute
+
+const HoldValidationError = `Invalid hold data:
+- Integer field 'room_id' is required
+- String field 'start_date' is required and must be in YYYY-MM-DD format
+- String field 'end_date' is required and must be in YYYY-MM-DD format
+- Integer field 'ttl_seconds' must be between 60 and 86400, 900 by default`
+
+func (h *Hold) ToDTO() HoldDTO {
+	holdDTO := HoldDTO{
+		ID:        h.ID,
+		RoomID:    h.RoomID,
+		StartDate: h.StartDate.Format(DateFormat),
+		EndDate:   h.EndDate.Format(DateFormat),
+		ExpiresAt: h.ExpiresAt.Format(time.RFC3339),
+	}
+	if h.CustomerID != nil {
+		holdDTO.CustomerID = *h.CustomerID
+	}
+	return holdDTO
+}
+
+// ToModel returns the hold and how long it lasts
+func (h *HoldDTO) ToModel() (Hold, time.Duration, error) {
+	startDate, err := ParseDate(h.StartDate)
+	if err != nil {
+		return Hold{}, 0, err
+	}
+	endDate, err := ParseDate(h.EndDate)
+	if err != nil {
+		return Hold{}, 0, err
+	}
+	hold := Hold{
+		ID:        h.ID,
+		RoomID:    h.RoomID,
+		StartDate: startDate,
+		EndDate:   endDate,
+	}
+	if h.CustomerID != 0 {
+		hold.CustomerID = &h.CustomerID
+	}
+	ttl := DefaultHoldTTL
+	if h.TTL != 0 {
+		ttl = time.Duration(h.TTL) * time.Second
+	}
+	return hold, ttl, nil
+}
@@ -1,27 +1,91 @@
 package models
 
+import (
+	"fmt"
+	"time"
+)
+
 type HotelService struct {
 	ID          int    `json:"id,omitempty"`
 	Type        string `json:"type" validate:"required,oneof=cleaning room_service massage"`
 	Description string `json:"description" validate:"required"`
-	Duration    int    `json:"duration" validate:"required,min=1"` // number of minutes
+	Duration    int    `json:"duration" validate:"required,min=1"`               // number of minutes
+	OpeningTime string `json:"opening_time" validate:"omitempty,datetime=15:04"` // 08:00 by default
+	ClosingTime string `json:"closing_time" validate:"omitempty,datetime=15:04"` // 20:00 by default
+	Capacity    int    `json:"capacity" validate:"omitempty,gt=0"`               // requests that can be served at the same time, 1 by default
 }
 
 type HotelServicePatch struct {
 	Type        *string `json:"type,omitempty" validate:"omitempty,oneof=cleaning room_service massage"`
 	Description *string `json:"description,omitempty"`
 	Duration    *int    `json:"duration,omitempty" validate:"omitempty,min=1"`
+	OpeningTime *string `json:"opening_time,omitempty" validate:"omitempty,datetime=15:04"`
+	ClosingTime *string `json:"closing_time,omitempty" validate:"omitempty,datetime=15:04"`
+	Capacity    *int    `json:"capacity,omitempty" validate:"omitempty,gt=0"`
+}
+
+// ServiceSlot is a time slot of a service on a given date
+type ServiceSlot struct {
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Available int    `json:"available"` // requests that can still be made for this slot
 }
 
 const HotelServiceValidationError = `Invalid hotel service data:
 - String field 'type' is required and must be one of: cleaning, room_service, massage
 - String field 'description' is required
-- Integer field 'duration' is required and must be greater than 0, representing minutes`
+- Integer field 'duration' is required and must be greater than 0, representing minutes
+- String field 'opening_time' must be in HH:MM format, 08:00 by default
+- String field 'closing_time' must be in HH:MM format, 20:00 by default
+- Integer field 'capacity' must be greater than 0, 1 by default`
+
+// SetDefaults gives the opening hours and the capacity left empty the defaults of their columns
+func (s *HotelService) SetDefaults() {
+	if s.OpeningTime == "" {
+		s.OpeningTime = "08:00"
+	}
+	if s.ClosingTime == "" {
+		s.ClosingTime = "20:00"
+	}
+	if s.Capacity == 0 {
+		s.Capacity = 1
+	}
+}
 
 func (p HotelServicePatch) FromStructToDBAttr() map[string]string {
 	return map[string]string{
 		"Type":        "service_type",
 		"Description": "description",
 		"Duration":    "duration",
+		"OpeningTime": "opening_time",
+		"ClosingTime": "closing_time",
+		"Capacity":    "capacity",
 	}
 }
+
+// OpeningHours returns the opening and closing time as offsets from midnight
+func (s *HotelService) OpeningHours() (time.Duration, time.Duration, error) {
+	opening, err := ParseClock(s.OpeningTime)
+	if err != nil {
+		return 0, 0, err
+	}
+	closing, err := ParseClock(s.ClosingTime)
+	if err != nil {
+		return 0, 0, err
+	}
+	return opening, closing, nil
+}
+
+// ParseClock parses a HH:MM time of the day into an offset from midnight
+func ParseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// FormatClock formats an offset from midnight as HH:MM
+func FormatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	HousekeepingClean        = "clean"
+	HousekeepingDirty        = "dirty"
+	HousekeepingInspected    = "inspected"
+	HousekeepingOutOfService = "out_of_service"
+)
+
+const (
+	HousekeepingDeparture = "departure" // the guest leaves, the room must be prepared for the next one
+	HousekeepingStayOver  = "stay_over" // the guest stays, daily cleaning
+)
+
+// housekeepingTransitions lists the statuses a room can move to from each status
+var housekeepingTransitions = map[string][]string{
+	HousekeepingClean:        {HousekeepingDirty, HousekeepingInspected, HousekeepingOutOfService},
+	HousekeepingDirty:        {HousekeepingClean, HousekeepingOutOfService},
+	HousekeepingInspected:    {HousekeepingDirty, HousekeepingOutOfService},
+	HousekeepingOutOfService: {HousekeepingDirty},
+}
+
+type HousekeepingStatusDTO struct {
+	Status string `json:"status" validate:"required,oneof=clean dirty inspected out_of_service"`
+}
+
+// HousekeepingBoardRow is the state of a room on a given date
+type HousekeepingBoardRow struct {
+	RoomID     int    `json:"room_id"`
+	RoomNumber int    `json:"room_number"`
+	RoomType   string `json:"room_type"`
+	Status     string `json:"status"`
+	Occupied   bool   `json:"occupied"`  // a guest sleeps in the room that night
+	Arrival    bool   `json:"arrival"`   // a guest arrives that day
+	Departure  bool   `json:"departure"` // a guest leaves that day
+	TaskID     *int   `json:"task_id,omitempty"`
+	StaffID    *int   `json:"staff_id,omitempty"`
+}
+
+type HousekeepingTaskDTO struct {
+	ID          int    `json:"id"`
+	RoomID      int    `json:"room_id"`
+	BookingID   int    `json:"booking_id"`
+	Date        string `json:"date"`
+	Type        string `json:"type"`
+	StaffID     *int   `json:"staff_id,omitempty"`
+	CompletedAt string `json:"completed_at,omitempty"`
+}
+
+type HousekeepingTask struct {
+	ID          int
+	RoomID      int
+	BookingID   int
+	Date        time.Time
+	Type        string
+	StaffID     *int
+	CompletedAt *time.Time
+}
+
+const HousekeepingStatusValidationError = `Invalid housekeeping data:
+- String field 'status' is required and must be one of: clean, dirty, inspected, out_of_service`
+
+// CanMoveHousekeeping reports whether a room can move from a housekeeping status to another
+func CanMoveHousekeeping(from, to string) bool {
+	return slices.Contains(housekeepingTransitions[from], to)
+}
+
+func (t *HousekeepingTask) ToDTO() HousekeepingTaskDTO {
+	dto := HousekeepingTaskDTO{
+		ID:        t.ID,
+		RoomID:    t.RoomID,
+		BookingID: t.BookingID,
+		Date:      t.Date.Format(DateFormat),
+		Type:      t.Type,
+		StaffID:   t.StaffID,
+	}
+	if t.CompletedAt != nil {
+		dto.CompletedAt = t.CompletedAt.Format(time.RFC3339)
+	}
+	return dto
+}
@@ -0,0 +1,86 @@
+package models
+
+import "time"
+
+const (
+	JobRunRunning   = "running"
+	JobRunSucceeded = "succeeded"
+	JobRunFailed    = "failed"
+)
+
+// how a job run was started
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+)
+
+// JobDTO is a background job run by the scheduler
+type JobDTO struct {
+	Name      string     `json:"name"`
+	Schedule  string     `json:"schedule"`
+	Paused    bool       `json:"paused"`
+	NextRunAt string     `json:"next_run_at,omitempty"`
+	LastRun   *JobRunDTO `json:"last_run,omitempty"`
+}
+
+type JobRunDTO struct {
+	ID         int    `json:"id"`
+	JobName    string `json:"job_name"`
+	Trigger    string `json:"trigger"`
+	Status     string `json:"status"`
+	Result     string `json:"result,omitempty"`
+	Error      string `json:"error,omitempty"`
+	StartedAt  string `json:"started_at"`
+	FinishedAt string `json:"finished_at,omitempty"`
+}
+
+type Job struct {
+	Name      string
+	Schedule  string
+	Paused    bool
+	NextRunAt *time.Time
+	LastRun   *JobRun
+}
+
+type JobRun struct {
+	ID         int
+	JobName    string
+	Trigger    string
+	Status     string
+	Result     string
+	Error      string
+	StartedAt  time.Time
+	FinishedAt *time.Time
+}
+
+func (j *Job) ToDTO() JobDTO {
+	jobDTO := JobDTO{
+		Name:     j.Name,
+		Schedule: j.Schedule,
+		Paused:   j.Paused,
+	}
+	if j.NextRunAt != nil {
+		jobDTO.NextRunAt = j.NextRunAt.Format(time.RFC3339)
+	}
+	if j.LastRun != nil {
+		lastRun := j.LastRun.ToDTO()
+		jobDTO.LastRun = &lastRun
+	}
+	return jobDTO
+}
+
+func (r *JobRun) ToDTO() JobRunDTO {
+	runDTO := JobRunDTO{
+		ID:        r.ID,
+		JobName:   r.JobName,
+		Trigger:   r.Trigger,
+		Status:    r.Status,
+		Result:    r.Result,
+		Error:     r.Error,
+		StartedAt: r.StartedAt.Format(time.RFC3339),
+	}
+	if r.FinishedAt != nil {
+		runDTO.FinishedAt = r.FinishedAt.Format(time.RFC3339)
+	}
+	return runDTO
+}
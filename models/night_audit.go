@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+type BusinessDateDTO struct {
+	BusinessDate string `json:"business_date"`
+}
+
+// NightAuditDTO is the summary of a business day, produced by the night audit that closes it
+type NightAuditDTO struct {
+	BusinessDate  string  `json:"business_date"`
+	ClosedAt      string  `json:"closed_at"`
+	Arrivals      int     `json:"arrivals"`
+	Departures    int     `json:"departures"`
+	InHouse       int     `json:"in_house"`
+	NoShows       int     `json:"no_shows"`
+	ChargesPosted int     `json:"charges_posted"`
+	RoomsOccupied int     `json:"rooms_occupied"`
+	RoomsTotal    int     `json:"rooms_total"`
+	Occupancy     float64 `json:"occupancy"` // percentage
+	RoomRevenue   int     `json:"room_revenue"`
+}
+
+type NightAudit struct {
+	BusinessDate  time.Time
+	ClosedAt      time.Time
+	Arrivals      int // guests that checked in on the day
+	Departures    int // guests that checked out on the day
+	InHouse       int // bookings staying the night
+	NoShows       int
+	ChargesPosted int
+	RoomsOccupied int
+	RoomsTotal    int
+	RoomRevenue   int // room charges of the night
+}
+
+func (a *NightAudit) ToDTO() NightAuditDTO {
+	return NightAuditDTO{
+		BusinessDate:  a.BusinessDate.Format(DateFormat),
+		ClosedAt:      a.ClosedAt.Format(time.RFC3339),
+		Arrivals:      a.Arrivals,
+		Departures:    a.Departures,
+		InHouse:       a.InHouse,
+		NoShows:       a.NoShows,
+		ChargesPosted: a.ChargesPosted,
+		RoomsOccupied: a.RoomsOccupied,
+		RoomsTotal:    a.RoomsTotal,
+		Occupancy:     ratio(a.RoomsOccupied*100, a.RoomsTotal),
+		RoomRevenue:   a.RoomRevenue,
+	}
+}
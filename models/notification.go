@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+// kinds of notification, each one has a template per language
+const (
+	NotificationConfirmation     = "booking_confirmation"
+	NotificationReminder         = "arrival_reminder"
+	NotificationCancellation     = "booking_cancellation"
+	NotificationReviewInvitation = "review_invitation"
+)
+
+const (
+	NotificationPending = "pending" // waiting for the send-notifications job
+	NotificationSent    = "sent"
+	NotificationFailed  = "failed"
+)
+
+// Email is a message ready to be sent
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// NotificationDTO is an entry of the send log, one per email to send, sent or failed
+type NotificationDTO struct {
+	ID         int    `json:"id"`
+	CustomerID int    `json:"customer_id"`
+	BookingID  int    `json:"booking_id"`
+	Kind       string `json:"kind"`
+	Recipient  string `json:"recipient"`
+	Language   string `json:"language"`
+	Subject    string `json:"subject"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	CreatedAt  string `json:"created_at"`
+	SentAt     string `json:"sent_at,omitempty"`
+}
+
+type Notification struct {
+	ID         int
+	CustomerID int
+	BookingID  int
+	Kind       string
+	Recipient  string
+	Language   string
+	Subject    string
+	Body       string
+	Status     string
+	Error      string
+	CreatedAt  time.Time
+	SentAt     *time.Time // when it was sent or failed
+}
+
+// Email returns the email of the notification
+func (n *Notification) Email() Email {
+	return Email{To: n.Recipient, Subject: n.Subject, Body: n.Body}
+}
+
+func (n *Notification) ToDTO() NotificationDTO {
+	notificationDTO := NotificationDTO{
+		ID:         n.ID,
+		CustomerID: n.CustomerID,
+		BookingID:  n.BookingID,
+		Kind:       n.Kind,
+		Recipient:  n.Recipient,
+		Language:   n.Language,
+		Subject:    n.Subject,
+		Status:     n.Status,
+		Error:      n.Error,
+		CreatedAt:  n.CreatedAt.Format(time.RFC3339),
+	}
+	if n.SentAt != nil {
+		notificationDTO.SentAt = n.SentAt.Format(time.RFC3339)
+	}
+	return notificationDTO
+}
@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// OccupantDTO is a guest staying in the room, adults need an identity document at check-in
+type OccupantDTO struct {
+	FirstName      string `json:"first_name" validate:"required,max=32"`
+	LastName       string `json:"last_name" validate:"required,max=32"`
+	BirthDate      string `json:"birth_date" validate:"required,datetime=2006-01-02"`
+	Nationality    string `json:"nationality" validate:"required,iso3166_1_alpha2"`
+	DocumentType   string `json:"document_type,omitempty" validate:"omitempty,oneof=id_card passport driving_license,required_with=DocumentNumber"`
+	DocumentNumber string `json:"document_number,omitempty" validate:"omitempty,max=32,required_with=DocumentType"`
+}
+
+type Occupant struct {
+	FirstName      string
+	LastName       string
+	BirthDate      time.Time
+	Nationality    string
+	DocumentType   string
+	DocumentNumber string
+}
+
+// OccupantsDTO registers the guests of a booking, before or at check-in
+type OccupantsDTO struct {
+	Occupants []OccupantDTO `json:"occupants" validate:"omitempty,dive"`
+}
+
+const OccupantsValidationError = `Invalid occupants data, for each occupant:
+- String fields 'first_name' and 'last_name' are required and must be at most 32 characters
+- String field 'birth_date' is required and must be in YYYY-MM-DD format
+- String field 'nationality' is required and must be an ISO 3166-1 alpha-2 country code
+- String field 'document_type' can be one of: id_card, passport, driving_license
+- String field 'document_number' must be at most 32 characters, document type and number go together`
+
+// AgeOn returns the age of the occupant on a date
+func (o *Occupant) AgeOn(date time.Time) int {
+	age := date.Year() - o.BirthDate.Year()
+	if date.Month() < o.BirthDate.Month() || (date.Month() == o.BirthDate.Month() && date.Day() < o.BirthDate.Day()) {
+		age--
+	}
+	return age
+}
+
+func (o *Occupant) ToDTO() OccupantDTO {
+	return OccupantDTO{
+		FirstName:      o.FirstName,
+		LastName:       o.LastName,
+		BirthDate:      o.BirthDate.Format(DateFormat),
+		Nationality:    o.Nationality,
+		DocumentType:   o.DocumentType,
+		DocumentNumber: o.DocumentNumber,
+	}
+}
+
+func (o *OccupantDTO) ToModel() (Occupant, error) {
+	birthDate, err := ParseDate(o.BirthDate)
+	if err != nil {
+		return Occupant{}, err
+	}
+	return Occupant{
+		FirstName:      o.FirstName,
+		LastName:       o.LastName,
+		BirthDate:      birthDate,
+		Nationality:    o.Nationality,
+		DocumentType:   o.DocumentType,
+		DocumentNumber: o.DocumentNumber,
+	}, nil
+}
+
+func OccupantsToModel(occupantDTOs []OccupantDTO) ([]Occupant, error) {
+	var occupants []Occupant
+	for _, occupantDTO := range occupantDTOs {
+		occupant, err := occupantDTO.ToModel()
+		if err != nil {
+			return nil, err
+		}
+		occupants = append(occupants, occupant)
+	}
+	return occupants, nil
+}
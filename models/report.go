@@ -0,0 +1,151 @@
+package models
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+type ReportParamsDTO struct {
+	StartDate string `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate   string `json:"end_date" validate:"required,datetime=2006-01-02"`
+	GroupBy   string `json:"group_by" validate:"required,oneof=day week month"`
+	By        string `json:"by" validate:"omitempty,oneof=room_type"`
+}
+
+type ReportParams struct {
+	StartDate  time.Time
+	EndDate    time.Time // inclusive, the night of EndDate is part of the report
+	GroupBy    string
+	ByRoomType bool
+}
+
+const ReportValidationError = `Invalid report parameters:
+- Query parameter 'start_date' is required and must be in YYYY-MM-DD format
+- Query parameter 'end_date' is required and must be in YYYY-MM-DD format, the night of end_date is included
+- Query parameter 'group_by' must be one of: day, week, month (default day)
+- Query parameter 'by' can be 'room_type' to split the results by room type`
+
+func (p *ReportParamsDTO) ToModel() (ReportParams, error) {
+	startDate, err := ParseDate(p.StartDate)
+	if err != nil {
+		return ReportParams{}, err
+	}
+	endDate, err := ParseDate(p.EndDate)
+	if err != nil {
+		return ReportParams{}, err
+	}
+	return ReportParams{
+		StartDate:  startDate,
+		EndDate:    endDate,
+		GroupBy:    p.GroupBy,
+		ByRoomType: p.By == "room_type",
+	}, nil
+}
+
+// KPIRow aggregates the room nights of one period, for one room type or for all of them ("all")
+type KPIRow struct {
+	Period         time.Time // first day of the period
+	RoomType       string
+	RoomsAvailable int // room nights available
+	RoomsSold      int // room nights sold
+	RoomRevenue    int
+}
+
+type OccupancyReportRow struct {
+	Period         string  `json:"period"`
+	RoomType       string  `json:"room_type"`
+	RoomsAvailable int     `json:"rooms_available"`
+	RoomsSold      int     `json:"rooms_sold"`
+	Occupancy      float64 `json:"occupancy"` // percentage
+}
+
+type ADRReportRow struct {
+	Period      string  `json:"period"`
+	RoomType    string  `json:"room_type"`
+	RoomsSold   int     `json:"rooms_sold"`
+	RoomRevenue int     `json:"room_revenue"`
+	ADR         float64 `json:"adr"`
+}
+
+type RevPARReportRow struct {
+	Period         string  `json:"period"`
+	RoomType       string  `json:"room_type"`
+	RoomsAvailable int     `json:"rooms_available"`
+	RoomRevenue    int     `json:"room_revenue"`
+	RevPAR         float64 `json:"revpar"`
+}
+
+func (k *KPIRow) ToOccupancyRow() OccupancyReportRow {
+	return OccupancyReportRow{
+		Period:         k.Period.Format(DateFormat),
+		RoomType:       k.RoomType,
+		RoomsAvailable: k.RoomsAvailable,
+		RoomsSold:      k.RoomsSold,
+		Occupancy:      ratio(k.RoomsSold*100, k.RoomsAvailable),
+	}
+}
+
+func (k *KPIRow) ToADRRow() ADRReportRow {
+	return ADRReportRow{
+		Period:      k.Period.Format(DateFormat),
+		RoomType:    k.RoomType,
+		RoomsSold:   k.RoomsSold,
+		RoomRevenue: k.RoomRevenue,
+		ADR:         ratio(k.RoomRevenue, k.RoomsSold),
+	}
+}
+
+func (k *KPIRow) ToRevPARRow() RevPARReportRow {
+	return RevPARReportRow{
+		Period:         k.Period.Format(DateFormat),
+		RoomType:       k.RoomType,
+		RoomsAvailable: k.RoomsAvailable,
+		RoomRevenue:    k.RoomRevenue,
+		RevPAR:         ratio(k.RoomRevenue, k.RoomsAvailable),
+	}
+}
+
+func (r OccupancyReportRow) CSVHeader() []string {
+	return []string{"period", "room_type", "rooms_available", "rooms_sold", "occupancy"}
+}
+
+func (r OccupancyReportRow) CSVRecord() []string {
+	return []string{r.Period, r.RoomType, strconv.Itoa(r.RoomsAvailable), strconv.Itoa(r.RoomsSold), formatFloat(r.Occupancy)}
+}
+
+func (r ADRReportRow) CSVHeader() []string {
+	return []string{"period", "room_type", "rooms_sold", "room_revenue", "adr"}
+}
+
+func (r ADRReportRow) CSVRecord() []string {
+	return []string{r.Period, r.RoomType, strconv.Itoa(r.RoomsSold), strconv.Itoa(r.RoomRevenue), formatFloat(r.ADR)}
+}
+
+func (r RevPARReportRow) CSVHeader() []string {
+	return []string{"period", "room_type", "rooms_available", "room_revenue", "revpar"}
+}
+
+func (r RevPARReportRow) CSVRecord() []string {
+	return []string{r.Period, r.RoomType, strconv.Itoa(r.RoomsAvailable), strconv.Itoa(r.RoomRevenue), formatFloat(r.RevPAR)}
+}
+
+// ratio returns a / b rounded to two decimals, 0 when b is 0
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return math.Round(float64(a)/float64(b)*100) / 100
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+// HotelStats are the figures of the current day exposed to the monitoring
+type HotelStats struct {
+	InHouseGuests       int
+	Arrivals            int // expected today, arrived or not
+	Departures          int // due today, left or not
+	OpenServiceRequests int // scheduled from today on
+}
@@ -3,17 +3,42 @@ package models
 import "time"
 
 type ReviewDTO struct {
-	BookingID int    `json:"booking_id" validate:"required"`
-	Comment   string `json:"comment" validate:"required"`
-	Rating    int    `json:"rating" validate:"required,min=1,max=5"`
-	Date      string `json:"date" validate:"required,datetime=2006-01-02"`
+	BookingID  int    `json:"booking_id" validate:"required"`
+	CustomerID int    `json:"customer_id" validate:"required"` // the reviewer, must be the customer of the booking
+	Comment    string `json:"comment" validate:"required"`
+	Rating     int    `json:"rating" validate:"required,min=1,max=5"`
+	Date       string `json:"date" validate:"required,datetime=2006-01-02"`
+	// set by the server, ignored on input
+	Status           string `json:"status,omitempty"`
+	ModerationReason string `json:"moderation_reason,omitempty"`
+	Reply            string `json:"reply,omitempty"`
+	ReplyDate        string `json:"reply_date,omitempty"`
 }
 
 type Review struct {
-	BookingID int
-	Comment   string
-	Rating    int
-	Date      time.Time
+	BookingID        int
+	CustomerID       int
+	Comment          string
+	Rating           int
+	Date             time.Time
+	Status           string // pending, published or rejected
+	ModerationReason string
+	Reply            string
+	ReplyDate        *time.Time
+}
+
+const (
+	ReviewPending   = "pending"
+	ReviewPublished = "published"
+	ReviewRejected  = "rejected"
+)
+
+type ReviewRejectionDTO struct {
+	Reason string `json:"reason" validate:"required,max=256"`
+}
+
+type ReviewReplyDTO struct {
+	Reply string `json:"reply" validate:"required,max=1024"`
 }
 
 type ReviewPatch struct {
@@ -25,29 +50,39 @@ type ReviewPatch struct {
 
 const ReviewValidationError = `Invalid review data:
 - Integer field 'booking_id' is required
+- Integer field 'customer_id' is required and must be the customer of the booking
 - String field 'comment' is required
 - Integer field 'rating' is required and must be between 1 and 5
 - String field 'date' is required and must be in YYYY-MM-DD format`
 
 func (r *Review) ToDTO() ReviewDTO {
-	return ReviewDTO{
-		BookingID: r.BookingID,
-		Comment:   r.Comment,
-		Rating:    r.Rating,
-		Date:      r.Date.Format("2006-01-02"),
+	reviewDTO := ReviewDTO{
+		BookingID:        r.BookingID,
+		CustomerID:       r.CustomerID,
+		Comment:          r.Comment,
+		Rating:           r.Rating,
+		Date:             r.Date.Format(DateFormat),
+		Status:           r.Status,
+		ModerationReason: r.ModerationReason,
+		Reply:            r.Reply,
+	}
+	if r.ReplyDate != nil {
+		reviewDTO.ReplyDate = r.ReplyDate.Format(DateFormat)
 	}
+	return reviewDTO
 }
 
 func (r *ReviewDTO) ToModel() (Review, error) {
-	date, err := time.Parse("2006-01-02", r.Date)
+	date, err := ParseDate(r.Date)
 	if err != nil {
 		return Review{}, err
 	}
 	return Review{
-		BookingID: r.BookingID,
-		Comment:   r.Comment,
-		Rating:    r.Rating,
-		Date:      date,
+		BookingID:  r.BookingID,
+		CustomerID: r.CustomerID,
+		Comment:    r.Comment,
+		Rating:     r.Rating,
+		Date:       date,
 	}, nil
 }
 
@@ -59,3 +94,38 @@ func (p ReviewPatch) FromStructToDBAttr() map[string]string {
 		"Date":      "review_date",
 	}
 }
+
+// ReviewStats aggregates the reviews of a group: a room number, a room type or a month (YYYY-MM)
+type ReviewStats struct {
+	Group         string      `json:"group"`
+	Reviews       int         `json:"reviews"`
+	AverageRating float64     `json:"average_rating"`
+	Distribution  map[int]int `json:"distribution"` // rating -> number of reviews
+}
+
+// ReviewKeyword is a word recurring in the review comments
+type ReviewKeyword struct {
+	Keyword       string  `json:"keyword"`
+	Mentions      int     `json:"mentions"`
+	AverageRating float64 `json:"average_rating"`
+	Sentiment     float64 `json:"sentiment"` // average sentiment of the reviews mentioning it, from -1 to 1
+}
+
+const ReviewRejectionValidationError = `Invalid review rejection:
+- String field 'reason' is required and must be at most 256 characters`
+
+const ReviewReplyValidationError = `Invalid review reply:
+- String field 'reply' is required and must be at most 1024 characters`
+
+const ReviewStatusValidationError = `Invalid review status:
+- Query parameter 'status' must be one of: pending, published, rejected, all`
+
+const ReviewViewValidationError = `Invalid review view:
+- Query parameter 'view' must be: moderation`
+
+const ReviewStatsValidationError = `Invalid review stats parameters:
+- Query parameter 'group_by' must be one of: room, room_type, month`
+
+const ReviewKeywordsValidationError = `Invalid review keywords parameters:
+- Query parameter 'sentiment' must be one of: positive, negative
+- Query parameter 'limit' must be a number between 1 and 100`
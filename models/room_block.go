@@ -0,0 +1,86 @@
+package models
+
+import "time"
+
+// RoomBlockDTO takes a room out of order, like a booking the room is free again on EndDate
+type RoomBlockDTO struct {
+	ID        int    `json:"id,omitempty"`
+	RoomID    int    `json:"room_id,omitempty"` // taken from the path
+	StartDate string `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate   string `json:"end_date" validate:"required,datetime=2006-01-02"`
+	Reason    string `json:"reason" validate:"required,max=256"`
+	// set by the server, active bookings overlapping the block that must be relocated
+	Conflicts []BookingDTO `json:"conflicts,omitempty"`
+}
+
+type RoomBlock struct {
+	ID        int
+	RoomID    int
+	StartDate time.Time
+	EndDate   time.Time
+	Reason    string
+}
+
+type RoomBlockConflictDTO struct {
+	Block   RoomBlockDTO `json:"block"`
+	Booking BookingDTO   `json:"booking"`
+}
+
+// RoomBlockConflict is an active booking in a room that is blocked for part of the stay
+type RoomBlockConflict struct {
+	Block   RoomBlock
+	Booking Booking
+}
+
+type AvailabilityParamsDTO struct {
+	StartDate string `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate   string `json:"end_date" validate:"required,datetime=2006-01-02"`
+	Type      string `json:"type" validate:"omitempty,oneof=basic suite"`
+	Guests    int    `json:"guests" validate:"omitempty,gt=0"`
+}
+
+const RoomBlockValidationError = `Invalid room block data:
+- String field 'start_date' is required and must be in YYYY-MM-DD format
+- String field 'end_date' is required and must be in YYYY-MM-DD format, the room is available again on end_date
+- String field 'reason' is required and must be at most 256 characters`
+
+const AvailabilityValidationError = `Invalid availability parameters:
+- Query parameter 'start_date' is required and must be in YYYY-MM-DD format
+- Query parameter 'end_date' is required and must be in YYYY-MM-DD format
+- Query parameter 'type' can be one of: basic, suite
+- Query parameter 'guests' must be a number greater than 0`
+
+func (b *RoomBlock) ToDTO() RoomBlockDTO {
+	return RoomBlockDTO{
+		ID:        b.ID,
+		RoomID:    b.RoomID,
+		StartDate: b.StartDate.Format(DateFormat),
+		EndDate:   b.EndDate.Format(DateFormat),
+		Reason:    b.Reason,
+	}
+}
+
+func (c *RoomBlockConflict) ToDTO() RoomBlockConflictDTO {
+	return RoomBlockConflictDTO{
+		Block:   c.Block.ToDTO(),
+		Booking: c.Booking.ToDTO(),
+	}
+}
+
+func (b *RoomBlockDTO) ToModel() (RoomBlock, error) {
+	startDate, err := ParseDate(b.StartDate)
+	if err != nil {
+		return RoomBlock{}, err
+	}
+	endDate, err := ParseDate(b.EndDate)
+	if err != nil {
+		return RoomBlock{}, err
+	}
+	return RoomBlock{
+		ID:        b.ID,
+		RoomID:    b.RoomID,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Reason:    b.Reason,
+	}, nil
+}
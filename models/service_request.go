@@ -7,6 +7,8 @@ type ServiceRequestDTO struct {
 	CustomerID int    `json:"customer_id" validate:"required"`
 	ServiceID  int    `json:"service_id" validate:"required"`
 	Date       string `json:"date" validate:"required,datetime=2006-01-02"`
+	StartTime  string `json:"start_time" validate:"required,datetime=15:04"`
+	StaffID    *int   `json:"staff_id,omitempty"` // assigned by the server
 }
 
 type ServiceRequest struct {
@@ -14,30 +16,40 @@ type ServiceRequest struct {
 	CustomerID int
 	ServiceID  int
 	Date       time.Time
+	StartTime  time.Duration // offset from midnight
+	StaffID    *int          // staff member assigned to the request, if any
 }
 
 type ServiceRequestPatch struct {
 	CustomerID *int    `json:"customer_id,omitempty"`
 	ServiceID  *int    `json:"service_id,omitempty"`
 	Date       *string `json:"date,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	StartTime  *string `json:"start_time,omitempty" validate:"omitempty,datetime=15:04"`
 }
 
 const ServiceRequestValidationError = `Invalid service request data:
 - Integer field 'customer_id' is required
 - Integer field 'service_id' is required
-- String field 'date' is required and must be in YYYY-MM-DD format`
+- String field 'date' is required and must be in YYYY-MM-DD format
+- String field 'start_time' is required and must be in HH:MM format`
 
 func (s *ServiceRequest) ToDTO() ServiceRequestDTO {
 	return ServiceRequestDTO{
 		ID:         s.ID,
 		CustomerID: s.CustomerID,
 		ServiceID:  s.ServiceID,
-		Date:       s.Date.Format("2006-01-02"),
+		Date:       s.Date.Format(DateFormat),
+		StartTime:  FormatClock(s.StartTime),
+		StaffID:    s.StaffID,
 	}
 }
 
 func (s *ServiceRequestDTO) ToModel() (ServiceRequest, error) {
-	date, err := time.Parse("2006-01-02", s.Date)
+	date, err := ParseDate(s.Date)
+	if err != nil {
+		return ServiceRequest{}, err
+	}
+	startTime, err := ParseClock(s.StartTime)
 	if err != nil {
 		return ServiceRequest{}, err
 	}
@@ -46,6 +58,7 @@ func (s *ServiceRequestDTO) ToModel() (ServiceRequest, error) {
 		CustomerID: s.CustomerID,
 		ServiceID:  s.ServiceID,
 		Date:       date,
+		StartTime:  startTime,
 	}, nil
 }
 
@@ -54,5 +67,6 @@ func (p ServiceRequestPatch) FromStructToDBAttr() map[string]string {
 		"CustomerID": "customer_id",
 		"ServiceID":  "service_id",
 		"Date":       "service_date",
+		"StartTime":  "start_time",
 	}
 }
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+type Staff struct {
+	ID     int      `json:"id,omitempty"`
+	Name   string   `json:"name" validate:"required"`
+	Email  string   `json:"email" validate:"required,email"`
+	Skills []string `json:"skills" validate:"required,min=1,unique,dive,oneof=cleaning room_service massage"` // hotel service types the staff member can perform
+}
+
+type StaffShiftDTO struct {
+	ID        int    `json:"id,omitempty"`
+	StaffID   int    `json:"staff_id,omitempty"` // taken from the path
+	Date      string `json:"date" validate:"required,datetime=2006-01-02"`
+	StartTime string `json:"start_time" validate:"required,datetime=15:04"`
+	EndTime   string `json:"end_time" validate:"required,datetime=15:04"`
+}
+
+type StaffShift struct {
+	ID        int
+	StaffID   int
+	Date      time.Time
+	StartTime time.Duration // offset from midnight
+	EndTime   time.Duration
+}
+
+// StaffSchedule lists the shifts of a staff member and the service requests assigned to them
+type StaffSchedule struct {
+	StaffID     int                 `json:"staff_id"`
+	Shifts      []StaffShiftDTO     `json:"shifts"`
+	Assignments []ServiceRequestDTO `json:"assignments"`
+}
+
+type StaffAssignmentDTO struct {
+	StaffID int `json:"staff_id" validate:"required"`
+}
+
+const StaffValidationError = `Invalid staff data:
+- String field 'name' is required
+- String field 'email' is required and must be a valid email address
+- Array field 'skills' is required, must not contain duplicates and each skill must be one of: cleaning, room_service, massage`
+
+const StaffShiftValidationError = `Invalid shift data:
+- String field 'date' is required and must be in YYYY-MM-DD format
+- String field 'start_time' is required and must be in HH:MM format
+- String field 'end_time' is required and must be in HH:MM format`
+
+const StaffAssignmentValidationError = `Invalid assignment data:
+- Integer field 'staff_id' is required`
+
+// HasSkill reports whether the staff member can perform the given service type
+func (s *Staff) HasSkill(serviceType string) bool {
+	return slices.Contains(s.Skills, serviceType)
+}
+
+func (s *StaffShift) ToDTO() StaffShiftDTO {
+	return StaffShiftDTO{
+		ID:        s.ID,
+		StaffID:   s.StaffID,
+		Date:      s.Date.Format(DateFormat),
+		StartTime: FormatClock(s.StartTime),
+		EndTime:   FormatClock(s.EndTime),
+	}
+}
+
+func (s *StaffShiftDTO) ToModel() (StaffShift, error) {
+	date, err := ParseDate(s.Date)
+	if err != nil {
+		return StaffShift{}, err
+	}
+	startTime, err := ParseClock(s.StartTime)
+	if err != nil {
+		return StaffShift{}, err
+	}
+	endTime, err := ParseClock(s.EndTime)
+	if err != nil {
+		return StaffShift{}, err
+	}
+	return StaffShift{
+		ID:        s.ID,
+		StaffID:   s.StaffID,
+		Date:      date,
+		StartTime: startTime,
+		EndTime:   endTime,
+	}, nil
+}
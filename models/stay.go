@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// BookingSegmentDTO is the part of a stay spent in one room, the guest leaves the room on EndDate
+type BookingSegmentDTO struct {
+	RoomID    int    `json:"room_id"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+type BookingSegment struct {
+	BookingID int
+	RoomID    int
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+type RoomMoveDTO struct {
+	RoomID int    `json:"room_id" validate:"required"`
+	Date   string `json:"date" validate:"omitempty,datetime=2006-01-02"` // first night in the new room, by default today or the start of the stay
+}
+
+type StayChangeDTO struct {
+	EndDate string `json:"end_date" validate:"required,datetime=2006-01-02"`
+}
+
+const RoomMoveValidationError = `Invalid room move data:
+- Integer field 'room_id' is required
+- String field 'date' must be in YYYY-MM-DD format, it is the first night in the new room`
+
+const StayChangeValidationError = `Invalid stay data:
+- String field 'end_date' is required and must be in YYYY-MM-DD format`
+
+func (s *BookingSegment) ToDTO() BookingSegmentDTO {
+	return BookingSegmentDTO{
+		RoomID:    s.RoomID,
+		StartDate: s.StartDate.Format(DateFormat),
+		EndDate:   s.EndDate.Format(DateFormat),
+	}
+}
@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+const (
+	WaitlistWaiting = "waiting"
+	WaitlistOffered = "offered"
+	WaitlistExpired = "expired" // the customer did not book the offered room before the hold expired
+	WaitlistBooked  = "booked"  // the customer booked the offered room
+)
+
+// WaitlistEntryDTO is a customer waiting for a room on fully booked dates
+type WaitlistEntryDTO struct {
+	ID         int    `json:"id,omitempty"`
+	CustomerID int    `json:"customer_id" validate:"required"`
+	RoomType   string `json:"room_type,omitempty" validate:"omitempty,oneof=basic suite"` // any type when empty
+	StartDate  string `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate    string `json:"end_date" validate:"required,datetime=2006-01-02"`
+	Guests     int    `json:"guests" validate:"omitempty,gt=0"` // 1 by default
+	// set by the server, ignored on input
+	Status    string `json:"status,omitempty"`
+	RoomID    *int   `json:"room_id,omitempty"` // the room that became available
+	HoldID    *int   `json:"hold_id,omitempty"` // the room is held for the customer until the hold expires
+	CreatedAt string `json:"created_at,omitempty"`
+	OfferedAt string `json:"offered_at,omitempty"`
+}
+
+type WaitlistEntry struct {
+	ID         int
+	CustomerID int
+	RoomType   string
+	StartDate  time.Time
+	EndDate    time.Time
+	Guests     int
+	Status     string
+	RoomID     *int
+	HoldID     *int
+	CreatedAt  time.Time
+	OfferedAt  *time.Time
+}
+
+const WaitlistValidationError = `Invalid waitlist data:
+- Integer field 'customer_id' is required
+- String field 'room_type' can be one of: basic, suite
+- String field 'start_date' is required and must be in YYYY-MM-DD format
+- String field 'end_date' is required and must be in YYYY-MM-DD format
+- Integer field 'guests' must be greater than 0, 1 by default`
+
+func (e *WaitlistEntry) ToDTO() WaitlistEntryDTO {
+	entryDTO := WaitlistEntryDTO{
+		ID:         e.ID,
+		CustomerID: e.CustomerID,
+		RoomType:   e.RoomType,
+		StartDate:  e.StartDate.Format(DateFormat),
+		EndDate:    e.EndDate.Format(DateFormat),
+		Guests:     e.Guests,
+		Status:     e.Status,
+		RoomID:     e.RoomID,
+		HoldID:     e.HoldID,
+		CreatedAt:  e.CreatedAt.Format(time.RFC3339),
+	}
+	if e.OfferedAt != nil {
+		entryDTO.OfferedAt = e.OfferedAt.Format(time.RFC3339)
+	}
+	return entryDTO
+}
+
+func (e *WaitlistEntryDTO) ToModel() (WaitlistEntry, error) {
+	startDate, err := ParseDate(e.StartDate)
+	if err != nil {
+		return WaitlistEntry{}, err
+	}
+	endDate, err := ParseDate(e.EndDate)
+	if err != nil {
+		return WaitlistEntry{}, err
+	}
+	entry := WaitlistEntry{
+		ID:         e.ID,
+		CustomerID: e.CustomerID,
+		RoomType:   e.RoomType,
+		StartDate:  startDate,
+		EndDate:    endDate,
+		Guests:     e.Guests,
+	}
+	if entry.Guests == 0 {
+		entry.Guests = 1
+	}
+	return entry, nil
+}
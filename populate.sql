@@ -29,6 +29,14 @@ INSERT INTO booking(code, customer_id, room_id, start_date, end_date) VALUES
 ('PR009', 9, 4, '2025-09-20', '2025-09-23'),
 ('PR010', 10, 5, '2025-10-24', '2025-10-26');
 
+INSERT INTO booking_group(code, group_name, lead_customer_id, start_date, end_date, cutoff_date) VALUES
+('GRPCONV', 'Convegno medico', 9, '2025-12-01', '2025-12-04', '2025-11-20');
+
+INSERT INTO booking(code, customer_id, room_id, start_date, end_date, group_id, allotment) VALUES
+('GRPCONV-1', 9, 1, '2025-12-01', '2025-12-04', 1, false),
+('GRPCONV-2', 10, 2, '2025-12-01', '2025-12-04', 1, false),
+('GRPCONV-3', 9, 3, '2025-12-01', '2025-12-04', 1, true);
+
 INSERT INTO review(booking_id, review_comment, rating, review_date) VALUES
 (1, 'Ottima esperienza, stanza pulita e confortevole.', 5, '2025-01-10'),
 (2, 'Suite spaziosa ma un po rumorosa.', 3, '2025-02-15'),
@@ -38,17 +46,41 @@ INSERT INTO review(booking_id, review_comment, rating, review_date) VALUES
 (6, 'Pulizia non perfetta, ma buona posizione.', 3, '2025-06-18'),
 (7, 'Servizio eccellente, ci tornerò sicuramente.', 5, '2025-07-22');
 
-INSERT INTO hotel_service(service_type, description, duration) VALUES
-('cleaning', 'Servizio di pulizia della stanza.', 90),
-('room_service', 'Servizio di ordinazione cibo in camera.', 30),
-('massage', 'Massaggio rilassante in camera.', 60);
-
-INSERT INTO service_request(customer_id, service_id, service_date) VALUES
-(1, 1, '2025-10-06'),
-(2, 3, '2025-01-11'),
-(3, 2, '2025-02-12'),
-(4, 3, '2025-03-02'),
-(5, 1, '2025-04-12'),
-(6, 2, '2025-05-08'),
-(7, 3, '2025-06-16'),
-(8, 1, '2025-07-20');
\ No newline at end of file
+INSERT INTO hotel_service(service_type, description, duration, opening_time, closing_time, capacity) VALUES
+('cleaning', 'Servizio di pulizia della stanza.', 90, '08:00', '18:00', 3),
+('room_service', 'Servizio di ordinazione cibo in camera.', 30, '07:00', '23:00', 4),
+('massage', 'Massaggio rilassante in camera.', 60, '10:00', '20:00', 1);
+
+INSERT INTO staff(staff_name, email) VALUES
+('Giulia Conti', 'giulia.conti@example.com'),
+('Marco Gallo', 'marco.gallo@example.com'),
+('Sara Marino', 'sara.marino@example.com');
+
+INSERT INTO staff_skill(staff_id, service_type) VALUES
+(1, 'cleaning'),
+(2, 'cleaning'),
+(2, 'room_service'),
+(3, 'massage');
+
+INSERT INTO staff_shift(staff_id, shift_date, start_time, end_time) VALUES
+(1, '2025-10-06', '08:00', '16:00'),
+(3, '2025-01-11', '12:00', '20:00'),
+(2, '2025-02-12', '15:00', '23:00'),
+(3, '2025-03-02', '10:00', '18:00'),
+(1, '2025-04-12', '08:00', '16:00'),
+(2, '2025-05-08', '07:00', '15:00'),
+(3, '2025-06-16', '12:00', '20:00'),
+(2, '2025-07-20', '10:00', '18:00');
+
+INSERT INTO service_request(customer_id, service_id, service_date, start_time, staff_id) VALUES
+(1, 1, '2025-10-06', '09:30', 1),
+(2, 3, '2025-01-11', '15:00', 3),
+(3, 2, '2025-02-12', '20:00', 2),
+(4, 3, '2025-03-02', '11:00', 3),
+(5, 1, '2025-04-12', '10:00', 1),
+(6, 2, '2025-05-08', '08:30', 2),
+(7, 3, '2025-06-16', '17:00', 3),
+(8, 1, '2025-07-20', '14:00', 2);
+
+INSERT INTO room_block(room_id, start_date, end_date, reason) VALUES
+(3, '2025-11-03', '2025-11-07', 'Rifacimento del bagno.');
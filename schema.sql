@@ -1,16 +1,26 @@
-DROP TABLE IF EXISTS customer, room, booking, review, hotel_service, service_request;
-DROP TYPE IF EXISTS room_types, hotel_services;
+DROP VIEW IF EXISTS room_stay;
+DROP TABLE IF EXISTS customer, room, booking_group, booking, booking_occupant, review, hotel_service, service_request, staff, staff_skill, staff_shift, housekeeping_task, room_block, booking_segment, folio_charge, booking_hold, waitlist_entry, notification, scheduled_job, job_run, business_day, night_audit;
+DROP TYPE IF EXISTS room_types, hotel_services, review_status, booking_status, housekeeping_status, housekeeping_task_type, group_status, waitlist_status, notification_status, job_run_status;
 
 -- Enum Types
 CREATE TYPE room_types AS ENUM ('basic', 'suite');
 CREATE TYPE hotel_services AS ENUM ('cleaning', 'room_service', 'massage');
+CREATE TYPE review_status AS ENUM ('pending', 'published', 'rejected');
+CREATE TYPE booking_status AS ENUM ('confirmed', 'checked_in', 'checked_out', 'cancelled', 'no_show');
+CREATE TYPE housekeeping_status AS ENUM ('clean', 'dirty', 'inspected', 'out_of_service');
+CREATE TYPE housekeeping_task_type AS ENUM ('departure', 'stay_over');
+CREATE TYPE group_status AS ENUM ('active', 'cancelled');
+CREATE TYPE waitlist_status AS ENUM ('waiting', 'offered', 'expired', 'booked');
+CREATE TYPE notification_status AS ENUM ('pending', 'sent', 'failed');
+CREATE TYPE job_run_status AS ENUM ('running', 'succeeded', 'failed');
 
 CREATE TABLE customer(
     id int generated always as identity primary key,
     cf varchar(16),
     customer_name varchar(16),
     age int check (age > 0),
-    email varchar(30)
+    email varchar(30),
+    lang varchar(2) -- language of the emails, English when null
 );
 
 CREATE TABLE room(
@@ -18,7 +28,20 @@ CREATE TABLE room(
     room_number int,
     room_type room_types,
     price int check (price > 0),
-    capacity int check (capacity > 0)
+    capacity int check (capacity > 0),
+    housekeeping_status housekeeping_status not null default 'clean'
+);
+
+CREATE TABLE booking_group(
+    id int generated always as identity primary key,
+    code varchar(12) unique,
+    group_name varchar(64),
+    lead_customer_id int references customer(id),
+    start_date date,
+    end_date date,
+    cutoff_date date, -- the rooms without a guest are released on this date
+    status group_status not null default 'active',
+    constraint valid_group_dates check (start_date < end_date)
 );
 
 CREATE TABLE booking(
@@ -28,21 +51,69 @@ CREATE TABLE booking(
     room_id int references room(id),
     start_date date,
     end_date date,
+    status booking_status not null default 'confirmed',
+    checked_in_at timestamptz,
+    checked_out_at timestamptz,
+    group_id int references booking_group(id),
+    allotment boolean not null default false, -- room held for a group, the guest is not known yet
+    adults int not null default 1 check (adults > 0),
+    children int not null default 0 check (children >= 0),
     constraint valid_dates check (start_date < end_date)
 );
 
+-- the guests staying in the room, registered at check-in for the police guest reporting
+CREATE TABLE booking_occupant(
+    id int generated always as identity primary key,
+    booking_id int references booking(id) on delete cascade,
+    first_name varchar(32) not null,
+    last_name varchar(32) not null,
+    birth_date date not null,
+    nationality char(2) not null,
+    document_type varchar(16) not null default '',
+    document_number varchar(32) not null default ''
+);
+
 CREATE TABLE review(
     booking_id int references booking (id) primary key,
     review_comment varchar(512),
     rating int check (rating >= 1 and rating <= 5),
-    review_date date
+    review_date date,
+    status review_status not null default 'published',
+    moderation_reason varchar(256), -- why the review is pending or rejected
+    reply varchar(1024), -- public reply of the hotel staff
+    reply_date date
 );
 
 CREATE TABLE hotel_service(
     id int generated always as identity primary key,
     service_type hotel_services unique,
     description varchar(512),
-    duration int check (duration > 0) -- duration in minutes
+    duration int check (duration > 0), -- duration in minutes
+    opening_time time not null default '08:00',
+    closing_time time not null default '20:00',
+    capacity int not null default 1 check (capacity > 0), -- requests that can be served at the same time
+    constraint valid_opening_hours check (opening_time < closing_time)
+);
+
+CREATE TABLE staff(
+    id int generated always as identity primary key,
+    staff_name varchar(32),
+    email varchar(30)
+);
+
+CREATE TABLE staff_skill(
+    staff_id int references staff(id) on delete cascade,
+    service_type hotel_services,
+    primary key (staff_id, service_type)
+);
+
+CREATE TABLE staff_shift(
+    id int generated always as identity primary key,
+    staff_id int references staff(id) on delete cascade,
+    shift_date date,
+    start_time time not null,
+    end_time time not null,
+    constraint valid_shift check (start_time < end_time)
 );
 
 CREATE TABLE service_request(
@@ -50,5 +121,137 @@ CREATE TABLE service_request(
     customer_id int references customer(id),
     service_id int references hotel_service(id),
     service_date date,
-    unique (customer_id, service_id, service_date)
-);
\ No newline at end of file
+    start_time time not null,
+    staff_id int references staff(id) on delete set null, -- staff member assigned to the request
+    unique (customer_id, service_id, service_date, start_time)
+);
+
+CREATE TABLE housekeeping_task(
+    id int generated always as identity primary key,
+    room_id int references room(id) on delete cascade,
+    booking_id int references booking(id) on delete cascade,
+    task_date date not null,
+    task_type housekeeping_task_type not null,
+    staff_id int references staff(id) on delete set null,
+    completed_at timestamptz,
+    unique (room_id, task_date)
+);
+
+CREATE TABLE room_block(
+    id int generated always as identity primary key,
+    room_id int references room(id) on delete cascade,
+    start_date date not null,
+    end_date date not null, -- the room is available again on end_date
+    reason varchar(256) not null,
+    constraint valid_block_dates check (start_date < end_date)
+);
+
+-- the rooms of a booking when the guest moved during the stay, bookings without segments stay in booking.room_id
+CREATE TABLE booking_segment(
+    id int generated always as identity primary key,
+    booking_id int references booking(id) on delete cascade,
+    room_id int references room(id),
+    start_date date not null,
+    end_date date not null,
+    constraint valid_segment_dates check (start_date < end_date)
+);
+
+-- every room occupied by a booking and when
+CREATE VIEW room_stay AS
+SELECT s.booking_id, s.room_id, s.start_date, s.end_date, b.status
+FROM booking_segment s JOIN booking b ON b.id = s.booking_id
+UNION ALL
+SELECT b.id, b.room_id, b.start_date, b.end_date, b.status
+FROM booking b WHERE NOT EXISTS (SELECT 1 FROM booking_segment s WHERE s.booking_id = b.id);
+
+CREATE TABLE folio_charge(
+    id int generated always as identity primary key,
+    booking_id int references booking(id) on delete cascade,
+    charge_date date not null,
+    charge_type varchar(16) not null,
+    description varchar(128),
+    amount int not null,
+    posted_at timestamptz -- charges that are not posted yet are re-priced when the stay changes
+);
+
+-- rooms reserved for a short time, e.g. while the guest pays, expired holds do not count and are cleaned up
+CREATE TABLE booking_hold(
+    id int generated always as identity primary key,
+    room_id int references room(id) on delete cascade,
+    customer_id int references customer(id) on delete cascade,
+    start_date date not null,
+    end_date date not null,
+    expires_at timestamptz not null,
+    constraint valid_hold_dates check (start_date < end_date)
+);
+
+-- customers waiting for a room on fully booked dates, served in order of creation
+CREATE TABLE waitlist_entry(
+    id int generated always as identity primary key,
+    customer_id int references customer(id) on delete cascade,
+    room_type room_types, -- any type when null
+    start_date date not null,
+    end_date date not null,
+    guests int not null default 1 check (guests > 0),
+    status waitlist_status not null default 'waiting',
+    room_id int references room(id) on delete set null, -- the room offered when it became available
+    hold_id int references booking_hold(id) on delete set null,
+    created_at timestamptz not null default now(),
+    offered_at timestamptz,
+    constraint valid_waitlist_dates check (start_date < end_date)
+);
+
+-- send log of the emails to the customers
+CREATE TABLE notification(
+    id int generated always as identity primary key,
+    customer_id int references customer(id) on delete cascade,
+    booking_id int references booking(id) on delete cascade,
+    kind varchar(32) not null,
+    recipient varchar(30) not null,
+    lang varchar(2) not null,
+    subject text not null,
+    body text not null,
+    status notification_status not null default 'pending', -- pending until the send-notifications job sends it
+    error text,
+    created_at timestamptz not null default now(),
+    sent_at timestamptz
+);
+
+-- state of the background jobs shared by all the replicas, the jobs themselves are defined in the code
+CREATE TABLE scheduled_job(
+    job_name varchar(64) primary key,
+    paused boolean not null default false,
+    next_run_at timestamptz
+);
+
+CREATE TABLE job_run(
+    id int generated always as identity primary key,
+    job_name varchar(64) not null,
+    run_trigger varchar(16) not null,
+    status job_run_status not null default 'running',
+    result text,
+    error text,
+    started_at timestamptz not null default now(),
+    finished_at timestamptz
+);
+
+-- the day the hotel is working on, the night audit closes it and moves to the next one, until the first audit
+-- it is today in the timezone of the hotel
+CREATE TABLE business_day(
+    singleton boolean primary key default true check (singleton),
+    business_date date not null
+);
+
+-- summary of every closed business day
+CREATE TABLE night_audit(
+    business_date date primary key,
+    closed_at timestamptz not null default now(),
+    arrivals int not null,
+    departures int not null,
+    in_house int not null,
+    no_shows int not null,
+    charges_posted int not null,
+    rooms_occupied int not null,
+    rooms_total int not null,
+    room_revenue int not null
+);
@@ -5,51 +5,139 @@ import (
 	"errors"
 	"example/dal"
 	"example/models"
+	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func GetAllBookings(ctx context.Context, conn *pgx.Conn) ([]models.Booking, error) {
+	ctx, span := startSpan(ctx, "GetAllBookings")
+	defer span.End()
 	return dal.GetAllBookings(ctx, conn)
 }
 
 func GetBookingByID(ctx context.Context, conn *pgx.Conn, bookingID int) (*models.Booking, error) {
+	ctx, span := startSpan(ctx, "GetBookingByID", attribute.Int("booking.id", bookingID))
+	defer span.End()
 	return dal.GetBookingByID(ctx, conn, bookingID)
 }
 
+// GetBookingByCode looks up a booking by its confirmation code, generated codes are matched ignoring case, spaces
+// and dashes, and one with a wrong check character is reported as mistyped
+func GetBookingByCode(ctx context.Context, conn *pgx.Conn, code string) (*models.Booking, error) {
+	ctx, span := startSpan(ctx, "GetBookingByCode", attribute.String("booking.code", code))
+	defer span.End()
+	booking, err := dal.GetBookingByCode(ctx, conn, code)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return booking, err
+	}
+	chars, ok := bookingCodeFormat.characters(code)
+	if !ok {
+		return nil, err
+	}
+	if checkCharacter(chars[:len(chars)-1]) != chars[len(chars)-1] {
+		return nil, models.ValidationError{Code: "invalid_booking_code", Message: "the booking code is mistyped, its check character does not match"}
+	}
+	return dal.GetBookingByCode(ctx, conn, bookingCodeFormat.Prefix+bookingCodeFormat.group(chars))
+}
+
+// CreateBooking saves a booking, a booking made with a hold takes its room and removes it
 func CreateBooking(ctx context.Context, conn *pgx.Conn, booking *models.Booking) error {
-	err := validateBooking(ctx, conn, booking)
+	ctx, span := startSpan(ctx, "CreateBooking")
+	defer span.End()
+	if booking.Code == "" {
+		code, err := newBookingCode(ctx, conn)
+		if err != nil {
+			return err
+		}
+		booking.Code = code
+	}
+	if booking.HoldID != 0 {
+		err := useHold(ctx, conn, booking)
+		if err != nil {
+			return err
+		}
+	}
+	err := validateBooking(ctx, conn, booking, nil)
 	if err != nil {
 		return err
 	}
-	return dal.CreateBooking(ctx, conn, booking)
+	err = dal.CreateBooking(ctx, conn, booking)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// the hold expired or was deleted since it was checked
+			return models.ValidationError{Code: "hold_expired", Message: "the hold does not exist or has expired"}
+		}
+		return err
+	}
+	emitEvent(ctx, conn, models.Event{Type: models.EventBookingConfirmed, CustomerID: booking.CustomerID, Payload: *booking})
+	return nil
 }
 
 func UpdateBookingByID(ctx context.Context, conn *pgx.Conn, booking *models.Booking) (int, error) {
-	err := validateBooking(ctx, conn, booking)
+	ctx, span := startSpan(ctx, "UpdateBookingByID")
+	defer span.End()
+	booking.HoldID = 0
+	oldBooking, err := dal.GetBookingByID(ctx, conn, booking.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+	// without a code the booking keeps its own, or gets a new one when it is created
+	if booking.Code == "" && oldBooking != nil {
+		booking.Code = oldBooking.Code
+	}
+	if booking.Code == "" {
+		booking.Code, err = newBookingCode(ctx, conn)
+		if err != nil {
+			return 0, err
+		}
+	}
+	segments, err := bookingRooms(ctx, conn, oldBooking, booking)
 	if err != nil {
 		return 0, err
 	}
-	_, err = dal.GetBookingByID(ctx, conn, booking.ID)
+	err = validateBooking(ctx, conn, booking, segments)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return http.StatusCreated, dal.CreateBooking(ctx, conn, booking)
+		return 0, err
+	}
+	if oldBooking == nil {
+		err = dal.CreateBooking(ctx, conn, booking)
+		if err != nil {
+			return 0, err
+		}
+		emitEvent(ctx, conn, models.Event{Type: models.EventBookingConfirmed, CustomerID: booking.CustomerID, Payload: *booking})
+		return http.StatusCreated, nil
+	}
+	// a new room or new dates replace the rooms of a guest that moved during the stay
+	if oldBooking.RoomID != booking.RoomID || !oldBooking.StartDate.Equal(booking.StartDate) || !oldBooking.EndDate.Equal(booking.EndDate) {
+		err = dal.DeleteBookingSegments(ctx, conn, booking.ID)
+		if err != nil {
+			return 0, err
 		}
+	}
+	err = dal.UpdateBookingByID(ctx, conn, booking)
+	if err != nil {
 		return 0, err
 	}
-	return http.StatusOK, dal.UpdateBookingByID(ctx, conn, booking)
+	return http.StatusOK, dal.RepriceFolio(ctx, conn, booking.ID)
 }
 
 func PatchBookingByID(ctx context.Context, conn *pgx.Conn, bookingID int, patch models.BookingPatch) error {
+	ctx, span := startSpan(ctx, "PatchBookingByID", attribute.Int("booking.id", bookingID))
+	defer span.End()
 	// first check that the patch is valid
 	oldBooking, err := dal.GetBookingByID(ctx, conn, bookingID)
 	if err != nil {
 		return err
 	}
+	stored := *oldBooking
 
 	if patch.Code != nil {
+		if *patch.Code == "" {
+			return models.ValidationError{Message: "booking code cannot be empty"}
+		}
 		oldBooking.Code = *patch.Code
 	}
 	if patch.CustomerID != nil {
@@ -59,38 +147,186 @@ func PatchBookingByID(ctx context.Context, conn *pgx.Conn, bookingID int, patch
 		oldBooking.RoomID = *patch.RoomID
 	}
 	if patch.StartDate != nil {
-		startDate, err := time.Parse("2006-01-02", *patch.StartDate)
+		startDate, err := models.ParseDate(*patch.StartDate)
 		if err != nil {
 			return models.ValidationError{Message: "start date must be in YYYY-MM-DD format"}
 		}
 		oldBooking.StartDate = startDate
 	}
 	if patch.EndDate != nil {
-		endDate, err := time.Parse("2006-01-02", *patch.EndDate)
+		endDate, err := models.ParseDate(*patch.EndDate)
 		if err != nil {
 			return models.ValidationError{Message: "end date must be in YYYY-MM-DD format"}
 		}
 		oldBooking.EndDate = endDate
 	}
-	err = validateBooking(ctx, conn, oldBooking)
+	if patch.Adults != nil {
+		oldBooking.Adults = *patch.Adults
+	}
+	if patch.Children != nil {
+		oldBooking.Children = *patch.Children
+	}
+	segments, err := bookingRooms(ctx, conn, &stored, oldBooking)
+	if err != nil {
+		return err
+	}
+	err = validateBooking(ctx, conn, oldBooking, segments)
 	if err != nil {
 		return err
 	}
 
-	return dal.PatchBookingByID(ctx, conn, bookingID, patch)
+	if patch.RoomID != nil || patch.StartDate != nil || patch.EndDate != nil {
+		err = dal.DeleteBookingSegments(ctx, conn, bookingID)
+		if err != nil {
+			return err
+		}
+	}
+	err = dal.PatchBookingByID(ctx, conn, bookingID, patch)
+	if err != nil {
+		return err
+	}
+	return dal.RepriceFolio(ctx, conn, bookingID)
+}
+
+// UpdateBookingOccupants registers the guests of a booking before the arrival
+func UpdateBookingOccupants(ctx context.Context, conn *pgx.Conn, bookingID int, occupants []models.Occupant) (*models.Booking, error) {
+	ctx, span := startSpan(ctx, "UpdateBookingOccupants", attribute.Int("booking.id", bookingID))
+	defer span.End()
+	booking, err := dal.GetBookingByID(ctx, conn, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Status != models.BookingConfirmed && booking.Status != models.BookingCheckedIn {
+		return nil, models.ValidationError{Code: "invalid_booking_status", Message: "occupants can be changed only for confirmed and checked in bookings, booking is " + booking.Status}
+	}
+	if len(occupants) > booking.Guests() {
+		return nil, models.ValidationError{Code: "too_many_occupants", Message: fmt.Sprintf("the booking is for %d guests", booking.Guests())}
+	}
+	booking.Occupants = occupants
+	return booking, dal.UpdateBookingOccupants(ctx, conn, booking.ID, occupants)
+}
+
+// CheckInBooking checks in a booking once all its guests are registered, the occupants given at the desk
+// replace the ones registered before
+func CheckInBooking(ctx context.Context, conn *pgx.Conn, bookingID int, occupants []models.Occupant) (*models.Booking, error) {
+	ctx, span := startSpan(ctx, "CheckInBooking", attribute.Int("booking.id", bookingID))
+	defer span.End()
+	booking, err := dal.GetBookingByID(ctx, conn, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Status != models.BookingConfirmed {
+		return nil, models.ValidationError{Code: "invalid_booking_status", Message: "only confirmed bookings can be checked in, booking is " + booking.Status}
+	}
+	now := Now()
+	today := models.DateOf(now, location)
+	if today.Before(booking.StartDate) {
+		return nil, models.ValidationError{Code: "check_in_too_early", Message: "check-in is possible from the booking start date"}
+	}
+	if !today.Before(booking.EndDate) {
+		return nil, models.ValidationError{Code: "booking_ended", Message: "the booking has already ended"}
+	}
+	if occupants == nil {
+		occupants = booking.Occupants
+	}
+	if len(occupants) != booking.Guests() {
+		return nil, models.ValidationError{Code: "occupants_missing", Message: fmt.Sprintf("all the %d guests must be registered at check-in, %d are", booking.Guests(), len(occupants))}
+	}
+	for _, occupant := range occupants {
+		if occupant.AgeOn(today) >= 18 && occupant.DocumentNumber == "" {
+			return nil, models.ValidationError{Code: "document_missing", Message: fmt.Sprintf("an identity document is required for %s %s", occupant.FirstName, occupant.LastName)}
+		}
+	}
+	err = dal.UpdateBookingOccupants(ctx, conn, booking.ID, occupants)
+	if err != nil {
+		return nil, err
+	}
+	booking.Occupants = occupants
+	booking.Status = models.BookingCheckedIn
+	booking.CheckedInAt = &now
+	return booking, dal.UpdateBookingStatus(ctx, conn, booking)
+}
+
+func CheckOutBooking(ctx context.Context, conn *pgx.Conn, bookingID int) (*models.Booking, error) {
+	ctx, span := startSpan(ctx, "CheckOutBooking", attribute.Int("booking.id", bookingID))
+	defer span.End()
+	booking, err := dal.GetBookingByID(ctx, conn, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Status != models.BookingCheckedIn {
+		return nil, models.ValidationError{Code: "invalid_booking_status", Message: "only checked in bookings can be checked out, booking is " + booking.Status}
+	}
+	now := Now()
+	booking.Status = models.BookingCheckedOut
+	booking.CheckedOutAt = &now
+	err = dal.UpdateBookingStatus(ctx, conn, booking)
+	if err != nil {
+		return nil, err
+	}
+	err = markRoomDirty(ctx, conn, booking.RoomID)
+	if err != nil {
+		return nil, err
+	}
+	// invite the guest to review the stay
+	emitEvent(ctx, conn, models.Event{Type: models.EventBookingCheckedOut, CustomerID: booking.CustomerID, Payload: *booking})
+	return booking, nil
+}
+
+func CancelBookingByID(ctx context.Context, conn *pgx.Conn, bookingID int) (*models.Booking, error) {
+	ctx, span := startSpan(ctx, "CancelBookingByID", attribute.Int("booking.id", bookingID))
+	defer span.End()
+	booking, err := dal.GetBookingByID(ctx, conn, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Status != models.BookingConfirmed {
+		return nil, models.ValidationError{Code: "invalid_booking_status", Message: "only confirmed bookings can be cancelled, booking is " + booking.Status}
+	}
+	booking.Status = models.BookingCancelled
+	err = dal.UpdateBookingStatus(ctx, conn, booking)
+	if err != nil {
+		return nil, err
+	}
+	err = dal.RepriceFolio(ctx, conn, booking.ID)
+	if err != nil {
+		return nil, err
+	}
+	emitEvent(ctx, conn, models.Event{Type: models.EventBookingCancelled, CustomerID: booking.CustomerID, Payload: *booking})
+	// the freed room may be what a waiting customer is looking for
+	matchWaitlist(ctx, conn)
+	return booking, nil
 }
 
 func DeleteBookingByID(ctx context.Context, conn *pgx.Conn, bookingID int) error {
+	ctx, span := startSpan(ctx, "DeleteBookingByID", attribute.Int("booking.id", bookingID))
+	defer span.End()
 	return dal.DeleteBookingByID(ctx, conn, bookingID)
 }
 
-func validateBooking(ctx context.Context, conn *pgx.Conn, booking *models.Booking) error {
+// bookingRooms returns the rooms of a booking being updated: the ones it stays in when neither its room nor its dates
+// change, a guest that moved during the stay has several. It returns nil for a new booking and for a booking that
+// changes room or dates, it is validated against its room from start to end.
+func bookingRooms(ctx context.Context, conn *pgx.Conn, oldBooking, booking *models.Booking) ([]models.BookingSegment, error) {
+	if oldBooking == nil || oldBooking.RoomID != booking.RoomID || !oldBooking.StartDate.Equal(booking.StartDate) || !oldBooking.EndDate.Equal(booking.EndDate) {
+		return nil, nil
+	}
+	return dal.GetBookingSegments(ctx, conn, booking.ID)
+}
+
+// validateBooking checks a booking that stays in the rooms of segments, or in its room from start to end when
+// segments is empty
+func validateBooking(ctx context.Context, conn *pgx.Conn, booking *models.Booking, segments []models.BookingSegment) error {
 	if booking.StartDate.After(booking.EndDate) {
 		return models.ValidationError{Message: "start date must be before end date"}
 	}
-	// cannot create or update bookings in the past
-	if booking.StartDate.Before(time.Now()) {
-		return models.ValidationError{Message: "start date must be in the future"}
+	// cannot create or update bookings in the past, the day is over only after the night audit
+	businessDate, err := BusinessDate(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if booking.StartDate.Before(businessDate) {
+		return models.ValidationError{Message: "start date cannot be before the business date " + businessDate.Format(models.DateFormat)}
 	}
 	startYear, startMonth, startDay := booking.StartDate.Date()
 	endYear, endMonth, endDay := booking.EndDate.Date()
@@ -98,37 +334,83 @@ func validateBooking(ctx context.Context, conn *pgx.Conn, booking *models.Bookin
 		return models.ValidationError{Message: "start date and end date cannot be the same"}
 	}
 
-	_, err := dal.GetCustomerByID(ctx, conn, booking.CustomerID)
+	_, err = dal.GetCustomerByID(ctx, conn, booking.CustomerID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.ValidationError{Message: "customer does not exist"}
 		}
 		return err
 	}
-	_, err = dal.GetRoomByID(ctx, conn, booking.RoomID)
+	if len(booking.Occupants) > booking.Guests() {
+		return models.ValidationError{Code: "too_many_occupants", Message: fmt.Sprintf("the booking is for %d guests", booking.Guests())}
+	}
+	sameCode, err := dal.GetBookingByCode(ctx, conn, booking.Code)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	// different ID for update case
+	if sameCode != nil && sameCode.ID != booking.ID {
+		return models.ValidationError{Message: "booking code already exists"}
+	}
+
+	if len(segments) == 0 {
+		segments = []models.BookingSegment{{BookingID: booking.ID, RoomID: booking.RoomID, StartDate: booking.StartDate, EndDate: booking.EndDate}}
+	}
+	for _, segment := range segments {
+		err = validateBookingRoom(ctx, conn, booking, segment)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateBookingRoom checks that the room of a segment hosts the guests of the booking and is free for its nights
+func validateBookingRoom(ctx context.Context, conn *pgx.Conn, booking *models.Booking, segment models.BookingSegment) error {
+	room, err := dal.GetRoomByID(ctx, conn, segment.RoomID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.ValidationError{Message: "room does not exist"}
 		}
 		return err
 	}
-
-	// check for overlapping bookings for the same room
-	allBookings, err := dal.GetAllBookings(ctx, conn)
+	if booking.Guests() > room.Capacity {
+		return models.ValidationError{Code: "over_capacity", Message: fmt.Sprintf("the room hosts at most %d guests", room.Capacity)}
+	}
+	blocks, err := dal.GetOverlappingRoomBlocks(ctx, conn, segment.RoomID, segment.StartDate, segment.EndDate)
 	if err != nil {
 		return err
 	}
-	for _, b := range allBookings {
-		// different ID for update case
-		if b.Code == booking.Code && b.ID != booking.ID {
-			return models.ValidationError{Message: "booking code already exists"}
-		}
-		if booking.RoomID == b.RoomID && booking.ID != b.ID {
-			// check for date overlap
-			if booking.StartDate.Before(b.EndDate) && booking.EndDate.After(b.StartDate) {
-				return models.ValidationError{Message: "booking dates overlap with an existing booking for the same room"}
-			}
-		}
+	if len(blocks) > 0 {
+		block := blocks[0]
+		return models.ValidationError{Code: "room_blocked", Message: fmt.Sprintf("the room is out of order from %s to %s: %s", block.StartDate.Format(models.DateFormat), block.EndDate.Format(models.DateFormat), block.Reason)}
+	}
+	// check for overlapping stays in the same room, cancelled and no show bookings free the room
+	stays, err := dal.GetOverlappingStays(ctx, conn, segment.RoomID, segment.StartDate, segment.EndDate, booking.ID)
+	if err != nil {
+		return err
+	}
+	if len(stays) > 0 {
+		return models.ValidationError{Message: "booking dates overlap with an existing booking for the same room"}
+	}
+	holds, err := dal.GetOverlappingHolds(ctx, conn, segment.RoomID, segment.StartDate, segment.EndDate, booking.HoldID, Now())
+	if err != nil {
+		return err
+	}
+	if len(holds) > 0 {
+		return models.ValidationError{Code: "room_held", Message: "the room is held for another guest in that period"}
 	}
 	return nil
 }
+
+// markRoomDirty sets a room as dirty after a guest left it, unless it is out of service
+func markRoomDirty(ctx context.Context, conn *pgx.Conn, roomID int) error {
+	status, err := dal.GetRoomHousekeepingStatus(ctx, conn, roomID)
+	if err != nil {
+		return err
+	}
+	if status == models.HousekeepingOutOfService {
+		return nil
+	}
+	return dal.UpdateRoomHousekeepingStatus(ctx, conn, roomID, models.HousekeepingDirty)
+}
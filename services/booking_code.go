@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"example/dal"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// bookingCodeAlphabet has no characters that are easy to confuse when read aloud or written by hand (0/O, 1/I/L, U/V)
+const bookingCodeAlphabet = "23456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// maxBookingCodeLength is the size of the code column
+const maxBookingCodeLength = 16
+
+// BookingCodeFormat configures the confirmation codes given to the bookings created without one
+type BookingCodeFormat struct {
+	Prefix    string // put in front of every code, e.g. "HB-"
+	Length    int    // random characters, a check character is added after them
+	GroupSize int    // the characters are split in groups of this size by dashes, 0 for a single group
+}
+
+func DefaultBookingCodeFormat() BookingCodeFormat {
+	return BookingCodeFormat{Length: 7, GroupSize: 4}
+}
+
+var bookingCodeFormat = DefaultBookingCodeFormat()
+
+// SetBookingCodeFormat changes the format of the new codes, the codes already given keep working
+func SetBookingCodeFormat(format BookingCodeFormat) error {
+	if format.Length < 4 {
+		return errors.New("booking codes need at least 4 random characters")
+	}
+	if len(format.Prefix)+len(format.group(strings.Repeat("X", format.Length+1))) > maxBookingCodeLength {
+		return fmt.Errorf("booking codes cannot be longer than %d characters", maxBookingCodeLength)
+	}
+	bookingCodeFormat = format
+	return nil
+}
+
+// generate returns a random code, the last character is a check character that catches a mistyped character or
+// two swapped ones
+func (f BookingCodeFormat) generate() (string, error) {
+	chars := make([]byte, f.Length, f.Length+1)
+	base := big.NewInt(int64(len(bookingCodeAlphabet)))
+	for i := range chars {
+		n, err := rand.Int(rand.Reader, base)
+		if err != nil {
+			return "", err
+		}
+		chars[i] = bookingCodeAlphabet[n.Int64()]
+	}
+	chars = append(chars, checkCharacter(string(chars)))
+	return f.Prefix + f.group(string(chars)), nil
+}
+
+func (f BookingCodeFormat) group(chars string) string {
+	if f.GroupSize <= 0 {
+		return chars
+	}
+	var groups []string
+	for len(chars) > f.GroupSize {
+		groups = append(groups, chars[:f.GroupSize])
+		chars = chars[f.GroupSize:]
+	}
+	return strings.Join(append(groups, chars), "-")
+}
+
+// characters returns the random and check characters of a code written in this format, ignoring case, spaces and
+// dashes, ok is false when the code does not have the format
+func (f BookingCodeFormat) characters(code string) (chars string, ok bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	prefix := strings.ToUpper(f.Prefix)
+	if !strings.HasPrefix(code, prefix) {
+		return "", false
+	}
+	chars = strings.NewReplacer("-", "", " ", "").Replace(strings.TrimPrefix(code, prefix))
+	if len(chars) != f.Length+1 {
+		return "", false
+	}
+	for _, c := range chars {
+		if !strings.ContainsRune(bookingCodeAlphabet, c) {
+			return "", false
+		}
+	}
+	return chars, true
+}
+
+// checkCharacter computes the Luhn mod N check character of the code
+func checkCharacter(chars string) byte {
+	n := len(bookingCodeAlphabet)
+	sum := 0
+	factor := 2
+	for i := len(chars) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(bookingCodeAlphabet, chars[i])
+		sum += addend/n + addend%n
+		if factor == 2 {
+			factor = 1
+		} else {
+			factor = 2
+		}
+	}
+	return bookingCodeAlphabet[(n-sum%n)%n]
+}
+
+// newBookingCode generates a code that no booking has yet
+func newBookingCode(ctx context.Context, conn *pgx.Conn) (string, error) {
+	for range 10 {
+		code, err := bookingCodeFormat.generate()
+		if err != nil {
+			return "", err
+		}
+		_, err = dal.GetBookingByCode(ctx, conn, code)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return code, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", errors.New("unable to generate a unique booking code")
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"example/models"
+	"time"
+)
+
+// Clock tells the current time, tests replace it to move the hotel to another day
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc turns a function into a Clock
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+var clock Clock = ClockFunc(time.Now)
+
+// location is the timezone of the hotel, its days start and end at midnight there
+var location = time.Local
+
+// SetClock replaces the clock, nil restores the system clock
+func SetClock(c Clock) {
+	if c == nil {
+		c = ClockFunc(time.Now)
+	}
+	clock = c
+}
+
+func SetLocation(loc *time.Location) {
+	location = loc
+}
+
+func Location() *time.Location {
+	return location
+}
+
+// Now returns the current time in the timezone of the hotel
+func Now() time.Time {
+	return clock.Now().In(location)
+}
+
+// Today returns the current date of the hotel, as midnight UTC like all the dates
+func Today() time.Time {
+	return models.DateOf(clock.Now(), location)
+}
@@ -8,21 +8,30 @@ import (
 	"net/http"
 
 	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func GetAllCustomers(ctx context.Context, conn *pgx.Conn) ([]models.Customer, error) {
+	ctx, span := startSpan(ctx, "GetAllCustomers")
+	defer span.End()
 	return dal.GetAllCustomers(ctx, conn)
 }
 
 func GetCustomerByID(ctx context.Context, conn *pgx.Conn, customerID int) (*models.Customer, error) {
+	ctx, span := startSpan(ctx, "GetCustomerByID", attribute.Int("customer.id", customerID))
+	defer span.End()
 	return dal.GetCustomerByID(ctx, conn, customerID)
 }
 
 func CreateCustomer(ctx context.Context, conn *pgx.Conn, customer *models.Customer) error {
+	ctx, span := startSpan(ctx, "CreateCustomer")
+	defer span.End()
 	return dal.CreateCustomer(ctx, conn, customer)
 }
 
 func UpdateCustomerByID(ctx context.Context, conn *pgx.Conn, customer *models.Customer) (int, error) {
+	ctx, span := startSpan(ctx, "UpdateCustomerByID")
+	defer span.End()
 	_, err := dal.GetCustomerByID(ctx, conn, customer.ID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -34,9 +43,13 @@ func UpdateCustomerByID(ctx context.Context, conn *pgx.Conn, customer *models.Cu
 }
 
 func PatchCustomerByID(ctx context.Context, conn *pgx.Conn, customerID int, patch models.CustomerPatch) error {
+	ctx, span := startSpan(ctx, "PatchCustomerByID", attribute.Int("customer.id", customerID))
+	defer span.End()
 	return dal.PatchCustomerByID(ctx, conn, customerID, patch)
 }
 
 func DeleteCustomerByID(ctx context.Context, conn *pgx.Conn, customerID int) error {
+	ctx, span := startSpan(ctx, "DeleteCustomerByID", attribute.Int("customer.id", customerID))
+	defer span.End()
 	return dal.DeleteCustomerByID(ctx, conn, customerID)
 }
@@ -0,0 +1,25 @@
+package services
+
+import (
+	"context"
+	"example/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// EventHandler reacts to an event, e.g. by notifying the customer. It is given the connection of the caller, the
+// events of the background jobs come from the worker connection and a connection is used by one goroutine at a time.
+type EventHandler func(ctx context.Context, conn *pgx.Conn, event models.Event)
+
+var eventHandlers []EventHandler
+
+// OnEvent registers a handler for all the events, handlers are registered at startup
+func OnEvent(handler EventHandler) {
+	eventHandlers = append(eventHandlers, handler)
+}
+
+func emitEvent(ctx context.Context, conn *pgx.Conn, event models.Event) {
+	for _, handler := range eventHandlers {
+		handler(ctx, conn, event)
+	}
+}
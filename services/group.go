@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"example/dal"
+	"example/models"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func GetAllGroups(ctx context.Context, conn *pgx.Conn) ([]models.Group, error) {
+	ctx, span := startSpan(ctx, "GetAllGroups")
+	defer span.End()
+	return dal.GetAllGroups(ctx, conn)
+}
+
+func GetGroupByID(ctx context.Context, conn *pgx.Conn, groupID int) (*models.Group, error) {
+	ctx, span := startSpan(ctx, "GetGroupByID", attribute.Int("group.id", groupID))
+	defer span.End()
+	return dal.GetGroupByID(ctx, conn, groupID)
+}
+
+// CreateGroup reserves all the rooms of a group or none of them, the bookings get the group code followed by
+// the position of the room
+func CreateGroup(ctx context.Context, conn *pgx.Conn, group *models.Group) error {
+	ctx, span := startSpan(ctx, "CreateGroup")
+	defer span.End()
+	if group.CutoffDate != nil && group.CutoffDate.After(group.StartDate) {
+		return models.ValidationError{Message: "cut-off date cannot be after the start date"}
+	}
+	_, err := dal.GetCustomerByID(ctx, conn, group.LeadCustomerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ValidationError{Message: "lead customer does not exist"}
+		}
+		return err
+	}
+	_, err = dal.GetGroupByCode(ctx, conn, group.Code)
+	if err == nil {
+		return models.ValidationError{Message: "group code already exists"}
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	for i := range group.Rooms {
+		booking := &group.Rooms[i].Booking
+		booking.Code = fmt.Sprintf("%s-%d", group.Code, i+1)
+		err = validateBooking(ctx, conn, booking, nil)
+		if err != nil {
+			var validationErr models.ValidationError
+			if errors.As(err, &validationErr) {
+				validationErr.Message = fmt.Sprintf("room %d: %s", booking.RoomID, validationErr.Message)
+				return validationErr
+			}
+			return err
+		}
+	}
+	err = dal.CreateGroup(ctx, conn, group)
+	if err != nil {
+		return err
+	}
+	for _, room := range group.Rooms {
+		err = dal.RepriceFolio(ctx, conn, room.Booking.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CancelGroup cancels the group and all its confirmed rooms, a group with guests in the hotel cannot be cancelled
+func CancelGroup(ctx context.Context, conn *pgx.Conn, groupID int) (*models.Group, error) {
+	ctx, span := startSpan(ctx, "CancelGroup", attribute.Int("group.id", groupID))
+	defer span.End()
+	group, err := dal.GetGroupByID(ctx, conn, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group.Status != models.GroupActive {
+		return nil, models.ValidationError{Code: "invalid_group_status", Message: "the group is already " + group.Status}
+	}
+	for _, room := range group.Rooms {
+		if room.Booking.Status == models.BookingCheckedIn || room.Booking.Status == models.BookingCheckedOut {
+			return nil, models.ValidationError{Code: "group_checked_in", Message: "the guests of the group have already checked in"}
+		}
+	}
+	err = dal.CancelGroup(ctx, conn, groupID)
+	if err != nil {
+		return nil, err
+	}
+	for _, room := range group.Rooms {
+		err = dal.RepriceFolio(ctx, conn, room.Booking.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	matchWaitlist(ctx, conn)
+	return dal.GetGroupByID(ctx, conn, groupID)
+}
+
+// SetGroupRoomGuest names the guest of a room of the group
+func SetGroupRoomGuest(ctx context.Context, conn *pgx.Conn, groupID int, bookingID int, customerID int) (*models.Group, error) {
+	ctx, span := startSpan(ctx, "SetGroupRoomGuest", attribute.Int("group.id", groupID), attribute.Int("booking.id", bookingID), attribute.Int("customer.id", customerID))
+	defer span.End()
+	group, err := dal.GetGroupByID(ctx, conn, groupID)
+	if err != nil {
+		return nil, err
+	}
+	var room *models.GroupRoom
+	for i := range group.Rooms {
+		if group.Rooms[i].Booking.ID == bookingID {
+			room = &group.Rooms[i]
+		}
+	}
+	if room == nil {
+		return nil, pgx.ErrNoRows
+	}
+	if room.Booking.Status != models.BookingConfirmed {
+		return nil, models.ValidationError{Code: "invalid_booking_status", Message: "only confirmed rooms can change guest, room is " + room.Booking.Status}
+	}
+	_, err = dal.GetCustomerByID(ctx, conn, customerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ValidationError{Message: "customer does not exist"}
+		}
+		return nil, err
+	}
+	err = dal.SetGroupRoomGuest(ctx, conn, groupID, bookingID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	return dal.GetGroupByID(ctx, conn, groupID)
+}
+
+// ReleaseAllotments frees the rooms without a guest of the groups past their cut-off date
+func ReleaseAllotments(ctx context.Context, conn *pgx.Conn, date time.Time) ([]models.Booking, error) {
+	ctx, span := startSpan(ctx, "ReleaseAllotments")
+	defer span.End()
+	released, err := dal.ReleaseAllotments(ctx, conn, date)
+	if err != nil {
+		return nil, err
+	}
+	for _, booking := range released {
+		err = dal.RepriceFolio(ctx, conn, booking.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(released) > 0 {
+		matchWaitlist(ctx, conn)
+	}
+	return released, nil
+}
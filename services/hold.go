@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"example/dal"
+	"example/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func GetActiveHolds(ctx context.Context, conn *pgx.Conn) ([]models.Hold, error) {
+	ctx, span := startSpan(ctx, "GetActiveHolds")
+	defer span.End()
+	return dal.GetActiveHolds(ctx, conn, Now())
+}
+
+func GetHoldByID(ctx context.Context, conn *pgx.Conn, holdID int) (*models.Hold, error) {
+	ctx, span := startSpan(ctx, "GetHoldByID", attribute.Int("hold.id", holdID))
+	defer span.End()
+	return dal.GetHoldByID(ctx, conn, holdID, Now())
+}
+
+// CreateHold reserves a free room until the hold expires after ttl
+func CreateHold(ctx context.Context, conn *pgx.Conn, hold *models.Hold, ttl time.Duration) error {
+	ctx, span := startSpan(ctx, "CreateHold")
+	defer span.End()
+	if !hold.StartDate.Before(hold.EndDate) {
+		return models.ValidationError{Message: "start date must be before end date"}
+	}
+	today := Today()
+	if hold.StartDate.Before(today) {
+		return models.ValidationError{Message: "start date cannot be in the past"}
+	}
+	if hold.CustomerID != nil {
+		_, err := dal.GetCustomerByID(ctx, conn, *hold.CustomerID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return models.ValidationError{Message: "customer does not exist"}
+			}
+			return err
+		}
+	}
+	_, err := dal.GetRoomByID(ctx, conn, hold.RoomID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ValidationError{Message: "room does not exist"}
+		}
+		return err
+	}
+	err = checkRoomAvailable(ctx, conn, hold.RoomID, hold.StartDate, hold.EndDate, 0)
+	if err != nil {
+		return err
+	}
+	hold.ExpiresAt = Now().Add(ttl)
+	return dal.CreateHold(ctx, conn, hold)
+}
+
+// DeleteHold releases a room before the hold expires, the waiting customers may get it
+func DeleteHold(ctx context.Context, conn *pgx.Conn, holdID int) error {
+	ctx, span := startSpan(ctx, "DeleteHold", attribute.Int("hold.id", holdID))
+	defer span.End()
+	// a hold released by hand ends the offer made with it
+	_, err := dal.ExpireWaitlistOffers(ctx, conn, time.Time{}, holdID)
+	if err != nil {
+		return err
+	}
+	err = dal.DeleteHold(ctx, conn, holdID)
+	if err != nil {
+		return err
+	}
+	matchWaitlist(ctx, conn)
+	return nil
+}
+
+// ExpireHolds removes the expired holds and offers their rooms to the waitlist, it returns how many holds expired.
+// The waitlist offers made with the expired holds expire as well, the room goes to the next waiting customer.
+func ExpireHolds(ctx context.Context, conn *pgx.Conn) (int64, error) {
+	ctx, span := startSpan(ctx, "ExpireHolds")
+	defer span.End()
+	now := Now()
+	_, err := dal.ExpireWaitlistOffers(ctx, conn, now, 0)
+	if err != nil {
+		return 0, err
+	}
+	expired, err := dal.DeleteExpiredHolds(ctx, conn, now)
+	if err != nil || expired == 0 {
+		return expired, err
+	}
+	matchWaitlist(ctx, conn)
+	return expired, nil
+}
+
+// useHold checks that a booking matches the hold it is made with
+func useHold(ctx context.Context, conn *pgx.Conn, booking *models.Booking) error {
+	hold, err := dal.GetHoldByID(ctx, conn, booking.HoldID, Now())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ValidationError{Code: "hold_expired", Message: "the hold does not exist or has expired"}
+		}
+		return err
+	}
+	if hold.RoomID != booking.RoomID || !hold.StartDate.Equal(booking.StartDate) || !hold.EndDate.Equal(booking.EndDate) {
+		return models.ValidationError{Code: "hold_mismatch", Message: "the booking must have the room and the dates of the hold"}
+	}
+	if hold.CustomerID != nil && *hold.CustomerID != booking.CustomerID {
+		return models.ValidationError{Code: "hold_mismatch", Message: "the hold is for another customer"}
+	}
+	return nil
+}
@@ -7,19 +7,28 @@ import (
 	"example/models"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func GetAllHotelServices(ctx context.Context, conn *pgx.Conn) ([]models.HotelService, error) {
+	ctx, span := startSpan(ctx, "GetAllHotelServices")
+	defer span.End()
 	return dal.GetAllHotelServices(ctx, conn)
 }
 
 func GetHotelServiceByID(ctx context.Context, conn *pgx.Conn, serviceID int) (*models.HotelService, error) {
+	ctx, span := startSpan(ctx, "GetHotelServiceByID", attribute.Int("hotel_service.id", serviceID))
+	defer span.End()
 	return dal.GetHotelServiceByID(ctx, conn, serviceID)
 }
 
 func CreateHotelService(ctx context.Context, conn *pgx.Conn, service *models.HotelService) error {
+	ctx, span := startSpan(ctx, "CreateHotelService")
+	defer span.End()
+	service.SetDefaults()
 	err := validateHotelService(ctx, conn, service)
 	if err != nil {
 		return err
@@ -28,6 +37,9 @@ func CreateHotelService(ctx context.Context, conn *pgx.Conn, service *models.Hot
 }
 
 func UpdateHotelServiceByID(ctx context.Context, conn *pgx.Conn, service *models.HotelService) (int, error) {
+	ctx, span := startSpan(ctx, "UpdateHotelServiceByID")
+	defer span.End()
+	service.SetDefaults()
 	err := validateHotelService(ctx, conn, service)
 	if err != nil {
 		return 0, err
@@ -43,6 +55,8 @@ func UpdateHotelServiceByID(ctx context.Context, conn *pgx.Conn, service *models
 }
 
 func PatchHotelServiceByID(ctx context.Context, conn *pgx.Conn, serviceID int, patch models.HotelServicePatch) error {
+	ctx, span := startSpan(ctx, "PatchHotelServiceByID", attribute.Int("hotel_service.id", serviceID))
+	defer span.End()
 	// first check that the patch is valid
 	oldService, err := dal.GetHotelServiceByID(ctx, conn, serviceID)
 	if err != nil {
@@ -58,6 +72,15 @@ func PatchHotelServiceByID(ctx context.Context, conn *pgx.Conn, serviceID int, p
 	if patch.Duration != nil {
 		oldService.Duration = *patch.Duration
 	}
+	if patch.OpeningTime != nil {
+		oldService.OpeningTime = *patch.OpeningTime
+	}
+	if patch.ClosingTime != nil {
+		oldService.ClosingTime = *patch.ClosingTime
+	}
+	if patch.Capacity != nil {
+		oldService.Capacity = *patch.Capacity
+	}
 
 	err = validateHotelService(ctx, conn, oldService)
 	if err != nil {
@@ -67,11 +90,77 @@ func PatchHotelServiceByID(ctx context.Context, conn *pgx.Conn, serviceID int, p
 	return dal.PatchHotelServiceByID(ctx, conn, serviceID, patch)
 }
 
+// GetServiceSlots splits the opening hours of a service in slots as long as the service and counts the free places of each
+func GetServiceSlots(ctx context.Context, conn *pgx.Conn, serviceID int, date time.Time) ([]models.ServiceSlot, error) {
+	ctx, span := startSpan(ctx, "GetServiceSlots", attribute.Int("hotel_service.id", serviceID))
+	defer span.End()
+	service, err := dal.GetHotelServiceByID(ctx, conn, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	opening, closing, err := service.OpeningHours()
+	if err != nil {
+		return nil, err
+	}
+	requests, err := dal.GetServiceRequestsByServiceAndDate(ctx, conn, serviceID, date)
+	if err != nil {
+		return nil, err
+	}
+	duration := time.Duration(service.Duration) * time.Minute
+	slots := []models.ServiceSlot{}
+	for start := opening; start+duration <= closing; start += duration {
+		available := service.Capacity - peakRequests(requests, start, duration, -1)
+		slots = append(slots, models.ServiceSlot{
+			StartTime: models.FormatClock(start),
+			EndTime:   models.FormatClock(start + duration),
+			Available: max(available, 0),
+		})
+	}
+	return slots, nil
+}
+
+// peakRequests returns the largest number of requests of a service, excluding the one with excludeID, running at the
+// same time during [start, start+duration). Two requests that both overlap the interval but not each other take one
+// place, not two.
+func peakRequests(requests []models.ServiceRequest, start, duration time.Duration, excludeID int) int {
+	end := start + duration
+	// the number of running requests only grows when one starts, the peak is at the start or when a request starts
+	instants := []time.Duration{start}
+	for _, r := range requests {
+		if r.ID != excludeID && r.StartTime > start && r.StartTime < end {
+			instants = append(instants, r.StartTime)
+		}
+	}
+	peak := 0
+	for _, t := range instants {
+		running := 0
+		for _, r := range requests {
+			if r.ID != excludeID && r.StartTime <= t && t < r.StartTime+duration {
+				running++
+			}
+		}
+		peak = max(peak, running)
+	}
+	return peak
+}
+
 func DeleteHotelServiceByID(ctx context.Context, conn *pgx.Conn, serviceID int) error {
+	ctx, span := startSpan(ctx, "DeleteHotelServiceByID", attribute.Int("hotel_service.id", serviceID))
+	defer span.End()
 	return dal.DeleteHotelServiceByID(ctx, conn, serviceID)
 }
 
 func validateHotelService(ctx context.Context, conn *pgx.Conn, service *models.HotelService) error {
+	opening, closing, err := service.OpeningHours()
+	if err != nil {
+		return models.ValidationError{Message: "opening and closing time must be in HH:MM format"}
+	}
+	if opening >= closing {
+		return models.ValidationError{Message: "opening time must be before closing time"}
+	}
+	if time.Duration(service.Duration)*time.Minute > closing-opening {
+		return models.ValidationError{Message: "service duration does not fit in the opening hours"}
+	}
 	services, err := dal.GetAllHotelServices(ctx, conn)
 	if err != nil {
 		return err
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"example/dal"
+	"example/models"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func GetHousekeepingBoard(ctx context.Context, conn *pgx.Conn, date time.Time) ([]models.HousekeepingBoardRow, error) {
+	ctx, span := startSpan(ctx, "GetHousekeepingBoard")
+	defer span.End()
+	return dal.GetHousekeepingBoard(ctx, conn, date)
+}
+
+func GetHousekeepingTasks(ctx context.Context, conn *pgx.Conn, date time.Time, staffID *int) ([]models.HousekeepingTask, error) {
+	ctx, span := startSpan(ctx, "GetHousekeepingTasks")
+	defer span.End()
+	return dal.GetHousekeepingTasks(ctx, conn, date, staffID)
+}
+
+func UpdateRoomHousekeepingStatus(ctx context.Context, conn *pgx.Conn, roomID int, status string) error {
+	ctx, span := startSpan(ctx, "UpdateRoomHousekeepingStatus", attribute.Int("room.id", roomID))
+	defer span.End()
+	current, err := dal.GetRoomHousekeepingStatus(ctx, conn, roomID)
+	if err != nil {
+		return err
+	}
+	if current == status {
+		return nil
+	}
+	if !models.CanMoveHousekeeping(current, status) {
+		return models.ValidationError{Code: "invalid_housekeeping_transition", Message: fmt.Sprintf("a %s room cannot become %s", current, status)}
+	}
+	return dal.UpdateRoomHousekeepingStatus(ctx, conn, roomID, status)
+}
+
+// GenerateHousekeepingTasks creates the tasks for the departures and stay-overs of a date and spreads them
+// among the staff members with the cleaning skill on shift that day. It can be run again during the day,
+// rooms that already have a task are left untouched.
+func GenerateHousekeepingTasks(ctx context.Context, conn *pgx.Conn, date time.Time) ([]models.HousekeepingTask, error) {
+	ctx, span := startSpan(ctx, "GenerateHousekeepingTasks")
+	defer span.End()
+	work, err := dal.GetHousekeepingWork(ctx, conn, date)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := dal.GetHousekeepingTasks(ctx, conn, date, nil)
+	if err != nil {
+		return nil, err
+	}
+	staffIDs, err := dal.GetStaffOnShift(ctx, conn, "cleaning", date)
+	if err != nil {
+		return nil, err
+	}
+
+	planned := make(map[int]bool)
+	load := make(map[int]int)
+	for _, task := range existing {
+		planned[task.RoomID] = true
+		if task.StaffID != nil {
+			load[*task.StaffID]++
+		}
+	}
+	for _, task := range work {
+		if planned[task.RoomID] {
+			continue
+		}
+		// the least loaded staff member gets the task
+		for _, id := range staffIDs {
+			if task.StaffID == nil || load[id] < load[*task.StaffID] {
+				task.StaffID = &id
+			}
+		}
+		if task.StaffID != nil {
+			load[*task.StaffID]++
+		}
+		err = dal.CreateHousekeepingTask(ctx, conn, &task)
+		if err != nil {
+			return nil, err
+		}
+		planned[task.RoomID] = true
+	}
+	return dal.GetHousekeepingTasks(ctx, conn, date, nil)
+}
+
+// CompleteHousekeepingTask marks a task as done, the room becomes clean unless it is out of service
+func CompleteHousekeepingTask(ctx context.Context, conn *pgx.Conn, taskID int) (*models.HousekeepingTask, error) {
+	ctx, span := startSpan(ctx, "CompleteHousekeepingTask", attribute.Int("housekeeping_task.id", taskID))
+	defer span.End()
+	task, err := dal.GetHousekeepingTaskByID(ctx, conn, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.CompletedAt != nil {
+		return nil, models.ValidationError{Code: "task_already_completed", Message: "the task has already been completed"}
+	}
+	now := Now()
+	task.CompletedAt = &now
+	err = dal.CompleteHousekeepingTask(ctx, conn, taskID, now)
+	if err != nil {
+		return nil, err
+	}
+	status, err := dal.GetRoomHousekeepingStatus(ctx, conn, task.RoomID)
+	if err != nil {
+		return nil, err
+	}
+	if status == models.HousekeepingDirty {
+		err = dal.UpdateRoomHousekeepingStatus(ctx, conn, task.RoomID, models.HousekeepingClean)
+	}
+	return task, err
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"example/models"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RegisterDefaultJobs registers the time-driven processes of the hotel, the schedules are in the timezone of the
+// hotel
+func RegisterDefaultJobs() error {
+	for _, j := range []struct {
+		name string
+		spec string
+		run  JobFunc
+	}{
+		{"expire-holds", "@every 1m", expireHoldsJob},
+		{"release-allotments", "5 0 * * *", releaseAllotmentsJob},
+		{"arrival-reminders", "0 10 * * *", arrivalRemindersJob},
+		{"night-audit", "0 3 * * *", nightAuditJob},
+	} {
+		err := RegisterJob(j.name, j.spec, j.run)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func expireHoldsJob(ctx context.Context, conn *pgx.Conn) (string, error) {
+	expired, err := ExpireHolds(ctx, conn)
+	return fmt.Sprintf("%d holds expired", expired), err
+}
+
+func releaseAllotmentsJob(ctx context.Context, conn *pgx.Conn) (string, error) {
+	today := Today()
+	released, err := ReleaseAllotments(ctx, conn, today)
+	return fmt.Sprintf("%d rooms released", len(released)), err
+}
+
+// arrivalRemindersJob reminds the guests arriving tomorrow
+func arrivalRemindersJob(ctx context.Context, conn *pgx.Conn) (string, error) {
+	tomorrow := Today().AddDate(0, 0, 1)
+	reminded, err := SendArrivalReminders(ctx, conn, tomorrow)
+	return fmt.Sprintf("%d guests reminded", len(reminded)), err
+}
+
+func nightAuditJob(ctx context.Context, conn *pgx.Conn) (string, error) {
+	audit, err := RunNightAudit(ctx, conn)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s closed, %d no-shows, %d charges posted", audit.BusinessDate.Format(models.DateFormat), audit.NoShows, audit.ChargesPosted), nil
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"example/dal"
+	"example/models"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BusinessDate returns the day the hotel is working on, the calendar date when it was never set
+func BusinessDate(ctx context.Context, conn *pgx.Conn) (time.Time, error) {
+	ctx, span := startSpan(ctx, "BusinessDate")
+	defer span.End()
+	date, err := dal.GetBusinessDate(ctx, conn)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Today(), nil
+	}
+	return date, err
+}
+
+// RunNightAudit closes the business day: the bookings that did not arrive become no shows and free their rooms,
+// the room charges of the guests in house are posted, and the summary of the day is saved. A business day can be
+// closed once it has started, usually after midnight.
+func RunNightAudit(ctx context.Context, conn *pgx.Conn) (*models.NightAudit, error) {
+	ctx, span := startSpan(ctx, "RunNightAudit")
+	defer span.End()
+	date, err := BusinessDate(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	today := Today()
+	if date.After(today) {
+		return nil, models.ValidationError{Code: "business_day_not_started", Message: fmt.Sprintf("the business day %s has not started yet", date.Format(models.DateFormat))}
+	}
+	audit, noShows, err := dal.CloseBusinessDay(ctx, conn, date, location)
+	if err != nil {
+		return nil, err
+	}
+	for _, booking := range noShows {
+		err = dal.RepriceFolio(ctx, conn, booking.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(noShows) > 0 {
+		matchWaitlist(ctx, conn)
+	}
+	return audit, nil
+}
+
+func GetNightAudits(ctx context.Context, conn *pgx.Conn) ([]models.NightAudit, error) {
+	ctx, span := startSpan(ctx, "GetNightAudits")
+	defer span.End()
+	return dal.GetNightAudits(ctx, conn)
+}
+
+func GetNightAudit(ctx context.Context, conn *pgx.Conn, date time.Time) (*models.NightAudit, error) {
+	ctx, span := startSpan(ctx, "GetNightAudit")
+	defer span.End()
+	return dal.GetNightAudit(ctx, conn, date)
+}
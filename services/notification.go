@@ -0,0 +1,193 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"example/dal"
+	"example/logging"
+	"example/models"
+	"fmt"
+	"io/fs"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+const defaultLanguage = "en"
+
+// emailTemplates has a template per language and kind of notification, keyed by "language/kind", each one defines
+// a subject and a body
+var emailTemplates = loadEmailTemplates()
+
+func loadEmailTemplates() map[string]*template.Template {
+	paths, err := fs.Glob(templateFS, "templates/*/*.tmpl")
+	if err != nil {
+		panic(err)
+	}
+	templates := make(map[string]*template.Template)
+	for _, path := range paths {
+		key := strings.TrimSuffix(strings.TrimPrefix(path, "templates/"), ".tmpl")
+		templates[key] = template.Must(template.ParseFS(templateFS, path))
+	}
+	return templates
+}
+
+// the email sent for each event about a booking
+var notificationKinds = map[string]string{
+	models.EventBookingConfirmed:  models.NotificationConfirmation,
+	models.EventArrivalReminder:   models.NotificationReminder,
+	models.EventBookingCancelled:  models.NotificationCancellation,
+	models.EventBookingCheckedOut: models.NotificationReviewInvitation,
+}
+
+// pendingBatch is how many notifications SendPending sends at most in a run
+const pendingBatch = 100
+
+// Notifier emails the customers about their bookings. Register its HandleEvent with OnEvent, it writes the emails to
+// the send log as pending, and its SendPending as a job, it sends them: the requests do not wait for the mail server.
+type Notifier struct {
+	sender Sender
+}
+
+func NewNotifier(sender Sender) *Notifier {
+	return &Notifier{sender: sender}
+}
+
+// HandleEvent writes the email of a booking event to the send log as pending, failures are logged
+func (n *Notifier) HandleEvent(ctx context.Context, conn *pgx.Conn, event models.Event) {
+	kind, ok := notificationKinds[event.Type]
+	if !ok {
+		return
+	}
+	booking, ok := event.Payload.(models.Booking)
+	if !ok {
+		return
+	}
+	err := n.notify(ctx, conn, kind, booking)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error saving notification", "kind", kind, "booking_id", booking.ID, "error", err)
+	}
+}
+
+func (n *Notifier) notify(ctx context.Context, conn *pgx.Conn, kind string, booking models.Booking) error {
+	customer, err := dal.GetCustomerByID(ctx, conn, booking.CustomerID)
+	if err != nil {
+		return err
+	}
+	language := customer.Language
+	if language == "" {
+		language = defaultLanguage
+	}
+	email, err := renderEmail(language, kind, customer, booking)
+	if err != nil {
+		return err
+	}
+	notification := models.Notification{
+		CustomerID: customer.ID,
+		BookingID:  booking.ID,
+		Kind:       kind,
+		Recipient:  email.To,
+		Language:   language,
+		Subject:    email.Subject,
+		Body:       email.Body,
+	}
+	return dal.CreateNotification(ctx, conn, &notification)
+}
+
+// SendPending sends the pending notifications, oldest first, and records whether each one was sent or failed. It is
+// the JobFunc of the send-notifications job.
+func (n *Notifier) SendPending(ctx context.Context, conn *pgx.Conn) (string, error) {
+	ctx, span := startSpan(ctx, "SendPendingNotifications")
+	defer span.End()
+	pending, err := dal.GetPendingNotifications(ctx, conn, pendingBatch)
+	if err != nil {
+		return "", err
+	}
+	failed := 0
+	for _, notification := range pending {
+		notification.Status = models.NotificationSent
+		err = n.sender.Send(ctx, notification.Email())
+		if err != nil {
+			failed++
+			notification.Status = models.NotificationFailed
+			notification.Error = err.Error()
+			logging.FromContext(ctx).Error("Error sending notification", "kind", notification.Kind, "booking_id", notification.BookingID, "error", err)
+		}
+		err = dal.FinishNotification(ctx, conn, &notification)
+		if err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%d notifications sent, %d failed", len(pending)-failed, failed), nil
+}
+
+// renderEmail fills the template of the kind in the language, English is used for languages without templates
+func renderEmail(language string, kind string, customer *models.Customer, booking models.Booking) (models.Email, error) {
+	tmpl, ok := emailTemplates[language+"/"+kind]
+	if !ok {
+		tmpl, ok = emailTemplates[defaultLanguage+"/"+kind]
+	}
+	if !ok {
+		return models.Email{}, fmt.Errorf("no template for %s", kind)
+	}
+	data := struct {
+		Name      string
+		Code      string
+		StartDate string
+		EndDate   string
+		Guests    int
+	}{
+		Name:      customer.Name,
+		Code:      booking.Code,
+		StartDate: booking.StartDate.Format(models.DateFormat),
+		EndDate:   booking.EndDate.Format(models.DateFormat),
+		Guests:    booking.Guests(),
+	}
+	var subject, body bytes.Buffer
+	err := tmpl.ExecuteTemplate(&subject, "subject", data)
+	if err != nil {
+		return models.Email{}, err
+	}
+	err = tmpl.ExecuteTemplate(&body, "body", data)
+	if err != nil {
+		return models.Email{}, err
+	}
+	return models.Email{To: customer.Email, Subject: strings.TrimSpace(subject.String()), Body: strings.TrimLeft(body.String(), "\n")}, nil
+}
+
+func GetNotifications(ctx context.Context, conn *pgx.Conn, bookingID int, customerID int) ([]models.Notification, error) {
+	ctx, span := startSpan(ctx, "GetNotifications", attribute.Int("booking.id", bookingID), attribute.Int("customer.id", customerID))
+	defer span.End()
+	return dal.GetNotifications(ctx, conn, bookingID, customerID)
+}
+
+// SendArrivalReminders reminds the guests arriving on the date of their stay, once per booking, and returns the
+// bookings reminded
+func SendArrivalReminders(ctx context.Context, conn *pgx.Conn, date time.Time) ([]models.Booking, error) {
+	ctx, span := startSpan(ctx, "SendArrivalReminders")
+	defer span.End()
+	arrivals, err := dal.GetArrivals(ctx, conn, date)
+	if err != nil {
+		return nil, err
+	}
+	var reminded []models.Booking
+	for _, booking := range arrivals {
+		sent, err := dal.HasSentNotification(ctx, conn, booking.ID, models.NotificationReminder)
+		if err != nil {
+			return nil, err
+		}
+		if sent {
+			continue
+		}
+		emitEvent(ctx, conn, models.Event{Type: models.EventArrivalReminder, CustomerID: booking.CustomerID, Payload: booking})
+		reminded = append(reminded, booking)
+	}
+	return reminded, nil
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"context"
+	"example/dal"
+	"example/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// maxReportDays limits the range of a report, every night of the range is expanded for every room
+const maxReportDays = 3 * 366
+
+func GetKPIReport(ctx context.Context, conn *pgx.Conn, params models.ReportParams) ([]models.KPIRow, error) {
+	ctx, span := startSpan(ctx, "GetKPIReport")
+	defer span.End()
+	if params.EndDate.Before(params.StartDate) {
+		return nil, models.ValidationError{Message: "end date must not be before start date"}
+	}
+	if params.EndDate.Sub(params.StartDate).Hours()/24 > maxReportDays {
+		return nil, models.ValidationError{Message: "report range cannot be longer than 3 years"}
+	}
+	return dal.GetKPIReport(ctx, conn, params)
+}
+
+// GetHotelStats returns the figures of the hotel today, for the monitoring
+func GetHotelStats(ctx context.Context, conn *pgx.Conn) (*models.HotelStats, error) {
+	ctx, span := startSpan(ctx, "GetHotelStats")
+	defer span.End()
+	return dal.GetHotelStats(ctx, conn, Today())
+}
@@ -5,50 +5,95 @@ import (
 	"errors"
 	"example/dal"
 	"example/models"
+	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func GetAllReviews(ctx context.Context, conn *pgx.Conn) ([]models.Review, error) {
+	ctx, span := startSpan(ctx, "GetAllReviews")
+	defer span.End()
 	return dal.GetAllReviews(ctx, conn)
 }
 
-func GetReviewByID(ctx context.Context, conn *pgx.Conn, reviewID int) (*models.Review, error) {
-	return dal.GetReviewByID(ctx, conn, reviewID)
+func GetReviewsByStatus(ctx context.Context, conn *pgx.Conn, status string) ([]models.Review, error) {
+	ctx, span := startSpan(ctx, "GetReviewsByStatus")
+	defer span.End()
+	return dal.GetReviewsByStatus(ctx, conn, status)
+}
+
+// GetReviewByID returns the review when it is published, or in any status for the moderation view
+func GetReviewByID(ctx context.Context, conn *pgx.Conn, reviewID int, moderation bool) (*models.Review, error) {
+	ctx, span := startSpan(ctx, "GetReviewByID", attribute.Int("review.id", reviewID))
+	defer span.End()
+	review, err := dal.GetReviewByID(ctx, conn, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if !moderation && review.Status != models.ReviewPublished {
+		return nil, pgx.ErrNoRows
+	}
+	return review, nil
+}
+
+func GetReviewsByRoomID(ctx context.Context, conn *pgx.Conn, roomID int) ([]models.Review, error) {
+	ctx, span := startSpan(ctx, "GetReviewsByRoomID", attribute.Int("room.id", roomID))
+	defer span.End()
+	_, err := dal.GetRoomByID(ctx, conn, roomID)
+	if err != nil {
+		return nil, err
+	}
+	return dal.GetReviewsByRoomID(ctx, conn, roomID)
+}
+
+func GetReviewStats(ctx context.Context, conn *pgx.Conn, groupBy string) ([]models.ReviewStats, error) {
+	ctx, span := startSpan(ctx, "GetReviewStats")
+	defer span.End()
+	return dal.GetReviewStats(ctx, conn, groupBy)
 }
 
 func CreateReview(ctx context.Context, conn *pgx.Conn, review *models.Review) error {
+	ctx, span := startSpan(ctx, "CreateReview")
+	defer span.End()
 	err := validateReview(ctx, conn, review, true)
 	if err != nil {
 		return err
 	}
+	moderate(review)
 	return dal.CreateReview(ctx, conn, review)
 }
 
 func UpdateReviewByID(ctx context.Context, conn *pgx.Conn, review *models.Review) (int, error) {
+	ctx, span := startSpan(ctx, "UpdateReviewByID")
+	defer span.End()
 	err := validateReview(ctx, conn, review, false)
 	if err != nil {
 		return 0, err
 	}
-	_, err = dal.GetReviewByID(ctx, conn, review.BookingID)
+	oldReview, err := dal.GetReviewByID(ctx, conn, review.BookingID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
+			moderate(review)
 			return http.StatusCreated, dal.CreateReview(ctx, conn, review)
 		}
 		return 0, err
 	}
+	remoderate(review, oldReview)
 	return http.StatusOK, dal.UpdateReviewByID(ctx, conn, review)
 }
 
 func PatchReviewByID(ctx context.Context, conn *pgx.Conn, reviewID int, patch models.ReviewPatch) error {
+	ctx, span := startSpan(ctx, "PatchReviewByID", attribute.Int("review.id", reviewID))
+	defer span.End()
 	// first check that the patch is valid
 	oldReview, err := dal.GetReviewByID(ctx, conn, reviewID)
 	if err != nil {
 		return err
 	}
 
+	stored := *oldReview
 	if patch.BookingID != nil {
 		oldReview.BookingID = *patch.BookingID
 	}
@@ -59,7 +104,7 @@ func PatchReviewByID(ctx context.Context, conn *pgx.Conn, reviewID int, patch mo
 		oldReview.Rating = *patch.Rating
 	}
 	if patch.Date != nil {
-		date, err := time.Parse("2006-01-02", *patch.Date)
+		date, err := models.ParseDate(*patch.Date)
 		if err != nil {
 			return models.ValidationError{Message: "date must be in YYYY-MM-DD format"}
 		}
@@ -71,23 +116,59 @@ func PatchReviewByID(ctx context.Context, conn *pgx.Conn, reviewID int, patch mo
 		return err
 	}
 
-	return dal.PatchReviewByID(ctx, conn, reviewID, patch)
+	err = dal.PatchReviewByID(ctx, conn, reviewID, patch)
+	if err != nil || patch.Comment == nil {
+		return err
+	}
+	remoderate(oldReview, &stored)
+	return dal.UpdateReviewModeration(ctx, conn, oldReview.BookingID, oldReview.Status, oldReview.ModerationReason)
 }
 
 func DeleteReviewByID(ctx context.Context, conn *pgx.Conn, reviewID int) error {
+	ctx, span := startSpan(ctx, "DeleteReviewByID", attribute.Int("review.id", reviewID))
+	defer span.End()
 	return dal.DeleteReviewByID(ctx, conn, reviewID)
 }
 
+// reviewWindowDays is how long after check-out a guest can write a review
+var reviewWindowDays = 30
+
+func SetReviewWindow(days int) {
+	reviewWindowDays = days
+}
+
 func validateReview(ctx context.Context, conn *pgx.Conn, review *models.Review, new bool) error {
 	booking, err := dal.GetBookingByID(ctx, conn, review.BookingID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return models.ValidationError{Message: "booking does not exist"}
+			return models.ValidationError{Code: "booking_not_found", Message: "booking does not exist"}
 		}
 		return err
 	}
-	if review.Date.Before(booking.StartDate) {
-		return models.ValidationError{Message: "review date must be after booking start date"}
+	if review.CustomerID != booking.CustomerID {
+		return models.ValidationError{Code: "reviewer_not_guest", Message: "only the customer of the booking can review it"}
+	}
+	if booking.Status == models.BookingCancelled || booking.Status == models.BookingNoShow {
+		return models.ValidationError{Code: "booking_not_stayed", Message: "cannot review a cancelled or no show booking"}
+	}
+	today := Today()
+	// the stay is completed at check-out, or when the end date has passed if the guest was never checked out
+	checkOut := booking.EndDate
+	if booking.CheckedOutAt != nil {
+		checkOut = *booking.CheckedOutAt
+	} else if today.Before(booking.EndDate) {
+		return models.ValidationError{Code: "stay_not_completed", Message: "the stay must be completed before it can be reviewed"}
+	}
+	checkOutDay := models.DateOf(checkOut, location)
+	if review.Date.Before(checkOutDay) {
+		return models.ValidationError{Code: "review_before_checkout", Message: "review date must not be before the check-out date"}
+	}
+	if review.Date.After(today) {
+		return models.ValidationError{Code: "review_date_in_future", Message: "review date must not be in the future"}
+	}
+	windowEnd := checkOutDay.AddDate(0, 0, reviewWindowDays+1)
+	if !review.Date.Before(windowEnd) || (new && !today.Before(windowEnd)) {
+		return models.ValidationError{Code: "review_window_closed", Message: fmt.Sprintf("reviews must be written within %d days after check-out", reviewWindowDays)}
 	}
 	// check if the customer associated with the booking has already written a review, only if it wants to create another one
 	if new {
@@ -97,7 +178,7 @@ func validateReview(ctx context.Context, conn *pgx.Conn, review *models.Review,
 		}
 		for _, r := range allReviews {
 			if r.BookingID == review.BookingID {
-				return models.ValidationError{Message: "customer has already written a review for this booking"}
+				return models.ValidationError{Code: "review_already_exists", Message: "customer has already written a review for this booking"}
 			}
 		}
 	}
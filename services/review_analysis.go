@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"example/dal"
+	"example/models"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// the guests write in English and Italian, so both languages are covered by the word lists
+
+var stopwords = toSet(
+	// english
+	"the", "and", "but", "for", "with", "was", "were", "are", "this", "that", "very", "too", "our", "out",
+	"you", "your", "had", "has", "have", "will", "would", "all", "not", "from", "they", "there", "room", "hotel",
+	"stay", "again", "also", "just", "really", "bit", "little", "been", "its", "it's", "which", "what", "when",
+	// italian
+	"che", "con", "per", "una", "uno", "del", "della", "dei", "delle", "nel", "nella", "non", "sono", "era",
+	"stato", "stata", "molto", "poco", "anche", "come", "più", "piu", "tutto", "tutti", "dalla", "dal", "alla",
+	"allo", "agli", "alle", "gli", "questo", "questa", "sicuramente", "stanza", "camera", "hotel", "soggiorno",
+)
+
+var positiveWords = toSet(
+	// english
+	"good", "great", "excellent", "clean", "comfortable", "friendly", "nice", "perfect", "spacious", "quiet",
+	"helpful", "amazing", "lovely", "beautiful", "recommended", "satisfied", "cozy", "kind", "wonderful",
+	// italian
+	"ottima", "ottimo", "ottime", "buona", "buono", "eccellente", "pulita", "pulito", "confortevole", "gentile",
+	"gentili", "perfetta", "perfetto", "spaziosa", "spazioso", "silenziosa", "accogliente", "soddisfatto",
+	"soddisfatta", "bella", "bello", "consigliato", "tornerò", "cordiale",
+)
+
+var negativeWords = toSet(
+	// english
+	"bad", "dirty", "noisy", "rude", "expensive", "small", "broken", "slow", "cold", "smell", "terrible",
+	"awful", "poor", "disappointing", "uncomfortable", "worst",
+	// italian
+	"sporca", "sporco", "rumorosa", "rumoroso", "maleducato", "cara", "caro", "alto", "piccola", "piccolo",
+	"rotto", "rotta", "lento", "fredda", "freddo", "pessimo", "pessima", "scomodo", "scomoda", "deludente",
+)
+
+// words that flip the sentiment of the words that follow, e.g. "not clean", "not very good", "non perfetta"
+var negations = toSet("not", "no", "never", "non", "mai", "nessun")
+
+// a negation applies to the next sentiment word within this many words, and never past a punctuation mark
+const negationWindow = 3
+
+func toSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+}
+
+// clauses splits a comment at the punctuation marks, where a negation ends
+func clauses(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return unicode.IsPunct(r) && r != '\''
+	})
+}
+
+// sentiment scores a comment from -1 (only negative words) to 1 (only positive words)
+func sentiment(comment string) float64 {
+	positive, negative := 0, 0
+	for _, clause := range clauses(comment) {
+		negated := 0 // words left in the window of the last negation
+		for _, token := range tokenize(clause) {
+			if negations[token] {
+				negated = negationWindow
+				continue
+			}
+			polarity := 0
+			if positiveWords[token] {
+				polarity = 1
+			} else if negativeWords[token] {
+				polarity = -1
+			}
+			if negated > 0 {
+				negated--
+				if polarity != 0 {
+					polarity = -polarity
+					negated = 0
+				}
+			}
+			if polarity > 0 {
+				positive++
+			} else if polarity < 0 {
+				negative++
+			}
+		}
+	}
+	if positive+negative == 0 {
+		return 0
+	}
+	return float64(positive-negative) / float64(positive+negative)
+}
+
+// keywords returns the distinct meaningful words of a comment
+func keywords(comment string) []string {
+	var words []string
+	for _, token := range tokenize(comment) {
+		token = strings.Trim(token, "'")
+		if len([]rune(token)) < 3 || stopwords[token] || negations[token] || slices.Contains(words, token) {
+			continue
+		}
+		words = append(words, token)
+	}
+	return words
+}
+
+// GetReviewKeywords extracts the recurring words of the published review comments; with sentiment "negative"
+// (or "positive") only the reviews with a negative (or positive) sentiment or rating are considered
+func GetReviewKeywords(ctx context.Context, conn *pgx.Conn, sentimentFilter string, limit int) ([]models.ReviewKeyword, error) {
+	ctx, span := startSpan(ctx, "GetReviewKeywords")
+	defer span.End()
+	reviews, err := dal.GetReviewsByStatus(ctx, conn, models.ReviewPublished)
+	if err != nil {
+		return nil, err
+	}
+	type total struct {
+		mentions  int
+		rating    int
+		sentiment float64
+	}
+	totals := map[string]*total{}
+	for _, review := range reviews {
+		score := sentiment(review.Comment)
+		if sentimentFilter == "negative" && score >= 0 && review.Rating > 2 {
+			continue
+		}
+		if sentimentFilter == "positive" && score <= 0 && review.Rating < 4 {
+			continue
+		}
+		for _, word := range keywords(review.Comment) {
+			t, ok := totals[word]
+			if !ok {
+				t = &total{}
+				totals[word] = t
+			}
+			t.mentions++
+			t.rating += review.Rating
+			t.sentiment += score
+		}
+	}
+
+	result := []models.ReviewKeyword{}
+	for word, t := range totals {
+		result = append(result, models.ReviewKeyword{
+			Keyword:       word,
+			Mentions:      t.mentions,
+			AverageRating: math.Round(float64(t.rating)/float64(t.mentions)*100) / 100,
+			Sentiment:     math.Round(t.sentiment/float64(t.mentions)*100) / 100,
+		})
+	}
+	// most mentioned first, then alphabetically to keep the output stable
+	slices.SortFunc(result, func(a, b models.ReviewKeyword) int {
+		if a.Mentions != b.Mentions {
+			return b.Mentions - a.Mentions
+		}
+		return strings.Compare(a.Keyword, b.Keyword)
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
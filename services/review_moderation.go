@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"example/dal"
+	"example/models"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ReviewModeration configures the filter run on the comment of new and edited reviews
+type ReviewModeration struct {
+	BannedWords []string // profanity, matched as whole words ignoring case
+	MaxLinks    int      // comments with more links are considered spam
+	HoldAll     bool     // when true every review waits for a moderator, not only the flagged ones
+}
+
+func DefaultReviewModeration() ReviewModeration {
+	return ReviewModeration{
+		BannedWords: []string{
+			"fuck", "fucking", "shit", "bitch", "asshole", "bastard", "idiot",
+			"cazzo", "merda", "stronzo", "stronzi", "vaffanculo", "puttana", "coglione",
+		},
+		MaxLinks: 0,
+	}
+}
+
+var reviewModeration = DefaultReviewModeration()
+
+func SetReviewModeration(moderation ReviewModeration) {
+	reviewModeration = moderation
+}
+
+// check returns the reasons why a comment needs a moderator, none if it is clean
+func (m ReviewModeration) check(comment string) []string {
+	var reasons []string
+	found := map[string]bool{}
+	for _, token := range tokenize(comment) {
+		for _, banned := range m.BannedWords {
+			// a word repeated in the comment, or listed twice, is reported once
+			word := strings.ToLower(token)
+			if strings.EqualFold(token, strings.TrimSpace(banned)) && !found[word] {
+				found[word] = true
+				reasons = append(reasons, fmt.Sprintf("contains the banned word '%s'", token))
+			}
+		}
+	}
+	links := 0
+	for _, field := range strings.Fields(strings.ToLower(comment)) {
+		if strings.HasPrefix(field, "http://") || strings.HasPrefix(field, "https://") || strings.HasPrefix(field, "www.") {
+			links++
+		}
+	}
+	if links > m.MaxLinks {
+		reasons = append(reasons, "contains links")
+	}
+	if hasCharacterRun(comment, 5) {
+		reasons = append(reasons, "contains repeated characters")
+	}
+	letters, upper := 0, 0
+	for _, r := range comment {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	if letters >= 20 && upper*10 > letters*7 {
+		reasons = append(reasons, "is mostly uppercase")
+	}
+	return reasons
+}
+
+// hasCharacterRun reports whether the same non space character appears n times in a row, e.g. "!!!!!"
+func hasCharacterRun(s string, n int) bool {
+	var last rune
+	run := 0
+	for _, r := range s {
+		if r == last && !unicode.IsSpace(r) {
+			run++
+			if run >= n {
+				return true
+			}
+		} else {
+			last = r
+			run = 1
+		}
+	}
+	return false
+}
+
+// moderate sets the status of a new or edited review according to the filter
+func moderate(review *models.Review) {
+	reasons := reviewModeration.check(review.Comment)
+	switch {
+	case len(reasons) > 0:
+		review.Status = models.ReviewPending
+		review.ModerationReason = "comment " + strings.Join(reasons, ", ")
+	case reviewModeration.HoldAll:
+		review.Status = models.ReviewPending
+		review.ModerationReason = "waiting for a moderator"
+	default:
+		review.Status = models.ReviewPublished
+		review.ModerationReason = ""
+	}
+}
+
+// remoderate sets the status of an edited review: the comment goes through moderation again only when it changed,
+// and a review rejected by a moderator stays rejected whatever the guest writes
+func remoderate(review *models.Review, oldReview *models.Review) {
+	if oldReview.Status == models.ReviewRejected || review.Comment == oldReview.Comment {
+		review.Status = oldReview.Status
+		review.ModerationReason = oldReview.ModerationReason
+		return
+	}
+	moderate(review)
+}
+
+func ApproveReview(ctx context.Context, conn *pgx.Conn, reviewID int) error {
+	ctx, span := startSpan(ctx, "ApproveReview", attribute.Int("review.id", reviewID))
+	defer span.End()
+	return dal.UpdateReviewModeration(ctx, conn, reviewID, models.ReviewPublished, "")
+}
+
+func RejectReview(ctx context.Context, conn *pgx.Conn, reviewID int, reason string) error {
+	ctx, span := startSpan(ctx, "RejectReview", attribute.Int("review.id", reviewID))
+	defer span.End()
+	return dal.UpdateReviewModeration(ctx, conn, reviewID, models.ReviewRejected, reason)
+}
+
+func ReplyToReview(ctx context.Context, conn *pgx.Conn, reviewID int, reply string) error {
+	ctx, span := startSpan(ctx, "ReplyToReview", attribute.Int("review.id", reviewID))
+	defer span.End()
+	review, err := dal.GetReviewByID(ctx, conn, reviewID)
+	if err != nil {
+		return err
+	}
+	if review.Status == models.ReviewRejected {
+		return models.ValidationError{Message: "cannot reply to a rejected review"}
+	}
+	return dal.UpdateReviewReply(ctx, conn, reviewID, reply, Now())
+}
+
+func DeleteReviewReply(ctx context.Context, conn *pgx.Conn, reviewID int) error {
+	ctx, span := startSpan(ctx, "DeleteReviewReply", attribute.Int("review.id", reviewID))
+	defer span.End()
+	return dal.UpdateReviewReply(ctx, conn, reviewID, "", time.Time{})
+}
@@ -6,23 +6,43 @@ import (
 	"example/dal"
 	"example/models"
 	"net/http"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func GetAllRooms(ctx context.Context, conn *pgx.Conn) ([]models.Room, error) {
+	ctx, span := startSpan(ctx, "GetAllRooms")
+	defer span.End()
 	return dal.GetAllRooms(ctx, conn)
 }
 
 func GetRoomByID(ctx context.Context, conn *pgx.Conn, roomID int) (*models.Room, error) {
+	ctx, span := startSpan(ctx, "GetRoomByID", attribute.Int("room.id", roomID))
+	defer span.End()
 	return dal.GetRoomByID(ctx, conn, roomID)
 }
 
+// GetAvailableRooms returns the rooms that can be booked between the given dates, cheapest first
+func GetAvailableRooms(ctx context.Context, conn *pgx.Conn, startDate, endDate time.Time, roomType string, guests int) ([]models.Room, error) {
+	ctx, span := startSpan(ctx, "GetAvailableRooms")
+	defer span.End()
+	if !startDate.Before(endDate) {
+		return nil, models.ValidationError{Message: "start date must be before end date"}
+	}
+	return dal.GetAvailableRooms(ctx, conn, startDate, endDate, roomType, guests, Now())
+}
+
 func CreateRoom(ctx context.Context, conn *pgx.Conn, room *models.Room) error {
+	ctx, span := startSpan(ctx, "CreateRoom")
+	defer span.End()
 	return dal.CreateRoom(ctx, conn, room)
 }
 
 func UpdateRoomByID(ctx context.Context, conn *pgx.Conn, room *models.Room) (int, error) {
+	ctx, span := startSpan(ctx, "UpdateRoomByID")
+	defer span.End()
 	_, err := dal.GetRoomByID(ctx, conn, room.ID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -34,9 +54,13 @@ func UpdateRoomByID(ctx context.Context, conn *pgx.Conn, room *models.Room) (int
 }
 
 func PatchRoomByID(ctx context.Context, conn *pgx.Conn, roomID int, patch models.RoomPatch) error {
+	ctx, span := startSpan(ctx, "PatchRoomByID", attribute.Int("room.id", roomID))
+	defer span.End()
 	return dal.PatchRoomByID(ctx, conn, roomID, patch)
 }
 
 func DeleteRoomByID(ctx context.Context, conn *pgx.Conn, roomID int) error {
+	ctx, span := startSpan(ctx, "DeleteRoomByID", attribute.Int("room.id", roomID))
+	defer span.End()
 	return dal.DeleteRoomByID(ctx, conn, roomID)
 }
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"example/dal"
+	"example/models"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func GetRoomBlocksByRoomID(ctx context.Context, conn *pgx.Conn, roomID int) ([]models.RoomBlock, error) {
+	ctx, span := startSpan(ctx, "GetRoomBlocksByRoomID", attribute.Int("room.id", roomID))
+	defer span.End()
+	_, err := dal.GetRoomByID(ctx, conn, roomID)
+	if err != nil {
+		return nil, err
+	}
+	return dal.GetRoomBlocksByRoomID(ctx, conn, roomID)
+}
+
+// CreateRoomBlock takes a room out of order, it returns the active bookings that overlap the block
+// so that the guests can be moved to another room
+func CreateRoomBlock(ctx context.Context, conn *pgx.Conn, block *models.RoomBlock) ([]models.Booking, error) {
+	ctx, span := startSpan(ctx, "CreateRoomBlock")
+	defer span.End()
+	_, err := dal.GetRoomByID(ctx, conn, block.RoomID)
+	if err != nil {
+		return nil, err
+	}
+	if !block.StartDate.Before(block.EndDate) {
+		return nil, models.ValidationError{Message: "block start date must be before end date"}
+	}
+	if !block.EndDate.After(Today()) {
+		return nil, models.ValidationError{Message: "block end date must be in the future"}
+	}
+	blocks, err := dal.GetOverlappingRoomBlocks(ctx, conn, block.RoomID, block.StartDate, block.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) > 0 {
+		return nil, models.ValidationError{Code: "block_overlap", Message: "the room is already blocked in that period"}
+	}
+	err = dal.CreateRoomBlock(ctx, conn, block)
+	if err != nil {
+		return nil, err
+	}
+	return dal.GetOverlappingBookings(ctx, conn, block.RoomID, block.StartDate, block.EndDate)
+}
+
+// DeleteRoomBlock lifts a block, the waiting customers may get the room
+func DeleteRoomBlock(ctx context.Context, conn *pgx.Conn, roomID int, blockID int) error {
+	ctx, span := startSpan(ctx, "DeleteRoomBlock", attribute.Int("room.id", roomID), attribute.Int("room_block.id", blockID))
+	defer span.End()
+	_, err := dal.DeleteRoomBlock(ctx, conn, roomID, blockID)
+	if err != nil {
+		return err
+	}
+	matchWaitlist(ctx, conn)
+	return nil
+}
+
+// GetRoomBlockConflicts returns the bookings to relocate because of current and future blocks
+func GetRoomBlockConflicts(ctx context.Context, conn *pgx.Conn) ([]models.RoomBlockConflict, error) {
+	ctx, span := startSpan(ctx, "GetRoomBlockConflicts")
+	defer span.End()
+	return dal.GetRoomBlockConflicts(ctx, conn, Today())
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"example/dal"
+	"example/logging"
+	"example/models"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// JobFunc does the work of a background job and returns a short summary of what it did
+type JobFunc func(ctx context.Context, conn *pgx.Conn) (string, error)
+
+type job struct {
+	name     string
+	spec     string
+	schedule Schedule
+	run      JobFunc
+}
+
+var jobs []job
+
+// RegisterJob adds a job to the scheduler, jobs are registered at startup
+func RegisterJob(name string, spec string, run JobFunc) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+	if findJob(name) != nil {
+		return fmt.Errorf("job %s already registered", name)
+	}
+	jobs = append(jobs, job{name: name, spec: spec, schedule: schedule, run: run})
+	return nil
+}
+
+func findJob(name string) *job {
+	for i := range jobs {
+		if jobs[i].name == name {
+			return &jobs[i]
+		}
+	}
+	return nil
+}
+
+// GetJobs returns the registered jobs with their state and last run
+func GetJobs(ctx context.Context, conn *pgx.Conn) ([]models.Job, error) {
+	ctx, span := startSpan(ctx, "GetJobs")
+	defer span.End()
+	states, err := dal.GetJobStates(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	lastRuns, err := dal.GetLastJobRuns(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	var result []models.Job
+	for _, j := range jobs {
+		state := states[j.name]
+		state.Name = j.name
+		state.Schedule = j.spec
+		for _, run := range lastRuns {
+			if run.JobName == j.name {
+				state.LastRun = &run
+			}
+		}
+		result = append(result, state)
+	}
+	return result, nil
+}
+
+func GetJob(ctx context.Context, conn *pgx.Conn, name string) (*models.Job, error) {
+	ctx, span := startSpan(ctx, "GetJob", attribute.String("job.name", name))
+	defer span.End()
+	all, err := GetJobs(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	for _, j := range all {
+		if j.Name == name {
+			return &j, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+// PauseJob stops or restarts the scheduled runs of a job on all the replicas, it can still be triggered by hand
+func PauseJob(ctx context.Context, conn *pgx.Conn, name string, paused bool) (*models.Job, error) {
+	ctx, span := startSpan(ctx, "PauseJob", attribute.String("job.name", name))
+	defer span.End()
+	if findJob(name) == nil {
+		return nil, pgx.ErrNoRows
+	}
+	err := dal.SetJobPaused(ctx, conn, name, paused)
+	if err != nil {
+		return nil, err
+	}
+	return GetJob(ctx, conn, name)
+}
+
+func GetJobRuns(ctx context.Context, conn *pgx.Conn, name string) ([]models.JobRun, error) {
+	ctx, span := startSpan(ctx, "GetJobRuns", attribute.String("job.name", name))
+	defer span.End()
+	if findJob(name) == nil {
+		return nil, pgx.ErrNoRows
+	}
+	return dal.GetJobRuns(ctx, conn, name, 50)
+}
+
+// trigger asks the scheduler for a manual run of a job, the result is sent on done
+type trigger struct {
+	ctx  context.Context
+	job  *job
+	done chan triggerResult
+}
+
+type triggerResult struct {
+	run *models.JobRun
+	err error
+}
+
+// triggers hands the manual runs to the scheduler, so that they run on its connection like the scheduled ones and
+// not on the one of the request
+var triggers = make(chan trigger)
+
+// TriggerJob runs a job now and waits for it to finish, unless it is already running. The scheduler runs it, when
+// the context is done before the run is over TriggerJob returns but the run goes on.
+func TriggerJob(ctx context.Context, name string) (*models.JobRun, error) {
+	ctx, span := startSpan(ctx, "TriggerJob", attribute.String("job.name", name))
+	defer span.End()
+	j := findJob(name)
+	if j == nil {
+		return nil, pgx.ErrNoRows
+	}
+	t := trigger{ctx: ctx, job: j, done: make(chan triggerResult, 1)}
+	select {
+	case triggers <- t:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case result := <-t.done:
+		return result.run, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// RunScheduler checks every interval for the jobs that are due and runs them, and runs the jobs triggered by hand,
+// until the context is done. Every replica runs a scheduler, the advisory lock of a job and its next run saved in the
+// database make sure that each run happens once. When the context is done the jobs already started are completed
+// before it returns.
+func RunScheduler(ctx context.Context, conn *pgx.Conn, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	runCtx := context.WithoutCancel(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-triggers:
+			// the run continues the trace of the request, but it is not stopped when the client goes away
+			run, err := runTriggered(context.WithoutCancel(t.ctx), conn, t.job)
+			t.done <- triggerResult{run: run, err: err}
+		case <-ticker.C:
+			for i := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				err := runIfDue(runCtx, conn, &jobs[i], Now())
+				if err != nil {
+					logging.FromContext(ctx).Error("Error scheduling job", "job", jobs[i].name, "error", err)
+				}
+			}
+		}
+	}
+}
+
+func runTriggered(ctx context.Context, conn *pgx.Conn, j *job) (*models.JobRun, error) {
+	locked, err := dal.TryLockJob(ctx, conn, j.name)
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, models.ValidationError{Code: "job_running", Message: "the job is already running"}
+	}
+	defer unlockJob(ctx, conn, j.name)
+	return runJob(ctx, conn, j, models.JobTriggerManual)
+}
+
+func runIfDue(ctx context.Context, conn *pgx.Conn, j *job, now time.Time) error {
+	locked, err := dal.TryLockJob(ctx, conn, j.name)
+	if err != nil || !locked {
+		return err
+	}
+	defer unlockJob(ctx, conn, j.name)
+	// read the state with the lock held, another replica may have just run the job
+	states, err := dal.GetJobStates(ctx, conn)
+	if err != nil {
+		return err
+	}
+	state := states[j.name]
+	if state.Paused {
+		return nil
+	}
+	if state.NextRunAt == nil {
+		// a new job waits for the next time of its schedule
+		return dal.SetJobNextRun(ctx, conn, j.name, j.schedule.Next(now))
+	}
+	if now.Before(*state.NextRunAt) {
+		return nil
+	}
+	_, err = runJob(ctx, conn, j, models.JobTriggerSchedule)
+	if err != nil {
+		return err
+	}
+	return dal.SetJobNextRun(ctx, conn, j.name, j.schedule.Next(now))
+}
+
+// runJob runs a job with its lock held and records the run in the history, the job logs with its name and run
+func runJob(ctx context.Context, conn *pgx.Conn, j *job, trigger string) (*models.JobRun, error) {
+	// a scheduled run starts a trace of its own, a manual one continues the trace of the request
+	ctx, span := startSpan(ctx, "runJob", attribute.String("job.name", j.name), attribute.String("job.trigger", trigger))
+	defer span.End()
+	run := models.JobRun{JobName: j.name, Trigger: trigger}
+	err := dal.CreateJobRun(ctx, conn, &run)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("job_run.id", run.ID))
+	logger := logging.FromContext(ctx).With("job", j.name, "run_id", run.ID)
+	result, err := safeRun(logging.WithLogger(ctx, logger), conn, j.run)
+	run.Status = models.JobRunSucceeded
+	run.Result = result
+	if err != nil {
+		run.Status = models.JobRunFailed
+		run.Error = err.Error()
+		span.SetStatus(codes.Error, run.Error)
+		logger.Error("Job failed", "trigger", trigger, "error", err)
+	} else {
+		logger.Info("Job succeeded", "trigger", trigger, "result", result)
+	}
+	return &run, dal.FinishJobRun(ctx, conn, &run)
+}
+
+// safeRun turns a panic of the job into an error, so that a broken job does not stop the scheduler
+func safeRun(ctx context.Context, conn *pgx.Conn, run JobFunc) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return run(ctx, conn)
+}
+
+func unlockJob(ctx context.Context, conn *pgx.Conn, name string) {
+	err := dal.UnlockJob(ctx, conn, name)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error unlocking job", "job", name, "error", err)
+	}
+}
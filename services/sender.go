@@ -0,0 +1,160 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"example/models"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sender delivers emails, the implementation is chosen at startup
+type Sender interface {
+	Send(ctx context.Context, email models.Email) error
+}
+
+const defaultSMTPTimeout = 10 * time.Second
+
+// SMTPSender sends the emails through an SMTP server, e.g. Mailpit in development
+type SMTPSender struct {
+	Addr     string // host:port of the server
+	From     string
+	Username string // no authentication when empty
+	Password string
+	Timeout  time.Duration // for the whole delivery, 10 seconds when 0
+}
+
+// Send delivers the email like smtp.SendMail, within the timeout and until the context is done, so that a slow
+// server does not hold the send-notifications job
+func (s *SMTPSender) Send(ctx context.Context, email models.Email) error {
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return err
+	}
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = defaultSMTPTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	// the deadline does not follow the cancellation of the context
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: host})
+		if err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		err = client.Auth(smtp.PlainAuth("", s.Username, s.Password, host))
+		if err != nil {
+			return err
+		}
+	}
+	err = client.Mail(s.From)
+	if err != nil {
+		return err
+	}
+	err = client.Rcpt(email.To)
+	if err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(formatEmail(s.From, email))
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// FileSender writes every email to a .eml file in a directory instead of sending it
+type FileSender struct {
+	Dir  string
+	From string
+}
+
+func (s *FileSender) Send(ctx context.Context, email models.Email) error {
+	err := os.MkdirAll(s.Dir, 0o755)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(s.Dir, time.Now().Format("20060102-150405-")+"*.eml")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(formatEmail(s.From, email))
+	return err
+}
+
+// MemorySender keeps the emails in memory, for tests
+type MemorySender struct {
+	mu     sync.Mutex
+	emails []models.Email
+}
+
+func (s *MemorySender) Send(ctx context.Context, email models.Email) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.emails = append(s.emails, email)
+	return nil
+}
+
+// Sent returns the emails sent so far
+func (s *MemorySender) Sent() []models.Email {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.Email(nil), s.emails...)
+}
+
+func (s *MemorySender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.emails = nil
+}
+
+// formatEmail builds a plain text message with its headers
+func formatEmail(from string, email models.Email) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", email.To)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(email.Body, "\n", "\r\n"))
+	return msg.Bytes()
+}
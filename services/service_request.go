@@ -5,44 +5,71 @@ import (
 	"errors"
 	"example/dal"
 	"example/models"
+	"fmt"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func GetAllServiceRequests(ctx context.Context, conn *pgx.Conn) ([]models.ServiceRequest, error) {
+	ctx, span := startSpan(ctx, "GetAllServiceRequests")
+	defer span.End()
 	return dal.GetAllServiceRequests(ctx, conn)
 }
 
 func GetServiceRequestByID(ctx context.Context, conn *pgx.Conn, requestID int) (*models.ServiceRequest, error) {
+	ctx, span := startSpan(ctx, "GetServiceRequestByID", attribute.Int("service_request.id", requestID))
+	defer span.End()
 	return dal.GetServiceRequestByID(ctx, conn, requestID)
 }
 
 func CreateServiceRequest(ctx context.Context, conn *pgx.Conn, request *models.ServiceRequest) error {
+	ctx, span := startSpan(ctx, "CreateServiceRequest")
+	defer span.End()
 	err := validateServiceRequest(ctx, conn, request)
 	if err != nil {
 		return err
 	}
+	err = assignStaff(ctx, conn, request)
+	if err != nil {
+		return err
+	}
 	return dal.CreateServiceRequest(ctx, conn, request)
 }
 
 func UpdateServiceRequestByID(ctx context.Context, conn *pgx.Conn, request *models.ServiceRequest) (int, error) {
+	ctx, span := startSpan(ctx, "UpdateServiceRequestByID")
+	defer span.End()
 	err := validateServiceRequest(ctx, conn, request)
 	if err != nil {
 		return 0, err
 	}
-	_, err = dal.GetServiceRequestByID(ctx, conn, request.ID)
+	oldRequest, err := dal.GetServiceRequestByID(ctx, conn, request.ID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
+			err = assignStaff(ctx, conn, request)
+			if err != nil {
+				return 0, err
+			}
 			return http.StatusCreated, dal.CreateServiceRequest(ctx, conn, request)
 		}
 		return 0, err
 	}
+	// keep the assigned staff member if they can still serve the request
+	request.StaffID = oldRequest.StaffID
+	err = assignStaff(ctx, conn, request)
+	if err != nil {
+		return 0, err
+	}
 	return http.StatusOK, dal.UpdateServiceRequestByID(ctx, conn, request)
 }
 
 func PatchServiceRequestByID(ctx context.Context, conn *pgx.Conn, requestID int, patch models.ServiceRequestPatch) error {
+	ctx, span := startSpan(ctx, "PatchServiceRequestByID", attribute.Int("service_request.id", requestID))
+	defer span.End()
 	// first check that the patch is valid
 	oldRequest, err := dal.GetServiceRequestByID(ctx, conn, requestID)
 	if err != nil {
@@ -56,28 +83,144 @@ func PatchServiceRequestByID(ctx context.Context, conn *pgx.Conn, requestID int,
 		oldRequest.ServiceID = *patch.ServiceID
 	}
 	if patch.Date != nil {
-		date, err := time.Parse("2006-01-02", *patch.Date)
+		date, err := models.ParseDate(*patch.Date)
 		if err != nil {
 			return models.ValidationError{Message: "service request date must be in YYYY-MM-DD format"}
 		}
 		oldRequest.Date = date
 	}
+	if patch.StartTime != nil {
+		startTime, err := models.ParseClock(*patch.StartTime)
+		if err != nil {
+			return models.ValidationError{Message: "service request start time must be in HH:MM format"}
+		}
+		oldRequest.StartTime = startTime
+	}
 
 	err = validateServiceRequest(ctx, conn, oldRequest)
 	if err != nil {
 		return err
 	}
+	staffID := oldRequest.StaffID
+	err = assignStaff(ctx, conn, oldRequest)
+	if err != nil {
+		return err
+	}
 
-	return dal.PatchServiceRequestByID(ctx, conn, requestID, patch)
+	err = dal.PatchServiceRequestByID(ctx, conn, requestID, patch)
+	if err != nil {
+		return err
+	}
+	if oldRequest.StaffID != staffID {
+		return dal.UpdateServiceRequestStaff(ctx, conn, requestID, oldRequest.StaffID)
+	}
+	return nil
 }
 
 func DeleteServiceRequestByID(ctx context.Context, conn *pgx.Conn, requestID int) error {
+	ctx, span := startSpan(ctx, "DeleteServiceRequestByID", attribute.Int("service_request.id", requestID))
+	defer span.End()
 	return dal.DeleteServiceRequestByID(ctx, conn, requestID)
 }
 
+// AssignServiceRequest assigns a request to the given staff member, who must be qualified and free at that time
+func AssignServiceRequest(ctx context.Context, conn *pgx.Conn, requestID int, staffID int) (*models.ServiceRequest, error) {
+	ctx, span := startSpan(ctx, "AssignServiceRequest", attribute.Int("service_request.id", requestID), attribute.Int("staff.id", staffID))
+	defer span.End()
+	request, err := dal.GetServiceRequestByID(ctx, conn, requestID)
+	if err != nil {
+		return nil, err
+	}
+	member, err := dal.GetStaffByID(ctx, conn, staffID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ValidationError{Message: "staff member does not exist"}
+		}
+		return nil, err
+	}
+	service, err := dal.GetHotelServiceByID(ctx, conn, request.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	if !member.HasSkill(service.Type) {
+		return nil, models.ValidationError{Code: "staff_not_qualified", Message: fmt.Sprintf("the staff member cannot perform %s", service.Type)}
+	}
+	staffIDs, err := availableStaff(ctx, conn, request, service)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(staffIDs, staffID) {
+		return nil, models.ValidationError{Code: "staff_unavailable", Message: "the staff member is not on shift or is busy at the requested time"}
+	}
+	request.StaffID = &staffID
+	return request, dal.UpdateServiceRequestStaff(ctx, conn, requestID, request.StaffID)
+}
+
+// ReassignServiceRequest moves a request to the least loaded available staff member other than the current one
+func ReassignServiceRequest(ctx context.Context, conn *pgx.Conn, requestID int) (*models.ServiceRequest, error) {
+	ctx, span := startSpan(ctx, "ReassignServiceRequest", attribute.Int("service_request.id", requestID))
+	defer span.End()
+	request, err := dal.GetServiceRequestByID(ctx, conn, requestID)
+	if err != nil {
+		return nil, err
+	}
+	service, err := dal.GetHotelServiceByID(ctx, conn, request.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	staffIDs, err := availableStaff(ctx, conn, request, service)
+	if err != nil {
+		return nil, err
+	}
+	if request.StaffID != nil {
+		staffIDs = slices.DeleteFunc(staffIDs, func(id int) bool { return id == *request.StaffID })
+	}
+	if len(staffIDs) == 0 {
+		return nil, models.ValidationError{Code: "no_staff_available", Message: "no other staff member can serve the request at the requested time"}
+	}
+	request.StaffID = &staffIDs[0]
+	return request, dal.UpdateServiceRequestStaff(ctx, conn, requestID, request.StaffID)
+}
+
+func UnassignServiceRequest(ctx context.Context, conn *pgx.Conn, requestID int) error {
+	ctx, span := startSpan(ctx, "UnassignServiceRequest", attribute.Int("service_request.id", requestID))
+	defer span.End()
+	return dal.UpdateServiceRequestStaff(ctx, conn, requestID, nil)
+}
+
+// assignStaff keeps the staff member of the request if they are still available,
+// otherwise picks the least loaded available one. The request stays unassigned when nobody is available.
+func assignStaff(ctx context.Context, conn *pgx.Conn, request *models.ServiceRequest) error {
+	service, err := dal.GetHotelServiceByID(ctx, conn, request.ServiceID)
+	if err != nil {
+		return err
+	}
+	staffIDs, err := availableStaff(ctx, conn, request, service)
+	if err != nil {
+		return err
+	}
+	if request.StaffID != nil && slices.Contains(staffIDs, *request.StaffID) {
+		return nil
+	}
+	request.StaffID = nil
+	if len(staffIDs) > 0 {
+		request.StaffID = &staffIDs[0]
+	}
+	return nil
+}
+
+func availableStaff(ctx context.Context, conn *pgx.Conn, request *models.ServiceRequest, service *models.HotelService) ([]int, error) {
+	end := request.StartTime + time.Duration(service.Duration)*time.Minute
+	return dal.GetAvailableStaff(ctx, conn, service.Type, request.Date, request.StartTime, end, request.ID)
+}
+
 func validateServiceRequest(ctx context.Context, conn *pgx.Conn, request *models.ServiceRequest) error {
-	if request.Date.Before(time.Now()) {
-		return models.ValidationError{Message: "service request date must be in the future"}
+	businessDate, err := BusinessDate(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if request.Date.Before(businessDate) {
+		return models.ValidationError{Message: "service request date cannot be before the business date " + businessDate.Format(models.DateFormat)}
 	}
 	customer, err := dal.GetCustomerByID(ctx, conn, request.CustomerID)
 	if err != nil {
@@ -100,22 +243,33 @@ func validateServiceRequest(ctx context.Context, conn *pgx.Conn, request *models
 			}
 		}
 	}
-	_, err = dal.GetHotelServiceByID(ctx, conn, request.ServiceID)
+	service, err := dal.GetHotelServiceByID(ctx, conn, request.ServiceID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.ValidationError{Message: "service does not exist"}
 		}
 		return err
 	}
-	requests, err := dal.GetAllServiceRequests(ctx, conn)
+	opening, closing, err := service.OpeningHours()
+	if err != nil {
+		return err
+	}
+	duration := time.Duration(service.Duration) * time.Minute
+	if request.StartTime < opening || request.StartTime+duration > closing {
+		return models.ValidationError{Code: "outside_opening_hours", Message: fmt.Sprintf("the service is available from %s to %s", service.OpeningTime, service.ClosingTime)}
+	}
+	requests, err := dal.GetServiceRequestsByServiceAndDate(ctx, conn, request.ServiceID, request.Date)
 	if err != nil {
 		return err
 	}
 	for _, r := range requests {
-		if r.CustomerID == request.CustomerID && r.ServiceID == request.ServiceID && r.Date.Equal(request.Date) && r.ID != request.ID {
+		if r.CustomerID == request.CustomerID && r.StartTime == request.StartTime && r.ID != request.ID {
 			return models.ValidationError{Message: "duplicate service request"}
 		}
 	}
+	if peakRequests(requests, request.StartTime, duration, request.ID) >= service.Capacity {
+		return models.ValidationError{Code: "slot_full", Message: "the service is fully booked at the requested time"}
+	}
 
 	return nil
 }
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"example/dal"
+	"example/models"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func GetAllStaff(ctx context.Context, conn *pgx.Conn) ([]models.Staff, error) {
+	ctx, span := startSpan(ctx, "GetAllStaff")
+	defer span.End()
+	return dal.GetAllStaff(ctx, conn)
+}
+
+func GetStaffByID(ctx context.Context, conn *pgx.Conn, staffID int) (*models.Staff, error) {
+	ctx, span := startSpan(ctx, "GetStaffByID", attribute.Int("staff.id", staffID))
+	defer span.End()
+	return dal.GetStaffByID(ctx, conn, staffID)
+}
+
+func CreateStaff(ctx context.Context, conn *pgx.Conn, member *models.Staff) error {
+	ctx, span := startSpan(ctx, "CreateStaff")
+	defer span.End()
+	return dal.CreateStaff(ctx, conn, member)
+}
+
+func UpdateStaffByID(ctx context.Context, conn *pgx.Conn, member *models.Staff) (int, error) {
+	ctx, span := startSpan(ctx, "UpdateStaffByID")
+	defer span.End()
+	_, err := dal.GetStaffByID(ctx, conn, member.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return http.StatusCreated, dal.CreateStaff(ctx, conn, member)
+		}
+		return 0, err
+	}
+	return http.StatusOK, dal.UpdateStaffByID(ctx, conn, member)
+}
+
+func DeleteStaffByID(ctx context.Context, conn *pgx.Conn, staffID int) error {
+	ctx, span := startSpan(ctx, "DeleteStaffByID", attribute.Int("staff.id", staffID))
+	defer span.End()
+	return dal.DeleteStaffByID(ctx, conn, staffID)
+}
+
+func CreateStaffShift(ctx context.Context, conn *pgx.Conn, shift *models.StaffShift) error {
+	ctx, span := startSpan(ctx, "CreateStaffShift")
+	defer span.End()
+	_, err := dal.GetStaffByID(ctx, conn, shift.StaffID)
+	if err != nil {
+		return err
+	}
+	if shift.StartTime >= shift.EndTime {
+		return models.ValidationError{Code: "invalid_shift", Message: "shift start time must be before the end time"}
+	}
+	shifts, err := dal.GetStaffShifts(ctx, conn, shift.StaffID, shift.Date, shift.Date)
+	if err != nil {
+		return err
+	}
+	for _, s := range shifts {
+		if s.StartTime < shift.EndTime && shift.StartTime < s.EndTime {
+			return models.ValidationError{Code: "shift_overlap", Message: "the staff member already has a shift at that time"}
+		}
+	}
+	return dal.CreateStaffShift(ctx, conn, shift)
+}
+
+// DeleteStaffShift deletes a shift, the requests of that day that are no longer covered are assigned to someone else
+func DeleteStaffShift(ctx context.Context, conn *pgx.Conn, staffID int, shiftID int) error {
+	ctx, span := startSpan(ctx, "DeleteStaffShift", attribute.Int("staff.id", staffID), attribute.Int("staff_shift.id", shiftID))
+	defer span.End()
+	date, err := dal.DeleteStaffShift(ctx, conn, staffID, shiftID)
+	if err != nil {
+		return err
+	}
+	requests, err := dal.GetServiceRequestsByStaffID(ctx, conn, staffID, date, date)
+	if err != nil {
+		return err
+	}
+	for _, request := range requests {
+		err = assignStaff(ctx, conn, &request)
+		if err != nil {
+			return err
+		}
+		if request.StaffID == nil || *request.StaffID != staffID {
+			err = dal.UpdateServiceRequestStaff(ctx, conn, request.ID, request.StaffID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetStaffSchedule returns the shifts and the assigned requests of a staff member between two dates, both included
+func GetStaffSchedule(ctx context.Context, conn *pgx.Conn, staffID int, from, to time.Time) (*models.StaffSchedule, error) {
+	ctx, span := startSpan(ctx, "GetStaffSchedule", attribute.Int("staff.id", staffID))
+	defer span.End()
+	if to.Before(from) {
+		return nil, models.ValidationError{Message: "schedule start date must be before the end date"}
+	}
+	_, err := dal.GetStaffByID(ctx, conn, staffID)
+	if err != nil {
+		return nil, err
+	}
+	shifts, err := dal.GetStaffShifts(ctx, conn, staffID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := dal.GetServiceRequestsByStaffID(ctx, conn, staffID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	schedule := models.StaffSchedule{
+		StaffID:     staffID,
+		Shifts:      []models.StaffShiftDTO{},
+		Assignments: []models.ServiceRequestDTO{},
+	}
+	for _, shift := range shifts {
+		schedule.Shifts = append(schedule.Shifts, shift.ToDTO())
+	}
+	for _, request := range requests {
+		schedule.Assignments = append(schedule.Assignments, request.ToDTO())
+	}
+	return &schedule, nil
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"example/dal"
+	"example/models"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func GetBookingSegments(ctx context.Context, conn *pgx.Conn, bookingID int) ([]models.BookingSegment, error) {
+	ctx, span := startSpan(ctx, "GetBookingSegments", attribute.Int("booking.id", bookingID))
+	defer span.End()
+	_, err := dal.GetBookingByID(ctx, conn, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	return dal.GetBookingSegments(ctx, conn, bookingID)
+}
+
+func GetFolio(ctx context.Context, conn *pgx.Conn, bookingID int) ([]models.FolioCharge, error) {
+	ctx, span := startSpan(ctx, "GetFolio", attribute.Int("booking.id", bookingID))
+	defer span.End()
+	_, err := dal.GetBookingByID(ctx, conn, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	return dal.GetFolioCharges(ctx, conn, bookingID)
+}
+
+// MoveBooking moves the nights of a stay from the given date to another room, a zero date moves
+// the remaining nights (all of them when the stay has not started yet)
+func MoveBooking(ctx context.Context, conn *pgx.Conn, bookingID int, roomID int, from time.Time) (*models.Booking, error) {
+	ctx, span := startSpan(ctx, "MoveBooking", attribute.Int("booking.id", bookingID), attribute.Int("room.id", roomID))
+	defer span.End()
+	booking, err := dal.GetBookingByID(ctx, conn, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Status != models.BookingConfirmed && booking.Status != models.BookingCheckedIn {
+		return nil, models.ValidationError{Code: "invalid_booking_status", Message: "only confirmed and checked in bookings can be moved, booking is " + booking.Status}
+	}
+	today := Today()
+	if from.IsZero() {
+		from = booking.StartDate
+		if from.Before(today) {
+			from = today
+		}
+	}
+	if from.Before(today) || from.Before(booking.StartDate) || !from.Before(booking.EndDate) {
+		return nil, models.ValidationError{Code: "invalid_move_date", Message: "the move date must be a future night of the stay"}
+	}
+	room, err := dal.GetRoomByID(ctx, conn, roomID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ValidationError{Message: "room does not exist"}
+		}
+		return nil, err
+	}
+	if booking.Guests() > room.Capacity {
+		return nil, models.ValidationError{Code: "over_capacity", Message: fmt.Sprintf("the room hosts at most %d guests", room.Capacity)}
+	}
+	err = checkRoomAvailable(ctx, conn, roomID, from, booking.EndDate, booking.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	segments, err := dal.GetBookingSegments(ctx, conn, booking.ID)
+	if err != nil {
+		return nil, err
+	}
+	var moved []models.BookingSegment
+	var oldRoomID int
+	for _, segment := range segments {
+		if !segment.StartDate.Before(from) {
+			if oldRoomID == 0 {
+				oldRoomID = segment.RoomID
+			}
+			continue
+		}
+		if segment.EndDate.After(from) {
+			oldRoomID = segment.RoomID
+			segment.EndDate = from
+		}
+		moved = append(moved, segment)
+	}
+	if oldRoomID == roomID {
+		return nil, models.ValidationError{Code: "same_room", Message: "the guest is already in that room"}
+	}
+	moved = append(moved, models.BookingSegment{BookingID: booking.ID, RoomID: roomID, StartDate: from, EndDate: booking.EndDate})
+	err = dal.UpdateBookingSegments(ctx, conn, booking, mergeSegments(moved))
+	if err != nil {
+		return nil, err
+	}
+	// a guest already in the hotel leaves the old room to the housekeeping
+	if booking.Status == models.BookingCheckedIn && !from.After(today) {
+		err = markRoomDirty(ctx, conn, oldRoomID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return booking, dal.RepriceFolio(ctx, conn, booking.ID)
+}
+
+// ChangeStay extends or shortens a stay, the guest stays in the room of the last night
+func ChangeStay(ctx context.Context, conn *pgx.Conn, bookingID int, endDate time.Time) (*models.Booking, error) {
+	ctx, span := startSpan(ctx, "ChangeStay", attribute.Int("booking.id", bookingID))
+	defer span.End()
+	booking, err := dal.GetBookingByID(ctx, conn, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Status != models.BookingConfirmed && booking.Status != models.BookingCheckedIn {
+		return nil, models.ValidationError{Code: "invalid_booking_status", Message: "only confirmed and checked in bookings can be changed, booking is " + booking.Status}
+	}
+	today := Today()
+	if !endDate.After(booking.StartDate) {
+		return nil, models.ValidationError{Message: "end date must be after the start date"}
+	}
+	if endDate.Before(today) {
+		return nil, models.ValidationError{Code: "stay_end_in_past", Message: "end date cannot be in the past"}
+	}
+	if endDate.Equal(booking.EndDate) {
+		return booking, nil
+	}
+
+	segments, err := dal.GetBookingSegments(ctx, conn, booking.ID)
+	if err != nil {
+		return nil, err
+	}
+	last := segments[len(segments)-1]
+	if endDate.After(booking.EndDate) {
+		err = checkRoomAvailable(ctx, conn, last.RoomID, booking.EndDate, endDate, booking.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var changed []models.BookingSegment
+	for _, segment := range segments {
+		if !segment.StartDate.Before(endDate) {
+			continue
+		}
+		changed = append(changed, segment)
+	}
+	changed[len(changed)-1].EndDate = endDate
+	err = dal.UpdateBookingSegments(ctx, conn, booking, changed)
+	if err != nil {
+		return nil, err
+	}
+	return booking, dal.RepriceFolio(ctx, conn, booking.ID)
+}
+
+// checkRoomAvailable returns a validation error when a room is blocked, held or taken by another booking between the dates
+func checkRoomAvailable(ctx context.Context, conn *pgx.Conn, roomID int, startDate, endDate time.Time, bookingID int) error {
+	blocks, err := dal.GetOverlappingRoomBlocks(ctx, conn, roomID, startDate, endDate)
+	if err != nil {
+		return err
+	}
+	if len(blocks) > 0 {
+		block := blocks[0]
+		return models.ValidationError{Code: "room_blocked", Message: fmt.Sprintf("the room is out of order from %s to %s: %s", block.StartDate.Format(models.DateFormat), block.EndDate.Format(models.DateFormat), block.Reason)}
+	}
+	stays, err := dal.GetOverlappingStays(ctx, conn, roomID, startDate, endDate, bookingID)
+	if err != nil {
+		return err
+	}
+	if len(stays) > 0 {
+		return models.ValidationError{Code: "room_unavailable", Message: "the room is booked by another guest in that period"}
+	}
+	holds, err := dal.GetOverlappingHolds(ctx, conn, roomID, startDate, endDate, 0, Now())
+	if err != nil {
+		return err
+	}
+	if len(holds) > 0 {
+		return models.ValidationError{Code: "room_held", Message: "the room is held for another guest in that period"}
+	}
+	return nil
+}
+
+// mergeSegments joins consecutive segments in the same room
+func mergeSegments(segments []models.BookingSegment) []models.BookingSegment {
+	var merged []models.BookingSegment
+	for _, segment := range segments {
+		if n := len(merged); n > 0 && merged[n-1].RoomID == segment.RoomID && merged[n-1].EndDate.Equal(segment.StartDate) {
+			merged[n-1].EndDate = segment.EndDate
+			continue
+		}
+		merged = append(merged, segment)
+	}
+	return merged
+}
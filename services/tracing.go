@@ -0,0 +1,16 @@
+package services
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("example/services")
+
+// startSpan starts the span of a service function, with the IDs it works on as attributes
+func startSpan(ctx context.Context, function string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "services."+function, trace.WithAttributes(attributes...))
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"example/dal"
+	"example/logging"
+	"example/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func GetWaitlist(ctx context.Context, conn *pgx.Conn, status string) ([]models.WaitlistEntry, error) {
+	ctx, span := startSpan(ctx, "GetWaitlist")
+	defer span.End()
+	return dal.GetWaitlist(ctx, conn, status)
+}
+
+func GetWaitlistEntryByID(ctx context.Context, conn *pgx.Conn, entryID int) (*models.WaitlistEntry, error) {
+	ctx, span := startSpan(ctx, "GetWaitlistEntryByID", attribute.Int("waitlist_entry.id", entryID))
+	defer span.End()
+	return dal.GetWaitlistEntryByID(ctx, conn, entryID)
+}
+
+func CreateWaitlistEntry(ctx context.Context, conn *pgx.Conn, entry *models.WaitlistEntry) error {
+	ctx, span := startSpan(ctx, "CreateWaitlistEntry")
+	defer span.End()
+	if !entry.StartDate.Before(entry.EndDate) {
+		return models.ValidationError{Message: "start date must be before end date"}
+	}
+	today := Today()
+	if entry.StartDate.Before(today) {
+		return models.ValidationError{Message: "start date cannot be in the past"}
+	}
+	_, err := dal.GetCustomerByID(ctx, conn, entry.CustomerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ValidationError{Message: "customer does not exist"}
+		}
+		return err
+	}
+	return dal.CreateWaitlistEntry(ctx, conn, entry)
+}
+
+func DeleteWaitlistEntry(ctx context.Context, conn *pgx.Conn, entryID int) error {
+	ctx, span := startSpan(ctx, "DeleteWaitlistEntry", attribute.Int("waitlist_entry.id", entryID))
+	defer span.End()
+	return dal.DeleteWaitlistEntry(ctx, conn, entryID)
+}
+
+// waitlistHoldTTL is how long a room offered to a waiting customer stays held
+var waitlistHoldTTL = 24 * time.Hour
+
+// MatchWaitlist offers the rooms that are available to the waiting customers, first come first served.
+// The offered room is held for the customer and every offer emits an event.
+func MatchWaitlist(ctx context.Context, conn *pgx.Conn) ([]models.WaitlistEntry, error) {
+	ctx, span := startSpan(ctx, "MatchWaitlist")
+	defer span.End()
+	now := Now()
+	today := models.DateOf(now, location)
+	entries, err := dal.GetWaitingEntries(ctx, conn, today)
+	if err != nil {
+		return nil, err
+	}
+	var offered []models.WaitlistEntry
+	for _, entry := range entries {
+		rooms, err := dal.GetAvailableRooms(ctx, conn, entry.StartDate, entry.EndDate, entry.RoomType, entry.Guests, now)
+		if err != nil {
+			return nil, err
+		}
+		if len(rooms) == 0 {
+			continue
+		}
+		// the hold keeps the room for the customer, and away from the next entries
+		hold := models.Hold{RoomID: rooms[0].ID, CustomerID: &entry.CustomerID, StartDate: entry.StartDate, EndDate: entry.EndDate, ExpiresAt: now.Add(waitlistHoldTTL)}
+		entry.Status = models.WaitlistOffered
+		entry.RoomID = &hold.RoomID
+		entry.OfferedAt = &now
+		err = dal.OfferWaitlistRoom(ctx, conn, &entry, &hold)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// offered or held by a matching running at the same time
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		offered = append(offered, entry)
+		emitEvent(ctx, conn, models.Event{Type: models.EventWaitlistRoomAvailable, CustomerID: entry.CustomerID, Payload: entry})
+	}
+	return offered, nil
+}
+
+// matchWaitlist offers the rooms freed by an operation that is already done, a failure is logged instead of failing
+// the operation
+func matchWaitlist(ctx context.Context, conn *pgx.Conn) {
+	_, err := MatchWaitlist(ctx, conn)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error matching waitlist", "error", err)
+	}
+}